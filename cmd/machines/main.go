@@ -30,8 +30,12 @@ import (
 
 	//register indexer
 	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/bmcinfo"
+	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/grpc"
+	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/inventory"
 	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/machineinfo"
 	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/machinetype"
+	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/machineview"
+	_ "github.com/onmetal/k8s-machines/pkg/servers/machineindexer/secondary"
 )
 
 func main() {
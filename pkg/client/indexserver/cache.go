@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package indexserver
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheKey identifies a cached lookup result.
+type CacheKey struct {
+	Index     string
+	Namespace string
+	Kind      string
+	Key       string
+	Full      bool
+}
+
+// Cache stores the objects found by single key lookups. Implementations
+// must be safe for concurrent use. Cached objects must not be modified.
+type Cache interface {
+	Get(key CacheKey) *Object
+	Put(key CacheKey, obj *Object)
+	// Remove removes all entries matched by the given function.
+	Remove(match func(key CacheKey, obj *Object) bool)
+	Flush()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type noCache struct{}
+
+// NoCache is a Cache not storing anything.
+var NoCache Cache = noCache{}
+
+func (noCache) Get(CacheKey) *Object                        { return nil }
+func (noCache) Put(CacheKey, *Object)                       {}
+func (noCache) Remove(func(key CacheKey, obj *Object) bool) {}
+func (noCache) Flush()                                      {}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type cacheEntry struct {
+	key     CacheKey
+	obj     *Object
+	expires time.Time
+}
+
+// lruCache is a bounded LRU cache whose entries expire after a ttl.
+type lruCache struct {
+	lock sync.Mutex
+	max  int
+	ttl  time.Duration
+	now  func() time.Time
+
+	list    *list.List
+	entries map[CacheKey]*list.Element
+}
+
+var _ Cache = &lruCache{}
+
+// NewCache creates a cache keeping at most max (0=unbounded) entries
+// for the given ttl (0=no expiry).
+func NewCache(max int, ttl time.Duration) Cache {
+	return &lruCache{
+		max:     max,
+		ttl:     ttl,
+		now:     time.Now,
+		list:    list.New(),
+		entries: map[CacheKey]*list.Element{},
+	}
+}
+
+func (this *lruCache) Get(key CacheKey) *Object {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	elem := this.entries[key]
+	if elem == nil {
+		return nil
+	}
+	e := elem.Value.(*cacheEntry)
+	if this.ttl > 0 && !this.now().Before(e.expires) {
+		this.remove(elem)
+		return nil
+	}
+	this.list.MoveToFront(elem)
+	return e.obj
+}
+
+func (this *lruCache) Put(key CacheKey, obj *Object) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if elem := this.entries[key]; elem != nil {
+		this.remove(elem)
+	}
+	this.entries[key] = this.list.PushFront(&cacheEntry{key: key, obj: obj, expires: this.now().Add(this.ttl)})
+	for this.max > 0 && this.list.Len() > this.max {
+		this.remove(this.list.Back())
+	}
+}
+
+func (this *lruCache) Remove(match func(key CacheKey, obj *Object) bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for key, elem := range this.entries {
+		if match(key, elem.Value.(*cacheEntry).obj) {
+			this.remove(elem)
+		}
+	}
+}
+
+func (this *lruCache) Flush() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.list.Init()
+	this.entries = map[CacheKey]*list.Element{}
+}
+
+func (this *lruCache) remove(elem *list.Element) {
+	delete(this.entries, elem.Value.(*cacheEntry).key)
+	this.list.Remove(elem)
+}
@@ -0,0 +1,435 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package indexserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Options configure a Client.
+type Options struct {
+	// HTTPClient is used to send the requests (default http.DefaultClient).
+	HTTPClient *http.Client
+	// Auth adds the credentials to the requests (default none).
+	Auth Authenticator
+	// Cache stores the results of single key lookups (default NoCache).
+	// It is invalidated by the events received by Watch.
+	Cache Cache
+}
+
+// Client is a client for the index server.
+type Client struct {
+	base   *url.URL
+	client *http.Client
+	auth   Authenticator
+	cache  Cache
+
+	// generation is incremented by every invalidation. Results of
+	// requests overlapping an invalidation are not cached.
+	lock       sync.Mutex
+	generation int64
+}
+
+// NewClient creates a client for the index server reachable
+// with the given base url, for example http://indexer:8080.
+func NewClient(base string, opts *Options) (*Client, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index server url %q: %s", base, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid index server url %q: http(s) url required", base)
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	c := &Client{
+		base:   u,
+		client: opts.HTTPClient,
+		auth:   opts.Auth,
+		cache:  opts.Cache,
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.cache == nil {
+		c.cache = NoCache
+	}
+	return c, nil
+}
+
+// Cache returns the cache used by the client.
+func (this *Client) Cache() Cache {
+	return this.cache
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Lookups
+
+// Request describes the keys for a lookup. All keys must
+// match the same object.
+type Request struct {
+	MACs      []string
+	UUIDs     []string
+	IPs       []string
+	Serials   []string
+	AssetTags []string
+	// Namespace restricts the lookup to a namespace.
+	Namespace string
+	// Full requests the object spec.
+	Full bool
+}
+
+// ByMAC creates a request for a MAC address.
+func ByMAC(mac string) *Request {
+	return &Request{MACs: []string{mac}}
+}
+
+// ByUUID creates a request for a UUID.
+func ByUUID(uuid string) *Request {
+	return &Request{UUIDs: []string{uuid}}
+}
+
+func (this *Request) query() url.Values {
+	q := url.Values{}
+	for _, mac := range this.MACs {
+		q.Add(KEY_MAC, normalizeMAC(mac))
+	}
+	add := func(kind string, keys []string) {
+		for _, k := range keys {
+			q.Add(kind, k)
+		}
+	}
+	add(KEY_UUID, this.UUIDs)
+	add(KEY_IP, this.IPs)
+	add(KEY_SERIAL, this.Serials)
+	add(KEY_ASSETTAG, this.AssetTags)
+	if this.Full {
+		q.Set(QUERY_VIEW, VIEW_FULL)
+	}
+	if this.Namespace != "" {
+		q.Set(QUERY_NAMESPACE, this.Namespace)
+	}
+	return q
+}
+
+// cacheKey returns the cache key for single key requests.
+func (this *Request) cacheKey(index string, q url.Values) (CacheKey, bool) {
+	var key CacheKey
+	n := 0
+	for _, kind := range []string{KEY_MAC, KEY_UUID, KEY_IP, KEY_SERIAL, KEY_ASSETTAG} {
+		for _, k := range q[kind] {
+			key = CacheKey{Index: index, Namespace: this.Namespace, Kind: kind, Key: k, Full: this.Full}
+			n++
+		}
+	}
+	return key, n == 1
+}
+
+// Machine looks up a machine info object by MAC addresses or UUIDs.
+func (this *Client) Machine(ctx context.Context, req *Request) (*Object, error) {
+	return this.Lookup(ctx, PATH_MACHINEINFO, req)
+}
+
+// BMC looks up a BMC info object by MAC addresses, UUIDs, IP addresses,
+// serial numbers or asset tags.
+func (this *Client) BMC(ctx context.Context, req *Request) (*Object, error) {
+	return this.Lookup(ctx, PATH_BMCINFO, req)
+}
+
+// MachineType looks up the machine type responsible for MAC addresses.
+func (this *Client) MachineType(ctx context.Context, req *Request) (*Object, error) {
+	return this.Lookup(ctx, PATH_MACHINETYPE, req)
+}
+
+// Lookup looks up the object matching a request in the given index.
+// If no object matches an error satisfying IsNotFound is returned.
+func (this *Client) Lookup(ctx context.Context, index string, req *Request) (*Object, error) {
+	q := req.query()
+	key, cacheable := req.cacheKey(index, q)
+	if cacheable {
+		if obj := this.cache.Get(key); obj != nil {
+			return copyObject(obj), nil
+		}
+	}
+
+	generation := this.currentGeneration()
+	resp := &response{}
+	err := this.call(ctx, http.MethodGet, index, q, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	obj := &resp.Object
+	if cacheable {
+		this.lock.Lock()
+		defer this.lock.Unlock()
+		if generation == this.generation {
+			this.cache.Put(key, copyObject(obj))
+		}
+	}
+	return obj, nil
+}
+
+// Batch looks up many keys of several indices with a single request.
+// Found objects are added to the cache.
+func (this *Client) Batch(ctx context.Context, req BatchRequest) (BatchResponse, error) {
+	normalized := BatchRequest{}
+	for index, keys := range req {
+		if keys == nil {
+			continue
+		}
+		n := &BatchKeys{UUIDs: keys.UUIDs}
+		for _, mac := range keys.MACs {
+			n.MACs = append(n.MACs, normalizeMAC(mac))
+		}
+		normalized[index] = n
+	}
+	body, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	generation := this.currentGeneration()
+	resp := BatchResponse{}
+	err = this.call(ctx, http.MethodPost, PATH_BATCH, nil, body, &resp)
+	if err != nil {
+		return nil, err
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if generation != this.generation {
+		return resp, nil
+	}
+	for index, results := range resp {
+		if results == nil {
+			continue
+		}
+		for mac, r := range results.MACs {
+			if obj := r.Found(); obj != nil {
+				this.cache.Put(CacheKey{Index: index, Kind: KEY_MAC, Key: normalizeMAC(mac)}, copyObject(obj))
+			}
+		}
+		for uuid, r := range results.UUIDs {
+			if obj := r.Found(); obj != nil {
+				this.cache.Put(CacheKey{Index: index, Kind: KEY_UUID, Key: uuid}, copyObject(obj))
+			}
+		}
+	}
+	return resp, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Change Feed
+
+// WatchOptions select the events of a watch.
+type WatchOptions struct {
+	// Indices restricts the watch to the given index paths (default all).
+	Indices []string
+	// Since resumes the watch after the given sequence number. If it is
+	// nil the watch starts with the current state and the cache is flushed,
+	// because changes before the watch has been established may be missed.
+	Since *int64
+}
+
+// Watch watches the change feed of the index server, invalidates the
+// affected cache entries and calls the handler for every event. It blocks
+// until the context is done, the connection fails or the handler returns
+// an error. The sequence number of the last handled event can be used
+// to resume the watch.
+func (this *Client) Watch(ctx context.Context, opts *WatchOptions, handler func(e *Event) error) error {
+	q := url.Values{}
+	if opts != nil {
+		for _, index := range opts.Indices {
+			q.Add(QUERY_INDEX, index)
+		}
+		if opts.Since != nil {
+			q.Set(QUERY_SINCE, strconv.FormatInt(*opts.Since, 10))
+		}
+	}
+	r, err := this.send(ctx, http.MethodGet, PATH_WATCH, q, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return errorResponse(r)
+	}
+	if opts == nil || opts.Since == nil {
+		this.flush()
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	for {
+		e := &Event{}
+		if err := decoder.Decode(e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return fmt.Errorf("watch closed by index server")
+			}
+			return err
+		}
+		this.invalidate(e)
+		if handler != nil {
+			if err := handler(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (this *Client) currentGeneration() int64 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.generation
+}
+
+func (this *Client) flush() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.generation++
+	this.cache.Flush()
+}
+
+// invalidate removes the cache entries affected by an event.
+func (this *Client) invalidate(e *Event) {
+	if e.Type == EVENT_RESET {
+		this.flush()
+		return
+	}
+	macs := map[string]bool{}
+	for _, mac := range e.MACs {
+		macs[normalizeMAC(mac)] = true
+	}
+	uuids := map[string]bool{}
+	for _, uuid := range e.UUIDs {
+		uuids[uuid] = true
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.generation++
+	this.cache.Remove(func(key CacheKey, obj *Object) bool {
+		if e.Index != "" && key.Index != e.Index {
+			return false
+		}
+		// MAC addresses covered by a changed prefix may now match another type
+		if len(e.Prefixes) > 0 && key.Index == PATH_MACHINETYPE {
+			return true
+		}
+		if obj.Cluster == e.Cluster && obj.Namespace == e.Namespace && obj.Name == e.Name {
+			return true
+		}
+		switch key.Kind {
+		case KEY_MAC:
+			return macs[key.Key]
+		case KEY_UUID:
+			return uuids[key.Key]
+		}
+		return false
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (this *Client) url(path string, query url.Values) string {
+	u := *this.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + path
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func (this *Client) send(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, this.url(path, query), reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if this.auth != nil {
+		if err := this.auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+	return this.client.Do(req)
+}
+
+// call sends a request and decodes the result into the given object.
+func (this *Client) call(ctx context.Context, method, path string, query url.Values, body []byte, result interface{}) error {
+	r, err := this.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return errorResponse(r)
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("invalid index server response: %s", err)
+	}
+	return nil
+}
+
+func errorResponse(r *http.Response) error {
+	data, _ := ioutil.ReadAll(r.Body)
+	resp := &response{}
+	if json.Unmarshal(data, resp) != nil {
+		resp = nil
+	}
+	return newError(r, resp)
+}
+
+// normalizeMAC returns the canonical representation of a MAC address
+// used by the index server. Invalid addresses are kept to let the
+// server report the error.
+func normalizeMAC(s string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+	return hw.String()
+}
+
+func copyObject(obj *Object) *Object {
+	c := *obj
+	return &c
+}
@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package indexserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+const token = "secret"
+
+// testServer is an in-process index server serving the machine info
+// and type index. It uses the response types of the index server to
+// verify the wire compatibility of the client.
+type testServer struct {
+	*httptest.Server
+	lock        sync.Mutex
+	requests    int
+	initialized bool
+	infos       map[string]*api.MachineInfoSpec
+	types       map[string]string
+	events      chan *machines.IndexEvent
+	// hold blocks lookups: they send on arrival and wait for the release
+	hold chan struct{}
+}
+
+func newTestServer() *testServer {
+	s := &testServer{
+		initialized: true,
+		infos: map[string]*api.MachineInfoSpec{
+			"m1": {UUID: "uuid-1", NICs: []api.NIC{{Name: "eth0", MAC: "aa:bb:cc:00:00:01"}}},
+			"m2": {UUID: "uuid-2", NICs: []api.NIC{{Name: "eth0", MAC: "aa:bb:cc:00:00:02"}}},
+		},
+		types:  map[string]string{"aa:bb:cc": "small"},
+		events: make(chan *machines.IndexEvent, 10),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+PATH_MACHINEINFO, s.info)
+	mux.HandleFunc("/"+PATH_MACHINETYPE, s.machineType)
+	mux.HandleFunc("/"+PATH_BATCH, s.batch)
+	mux.HandleFunc("/"+PATH_WATCH, s.watch)
+	s.Server = httptest.NewServer(s.authenticate(mux))
+	return s
+}
+
+func (this *testServer) Requests() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.requests
+}
+
+func (this *testServer) authenticate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		this.lock.Lock()
+		this.requests++
+		this.lock.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			this.error(w, http.StatusUnauthorized, machines.NewErrorResponse(machines.REASON_UNAUTHORIZED, "missing token"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (this *testServer) error(w http.ResponseWriter, status int, resp *machines.IndexResponse) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (this *testServer) find(kind, key string) []string {
+	var found []string
+	for name, spec := range this.infos {
+		if kind == KEY_UUID && spec.UUID == key {
+			found = append(found, name)
+		}
+		for _, nic := range spec.NICs {
+			if kind == KEY_MAC && nic.MAC == key {
+				found = append(found, name)
+			}
+		}
+	}
+	return found
+}
+
+func (this *testServer) wait() {
+	if this.hold != nil {
+		this.hold <- struct{}{}
+		<-this.hold
+	}
+}
+
+func (this *testServer) info(w http.ResponseWriter, r *http.Request) {
+	this.wait()
+	if !this.initialized {
+		w.Header().Set("Retry-After", "3")
+		this.error(w, http.StatusServiceUnavailable, machines.NewErrorResponse(machines.REASON_NOT_INITIALIZED, "not initialized"))
+		return
+	}
+	q := r.URL.Query()
+	names := map[string]bool{}
+	var matches []*machines.KeyMatch
+	for _, kind := range []string{KEY_MAC, KEY_UUID} {
+		for _, key := range q[kind] {
+			for _, name := range this.find(kind, key) {
+				names[name] = true
+				matches = append(matches, &machines.KeyMatch{Kind: kind, Key: key, Name: name, Namespace: "default"})
+			}
+		}
+	}
+	switch len(names) {
+	case 0:
+		this.error(w, http.StatusNotFound, machines.NewErrorResponse(machines.REASON_NOT_FOUND, "no machine info found"))
+	case 1:
+		name := matches[0].Name
+		resp := &machines.MachineInfoResponse{
+			IndexResponse: machines.NewIndexResponse(machines.NewClusterObjectName("", "default", name), "1"),
+		}
+		if q.Get(QUERY_VIEW) == VIEW_FULL {
+			resp.Spec = this.infos[name]
+		}
+		json.NewEncoder(w).Encode(resp)
+	default:
+		this.error(w, http.StatusConflict, machines.NewErrorResponse(machines.REASON_AMBIGUOUS, "ambiguous", matches...))
+	}
+}
+
+func (this *testServer) machineType(w http.ResponseWriter, r *http.Request) {
+	mac := r.URL.Query().Get(KEY_MAC)
+	for prefix, name := range this.types {
+		if strings.HasPrefix(mac, prefix) {
+			json.NewEncoder(w).Encode(&machines.MachineTypeResponse{
+				IndexResponse: machines.NewIndexResponse(machines.NewClusterObjectName("", "default", name), "1"),
+				Spec:          &api.MachineTypeSpec{Type: name, MACPrefixes: []string{prefix}},
+			})
+			return
+		}
+	}
+	this.error(w, http.StatusNotFound, machines.NewErrorResponse(machines.REASON_NOT_FOUND, "no machine type found"))
+}
+
+func (this *testServer) batch(w http.ResponseWriter, r *http.Request) {
+	this.wait()
+	req := machines.BatchRequest{}
+	json.NewDecoder(r.Body).Decode(&req)
+	result := func(kind, key string) *machines.BatchResult {
+		found := this.find(kind, key)
+		if len(found) == 0 {
+			return machines.NewBatchResult(nil, "")
+		}
+		return machines.NewBatchResult(machines.NewClusterObjectName("", "default", found[0]), "1")
+	}
+	keys := req[PATH_MACHINEINFO]
+	results := &machines.BatchKeyResults{MACs: map[string]*machines.BatchResult{}, UUIDs: map[string]*machines.BatchResult{}}
+	for _, mac := range keys.MACs {
+		results.MACs[mac] = result(KEY_MAC, mac)
+	}
+	for _, uuid := range keys.UUIDs {
+		results.UUIDs[uuid] = result(KEY_UUID, uuid)
+	}
+	json.NewEncoder(w).Encode(machines.BatchResponse{PATH_MACHINEINFO: results})
+}
+
+func (this *testServer) watch(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-this.events:
+			json.NewEncoder(w).Encode(e)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+var _ = Describe("Index Server Client", func() {
+	var server *testServer
+	var client *Client
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		var err error
+		server = newTestServer()
+		client, err = NewClient(server.URL, &Options{Auth: BearerToken(token), Cache: NewCache(10, 0)})
+		Expect(err).To(Succeed())
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("looks up machines by MAC address and UUID", func() {
+		obj, err := client.Machine(ctx, ByMAC("AA-BB-CC-00-00-01"))
+		Expect(err).To(Succeed())
+		Expect(obj.String()).To(Equal("default/m1"))
+		Expect(obj.ResourceVersion).To(Equal("1"))
+		Expect(obj.DecodeSpec(&api.MachineInfoSpec{})).NotTo(Succeed())
+
+		obj, err = client.Machine(ctx, &Request{UUIDs: []string{"uuid-2"}, Full: true})
+		Expect(err).To(Succeed())
+		spec := &api.MachineInfoSpec{}
+		Expect(obj.DecodeSpec(spec)).To(Succeed())
+		Expect(spec.NICs[0].MAC).To(Equal("aa:bb:cc:00:00:02"))
+	})
+
+	It("looks up machine types", func() {
+		obj, err := client.MachineType(ctx, &Request{MACs: []string{"aa:bb:cc:00:00:09"}, Full: true})
+		Expect(err).To(Succeed())
+		spec := &api.MachineTypeSpec{}
+		Expect(obj.DecodeSpec(spec)).To(Succeed())
+		Expect(spec.Type).To(Equal("small"))
+	})
+
+	It("reports error responses", func() {
+		_, err := client.Machine(ctx, ByMAC("aa:bb:cc:00:00:09"))
+		Expect(IsNotFound(err)).To(BeTrue())
+
+		_, err = client.Machine(ctx, &Request{MACs: []string{"aa:bb:cc:00:00:01"}, UUIDs: []string{"uuid-2"}})
+		Expect(IsAmbiguous(err)).To(BeTrue())
+		Expect(err.(*Error).Conflicts).To(HaveLen(2))
+
+		server.initialized = false
+		_, err = client.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+		Expect(IsNotInitialized(err)).To(BeTrue())
+		Expect(err.(*Error).RetryAfter.Seconds()).To(Equal(3.0))
+
+		anonymous, _ := NewClient(server.URL, nil)
+		_, err = anonymous.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+		Expect(IsUnauthorized(err)).To(BeTrue())
+	})
+
+	It("caches single key lookups and batch results", func() {
+		_, err := client.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+		Expect(err).To(Succeed())
+		obj, err := client.Machine(ctx, ByMAC("AA:BB:CC:00:00:01"))
+		Expect(err).To(Succeed())
+		Expect(obj.Name).To(Equal("m1"))
+		Expect(server.Requests()).To(Equal(1))
+
+		resp, err := client.Batch(ctx, BatchRequest{PATH_MACHINEINFO: {MACs: []string{"aa:bb:cc:00:00:02"}, UUIDs: []string{"uuid-3"}}})
+		Expect(err).To(Succeed())
+		Expect(resp[PATH_MACHINEINFO].MACs["aa:bb:cc:00:00:02"].Found().Name).To(Equal("m2"))
+		Expect(resp[PATH_MACHINEINFO].UUIDs["uuid-3"].Status).To(Equal(STATUS_NOTFOUND))
+		obj, err = client.Machine(ctx, ByMAC("aa:bb:cc:00:00:02"))
+		Expect(err).To(Succeed())
+		Expect(obj.Name).To(Equal("m2"))
+		Expect(server.Requests()).To(Equal(2))
+	})
+
+	It("invalidates the cache for watched events", func() {
+		_, err := client.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+		Expect(err).To(Succeed())
+		_, err = client.Machine(ctx, ByUUID("uuid-2"))
+		Expect(err).To(Succeed())
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		events := make(chan *Event, 10)
+		since := int64(0)
+		go client.Watch(wctx, &WatchOptions{Indices: []string{PATH_MACHINEINFO}, Since: &since}, func(e *Event) error {
+			events <- e
+			return nil
+		})
+
+		server.events <- &machines.IndexEvent{Sequence: 1, Index: PATH_MACHINEINFO, Type: "update", Namespace: "default", Name: "m1"}
+		var e *Event
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Sequence).To(Equal(int64(1)))
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_MAC, Key: "aa:bb:cc:00:00:01"})).To(BeNil())
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_UUID, Key: "uuid-2"})).NotTo(BeNil())
+
+		server.events <- &machines.IndexEvent{Sequence: 2, Type: EVENT_RESET}
+		Eventually(events).Should(Receive())
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_UUID, Key: "uuid-2"})).To(BeNil())
+	})
+
+	It("does not cache results of requests overlapping events", func() {
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		events := make(chan *Event, 10)
+		since := int64(0)
+		go client.Watch(wctx, &WatchOptions{Since: &since}, func(e *Event) error {
+			events <- e
+			return nil
+		})
+
+		server.hold = make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := client.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+			done <- err
+		}()
+		<-server.hold
+		server.events <- &machines.IndexEvent{Sequence: 1, Index: PATH_MACHINEINFO, Type: "update", Namespace: "default", Name: "m1"}
+		Eventually(events).Should(Receive())
+		server.hold <- struct{}{}
+		Expect(<-done).To(Succeed())
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_MAC, Key: "aa:bb:cc:00:00:01"})).To(BeNil())
+
+		go func() {
+			_, err := client.Batch(ctx, BatchRequest{PATH_MACHINEINFO: {UUIDs: []string{"uuid-2"}}})
+			done <- err
+		}()
+		<-server.hold
+		server.events <- &machines.IndexEvent{Sequence: 2, Index: PATH_MACHINEINFO, Type: "update", Namespace: "default", Name: "m2"}
+		Eventually(events).Should(Receive())
+		server.hold <- struct{}{}
+		Expect(<-done).To(Succeed())
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_UUID, Key: "uuid-2"})).To(BeNil())
+
+		server.hold = nil
+		_, err := client.Machine(ctx, ByMAC("aa:bb:cc:00:00:01"))
+		Expect(err).To(Succeed())
+		Expect(client.Cache().Get(CacheKey{Index: PATH_MACHINEINFO, Kind: KEY_MAC, Key: "aa:bb:cc:00:00:01"})).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package indexserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error is returned for error responses of the index server.
+type Error struct {
+	StatusCode int
+	Reason     string
+	Message    string
+	Conflicts  []*KeyMatch
+	// RetryAfter is the delay requested by the server for
+	// a not yet initialized index.
+	RetryAfter time.Duration
+}
+
+func (this *Error) Error() string {
+	msg := this.Message
+	if msg == "" {
+		msg = http.StatusText(this.StatusCode)
+	}
+	if len(this.Conflicts) > 0 {
+		var conflicts []string
+		for _, c := range this.Conflicts {
+			o := &Object{Cluster: c.Cluster, Namespace: c.Namespace, Name: c.Name}
+			conflicts = append(conflicts, fmt.Sprintf("%s %s -> %s", c.Kind, c.Key, o))
+		}
+		msg = fmt.Sprintf("%s (%s)", msg, strings.Join(conflicts, ", "))
+	}
+	return fmt.Sprintf("index server: %s", msg)
+}
+
+func newError(r *http.Response, resp *response) *Error {
+	err := &Error{
+		StatusCode: r.StatusCode,
+	}
+	if resp != nil {
+		err.Reason = resp.Reason
+		err.Message = resp.Error
+		err.Conflicts = resp.Conflicts
+	}
+	if err.Reason == "" {
+		switch r.StatusCode {
+		case http.StatusNotFound:
+			err.Reason = REASON_NOT_FOUND
+		case http.StatusConflict:
+			err.Reason = REASON_AMBIGUOUS
+		case http.StatusServiceUnavailable:
+			err.Reason = REASON_NOT_INITIALIZED
+		case http.StatusBadRequest:
+			err.Reason = REASON_BAD_REQUEST
+		case http.StatusUnauthorized:
+			err.Reason = REASON_UNAUTHORIZED
+		case http.StatusForbidden:
+			err.Reason = REASON_FORBIDDEN
+		default:
+			err.Reason = REASON_INTERNAL
+		}
+	}
+	if s := r.Header.Get("Retry-After"); s != "" {
+		var secs int
+		if _, e := fmt.Sscanf(s, "%d", &secs); e == nil && secs > 0 {
+			err.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return err
+}
+
+func reason(err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Reason
+	}
+	return ""
+}
+
+// IsNotFound reports whether no object matches the requested keys.
+func IsNotFound(err error) bool {
+	return reason(err) == REASON_NOT_FOUND
+}
+
+// IsAmbiguous reports whether the requested keys match different objects.
+func IsAmbiguous(err error) bool {
+	return reason(err) == REASON_AMBIGUOUS
+}
+
+// IsNotInitialized reports whether the index server is still syncing.
+func IsNotInitialized(err error) bool {
+	return reason(err) == REASON_NOT_INITIALIZED
+}
+
+// IsUnauthorized reports whether the request has been rejected
+// because of missing or insufficient credentials.
+func IsUnauthorized(err error) bool {
+	r := reason(err)
+	return r == REASON_UNAUTHORIZED || r == REASON_FORBIDDEN
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+// Package indexserver provides a dependency-light client for the machine
+// index server. It only uses the standard library and can be used by tools
+// outside of the controller-manager-library world.
+//
+// The wire types of this package mirror those of the index server. Object
+// specs are passed as raw JSON and can be decoded into the types of the
+// machines API group (or any compatible type) with Object.DecodeSpec.
+package indexserver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Index paths of the index server.
+const (
+	PATH_MACHINEINFO = "info"
+	PATH_BMCINFO     = "bmc"
+	PATH_MACHINETYPE = "type"
+	PATH_BATCH       = "batch"
+	PATH_WATCH       = "watch"
+)
+
+// Key kinds used for lookups.
+const (
+	KEY_MAC      = "mac"
+	KEY_UUID     = "uuid"
+	KEY_IP       = "ip"
+	KEY_SERIAL   = "serial"
+	KEY_ASSETTAG = "assettag"
+)
+
+// Query parameters understood by the index server.
+const (
+	QUERY_VIEW      = "view"
+	QUERY_NAMESPACE = "namespace"
+	QUERY_SINCE     = "since"
+	QUERY_INDEX     = "index"
+)
+
+const VIEW_FULL = "full"
+
+const REASON_NOT_FOUND = "NotFound"
+const REASON_AMBIGUOUS = "Ambiguous"
+const REASON_NOT_INITIALIZED = "NotInitialized"
+const REASON_BAD_REQUEST = "BadRequest"
+const REASON_UNAUTHORIZED = "Unauthorized"
+const REASON_FORBIDDEN = "Forbidden"
+const REASON_INTERNAL = "Internal"
+
+const STATUS_FOUND = "found"
+const STATUS_NOTFOUND = "notfound"
+const STATUS_AMBIGUOUS = "ambiguous"
+
+// EVENT_RESET is sent by the change feed if the requested sequence number
+// is not available anymore. Cached information must be discarded.
+const EVENT_RESET = "reset"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Object is the object found by a lookup. Spec is only set
+// for lookups requesting the full view.
+type Object struct {
+	// Cluster is the identity of the additional cluster hosting
+	// the object. It is empty for objects of the main cluster.
+	Cluster         string          `json:"cluster,omitempty"`
+	Name            string          `json:"name"`
+	Namespace       string          `json:"namespace,omitempty"`
+	ResourceVersion string          `json:"resourceVersion,omitempty"`
+	Spec            json.RawMessage `json:"spec,omitempty"`
+}
+
+// DecodeSpec decodes the object spec into the given object, for example
+// a *v1alpha1.MachineInfoSpec. It fails if no spec has been requested.
+func (this *Object) DecodeSpec(into interface{}) error {
+	if len(this.Spec) == 0 {
+		return fmt.Errorf("no spec for %s (full view not requested)", this)
+	}
+	return json.Unmarshal(this.Spec, into)
+}
+
+func (this *Object) String() string {
+	name := this.Name
+	if this.Namespace != "" {
+		name = this.Namespace + "/" + name
+	}
+	if this.Cluster != "" {
+		name = this.Cluster + ":" + name
+	}
+	return name
+}
+
+// KeyMatch describes the object found for a dedicated key.
+type KeyMatch struct {
+	Kind      string `json:"kind"`
+	Key       string `json:"key"`
+	Cluster   string `json:"cluster,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type response struct {
+	Object
+	Error     string      `json:"error,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Conflicts []*KeyMatch `json:"conflicts,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Batch Lookup
+
+// BatchKeys lists the keys to look up in a dedicated index.
+type BatchKeys struct {
+	MACs  []string `json:"macs,omitempty"`
+	UUIDs []string `json:"uuids,omitempty"`
+}
+
+// BatchRequest maps index paths (for example PATH_MACHINEINFO) to the keys
+// to look up.
+type BatchRequest map[string]*BatchKeys
+
+// BatchResult is the result for a single key of a batch request.
+// The object fields are only set for STATUS_FOUND.
+type BatchResult struct {
+	Status string `json:"status"`
+	Object
+	Conflicts []*KeyMatch `json:"conflicts,omitempty"`
+}
+
+// Found returns the found object or nil.
+func (this *BatchResult) Found() *Object {
+	if this == nil || this.Status != STATUS_FOUND {
+		return nil
+	}
+	return &this.Object
+}
+
+// BatchKeyResults maps every requested key to its lookup result.
+type BatchKeyResults struct {
+	MACs  map[string]*BatchResult `json:"macs,omitempty"`
+	UUIDs map[string]*BatchResult `json:"uuids,omitempty"`
+}
+
+// BatchResponse maps index paths to the results for the requested keys.
+type BatchResponse map[string]*BatchKeyResults
+
+////////////////////////////////////////////////////////////////////////////////
+// Change Feed
+
+// Event is a single entry of the change feed of the index server.
+type Event struct {
+	Sequence        int64    `json:"sequence"`
+	Index           string   `json:"index,omitempty"`
+	Type            string   `json:"type"`
+	Cluster         string   `json:"cluster,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	Namespace       string   `json:"namespace,omitempty"`
+	ResourceVersion string   `json:"resourceVersion,omitempty"`
+	MACs            []string `json:"macs,omitempty"`
+	UUIDs           []string `json:"uuids,omitempty"`
+	Prefixes        []string `json:"prefixes,omitempty"`
+}
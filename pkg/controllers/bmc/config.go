@@ -23,17 +23,41 @@
 package machines
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/gardener/controller-manager-library/pkg/config"
+
+	"github.com/onmetal/k8s-machines/pkg/controllers"
 )
 
 type Config struct {
-	LocalNamespaceOnly bool
+	controllers.NamespaceConfig
+	SnapshotFile     string
+	SnapshotInterval time.Duration
+	RemoteClusters   []string
 }
 
 func (this *Config) AddOptionsToSet(set config.OptionSet) {
-	set.AddBoolOption(&this.LocalNamespaceOnly, "local-namespace-only", "", false, "server only resources in local namespace")
+	this.NamespaceConfig.AddOptionsToSet(set)
+	set.AddStringOption(&this.SnapshotFile, "snapshot-file", "", "", "snapshot file used to warm start the index")
+	set.AddDurationOption(&this.SnapshotInterval, "snapshot-interval", "", 5*time.Minute, "interval for writing index snapshots")
+	set.AddStringArrayOption(&this.RemoteClusters, "remote-clusters", "", nil, "additional clusters to index (<identity>=<kubeconfig>)")
 }
 
 func (this *Config) Prepare() error {
+	if err := this.NamespaceConfig.Prepare(); err != nil {
+		return err
+	}
+	if this.SnapshotFile != "" {
+		if this.SnapshotInterval <= 0 {
+			return fmt.Errorf("snapshot interval must be positive")
+		}
+	}
+	if len(this.RemoteClusters) > 0 {
+		if _, err := controllers.ParseRemoteClusters(this.RemoteClusters); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -49,7 +49,7 @@ func Create(controller controller.Interface) (reconcile.Interface, error) {
 	config := cfg.(*Config)
 
 	if selected[config.Implementation] == nil {
-		return nil, fmt.Errorf("lease management %q not found")
+		return nil, fmt.Errorf("lease management %q not found", config.Implementation)
 	}
 	leases, err := selected[config.Implementation](config.LeaseFile)
 	if err != nil {
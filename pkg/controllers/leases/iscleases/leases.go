@@ -24,9 +24,13 @@ package iscleases
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
 	"sync"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
+	"github.com/gardener/controller-manager-library/pkg/logger"
 
 	"github.com/onmetal/k8s-machines/pkg/controllers/leases"
 	"github.com/onmetal/k8s-machines/pkg/filewatcher"
@@ -38,18 +42,26 @@ func init() {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// leaseManagement maps the lease file of the ISC dhcp server.
+// Modifications are appended to the lease file the same way dhcpd
+// maintains its lease journal, the last declaration for an IP address
+// always wins.
 type leaseManagement struct {
-	lock sync.Mutex
-	path string
+	lock      sync.Mutex
+	path      string
+	namespace string
+	// number of leases of the last List, -1 before the first List
+	count int
 }
 
 var _ leases.LeaseManagement = &leaseManagement{}
 
 func NewLeaseManagement(path string) (leases.LeaseManagement, error) {
-	return &leaseManagement{path: path}, nil
+	return &leaseManagement{path: path, count: -1}, nil
 }
 
 func (this *leaseManagement) Start(c controller.Interface) error {
+	this.namespace = c.GetEnvironment().Namespace()
 	filewatcher.Configure().For(this.path).EnqueueCommand(c, leases.CMD_SCAN).StartWith(c, "leasefile")
 	return nil
 }
@@ -58,34 +70,136 @@ func (this *leaseManagement) List() ([]*leases.Lease, error) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
-	return nil, fmt.Errorf("not implemented yet")
+	list, err := this.read()
+	if err != nil {
+		return nil, err
+	}
+	result := []*leases.Lease{}
+	for _, l := range list {
+		result = append(result, this.convert(l))
+	}
+	// List is called for every scan of the lease file
+	if len(result) != this.count {
+		logger.Infof("found %d leases", len(result))
+		this.count = len(result)
+	}
+	return result, nil
 }
 
 func (this *leaseManagement) Get(mac string) *leases.Lease {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
-	return nil
+	l, err := this.get(mac)
+	if l == nil || err != nil {
+		return nil
+	}
+	return this.convert(l)
 }
 
 func (this *leaseManagement) Create(l *leases.Lease) error {
-	n := l.MAC.String()
-	if this.Get(n) != nil {
-		return fmt.Errorf("already exsists")
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	old, err := this.get(l.MAC.String())
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		return fmt.Errorf("lease for %s already exists", l.MAC)
 	}
-	return fmt.Errorf("not implemented yet")
+	return this.write(this.lease(l, STATE_ACTIVE))
 }
 
 func (this *leaseManagement) Update(l *leases.Lease) error {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
-	return fmt.Errorf("not implemented yet")
+	old, err := this.get(l.MAC.String())
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return fmt.Errorf("lease not found")
+	}
+	if !old.IP.Equal(l.IP) {
+		old.BindingState = STATE_FREE
+		err = this.write(old)
+		if err != nil {
+			return err
+		}
+	}
+	return this.write(this.lease(l, STATE_ACTIVE))
 }
 
 func (this *leaseManagement) Delete(mac string) error {
 	this.lock.Lock()
 	defer this.lock.Unlock()
 
-	return fmt.Errorf("not implemented yet")
+	old, err := this.get(mac)
+	if old == nil || err != nil {
+		return err
+	}
+	old.BindingState = STATE_FREE
+	return this.write(old)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (this *leaseManagement) read() (map[string]*lease, error) {
+	data, err := ioutil.ReadFile(this.path)
+	if err != nil {
+		return nil, err
+	}
+	list, err := parseLeases(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid lease file %q: %s", this.path, err)
+	}
+	return effectiveLeases(list), nil
+}
+
+func (this *leaseManagement) get(mac string) (*lease, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac address %q: %s", mac, err)
+	}
+	list, err := this.read()
+	if err != nil {
+		return nil, err
+	}
+	return list[hw.String()], nil
+}
+
+func (this *leaseManagement) write(l *lease) error {
+	f, err := os.OpenFile(this.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(formatLease(l))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (this *leaseManagement) convert(l *lease) *leases.Lease {
+	return &leases.Lease{
+		Namespace:  this.namespace,
+		Hostname:   l.Hostname,
+		IP:         l.IP,
+		MAC:        l.MAC,
+		LeaseTime:  l.Starts,
+		ExpireTime: l.Ends,
+	}
+}
+
+func (this *leaseManagement) lease(l *leases.Lease, state string) *lease {
+	return &lease{
+		IP:           l.IP,
+		Starts:       l.LeaseTime,
+		Ends:         l.ExpireTime,
+		BindingState: state,
+		MAC:          l.MAC,
+		Hostname:     l.Hostname,
+	}
 }
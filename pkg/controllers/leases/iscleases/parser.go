@@ -0,0 +1,351 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package iscleases
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TIME_FORMAT = "2006/01/02 15:04:05"
+
+const STATE_ACTIVE = "active"
+const STATE_FREE = "free"
+
+// lease is a single lease declaration found in a dhcpd lease file.
+type lease struct {
+	IP           net.IP
+	Starts       time.Time
+	Ends         time.Time
+	BindingState string
+	MAC          net.HardwareAddr
+	Hostname     string
+
+	// index is the position of the declaration in the lease file
+	index int
+}
+
+func (this *lease) IsActive() bool {
+	return this.BindingState == "" || this.BindingState == STATE_ACTIVE
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// tokenizer
+
+type scanner struct {
+	data string
+	pos  int
+	line int
+}
+
+type token struct {
+	value  string
+	quoted bool
+	line   int
+}
+
+func (this *token) is(s string) bool {
+	return !this.quoted && this.value == s
+}
+
+func newScanner(data string) *scanner {
+	return &scanner{data: data, line: 1}
+}
+
+func (this *scanner) skip() {
+	for this.pos < len(this.data) {
+		c := this.data[this.pos]
+		switch {
+		case c == '\n':
+			this.line++
+			this.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			this.pos++
+		case c == '#':
+			for this.pos < len(this.data) && this.data[this.pos] != '\n' {
+				this.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token or nil at end of input.
+func (this *scanner) next() (*token, error) {
+	this.skip()
+	if this.pos >= len(this.data) {
+		return nil, nil
+	}
+	line := this.line
+	c := this.data[this.pos]
+	switch c {
+	case '{', '}', ';':
+		this.pos++
+		return &token{value: string(c), line: line}, nil
+	case '"':
+		return this.quoted()
+	}
+	start := this.pos
+	for this.pos < len(this.data) && !strings.ContainsRune(" \t\r\n{};\"#", rune(this.data[this.pos])) {
+		this.pos++
+	}
+	return &token{value: this.data[start:this.pos], line: line}, nil
+}
+
+func (this *scanner) quoted() (*token, error) {
+	line := this.line
+	value := []byte{}
+	this.pos++
+	for this.pos < len(this.data) {
+		c := this.data[this.pos]
+		this.pos++
+		switch c {
+		case '"':
+			return &token{value: string(value), quoted: true, line: line}, nil
+		case '\n':
+			this.line++
+		case '\\':
+			if this.pos >= len(this.data) {
+				break
+			}
+			c = this.data[this.pos]
+			if c >= '0' && c <= '7' {
+				n := 0
+				for i := 0; i < 3 && this.pos < len(this.data) && this.data[this.pos] >= '0' && this.data[this.pos] <= '7'; i++ {
+					n = n*8 + int(this.data[this.pos]-'0')
+					this.pos++
+				}
+				c = byte(n)
+			} else {
+				this.pos++
+			}
+		}
+		value = append(value, c)
+	}
+	return nil, fmt.Errorf("line %d: unterminated string", line)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// statements
+
+// statement is a sequence of tokens terminated by a semicolon
+// or followed by a block of nested statements.
+type statement struct {
+	args  []*token
+	block []*statement
+	line  int
+}
+
+func (this *statement) is(keywords ...string) bool {
+	if len(this.args) < len(keywords) {
+		return false
+	}
+	for i, k := range keywords {
+		if !this.args[i].is(k) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *statement) values(start int) []string {
+	var result []string
+	for _, a := range this.args[start:] {
+		result = append(result, a.value)
+	}
+	return result
+}
+
+func parseStatements(s *scanner, nested bool) ([]*statement, error) {
+	var result []*statement
+	var cur *statement
+
+	for {
+		t, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			if nested {
+				return nil, fmt.Errorf("line %d: unexpected end of file, missing '}'", s.line)
+			}
+			if cur != nil {
+				return nil, fmt.Errorf("line %d: unterminated statement", cur.line)
+			}
+			return result, nil
+		}
+		switch {
+		case t.is(";"):
+			if cur != nil {
+				result = append(result, cur)
+				cur = nil
+			}
+		case t.is("{"):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: unexpected '{'", t.line)
+			}
+			cur.block, err = parseStatements(s, true)
+			if err != nil {
+				return nil, err
+			}
+			if cur.block == nil {
+				cur.block = []*statement{}
+			}
+			result = append(result, cur)
+			cur = nil
+		case t.is("}"):
+			if !nested {
+				return nil, fmt.Errorf("line %d: unexpected '}'", t.line)
+			}
+			if cur != nil {
+				return nil, fmt.Errorf("line %d: unterminated statement", cur.line)
+			}
+			return result, nil
+		default:
+			if cur == nil {
+				cur = &statement{line: t.line}
+			}
+			cur.args = append(cur.args, t)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// lease file
+
+// parseLeases parses the content of a dhcpd lease file and returns
+// all lease declarations in the order found in the file.
+func parseLeases(data string) ([]*lease, error) {
+	stmts, err := parseStatements(newScanner(data), false)
+	if err != nil {
+		return nil, err
+	}
+	var result []*lease
+	for _, s := range stmts {
+		if !s.is("lease") || s.block == nil {
+			continue
+		}
+		if len(s.args) != 2 {
+			return nil, fmt.Errorf("line %d: invalid lease declaration", s.line)
+		}
+		l, err := parseLease(s)
+		if err != nil {
+			return nil, err
+		}
+		l.index = len(result)
+		result = append(result, l)
+	}
+	return result, nil
+}
+
+func parseLease(s *statement) (*lease, error) {
+	var err error
+
+	l := &lease{IP: net.ParseIP(s.args[1].value)}
+	if l.IP == nil {
+		return nil, fmt.Errorf("line %d: invalid ip address %q", s.line, s.args[1].value)
+	}
+	for _, e := range s.block {
+		switch {
+		case e.is("starts"):
+			l.Starts, err = parseTime(e.values(1))
+		case e.is("ends"):
+			l.Ends, err = parseTime(e.values(1))
+		case e.is("binding", "state"):
+			if len(e.args) != 3 {
+				err = fmt.Errorf("binding state requires one argument")
+			} else {
+				l.BindingState = e.args[2].value
+			}
+		case e.is("hardware"):
+			if len(e.args) != 3 {
+				err = fmt.Errorf("hardware requires type and address")
+			} else {
+				if e.args[1].value == "ethernet" {
+					l.MAC, err = net.ParseMAC(e.args[2].value)
+				}
+			}
+		case e.is("client-hostname"):
+			if len(e.args) != 2 {
+				err = fmt.Errorf("client-hostname requires one argument")
+			} else {
+				l.Hostname = e.args[1].value
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: lease %s: %s", e.line, l.IP, err)
+		}
+	}
+	return l, nil
+}
+
+// parseTime parses the time formats used by dhcpd:
+// "never", "epoch <seconds>" and "<weekday> <yyyy/mm/dd> <hh:mm:ss>" (UTC).
+func parseTime(args []string) (time.Time, error) {
+	switch {
+	case len(args) == 1 && args[0] == "never":
+		return time.Time{}, nil
+	case len(args) == 2 && args[0] == "epoch":
+		secs, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch %q", args[1])
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	case len(args) == 3:
+		t, err := time.Parse(TIME_FORMAT, args[1]+" "+args[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: %s", args[1]+" "+args[2], err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time specification %q", strings.Join(args, " "))
+}
+
+// effectiveLeases reduces the lease journal to the currently valid
+// leases per MAC address. dhcpd appends updated declarations to the
+// lease file, so the last declaration for an IP address is the valid one.
+// If several IP addresses are still bound to the same MAC address
+// the most recently started lease is used.
+func effectiveLeases(list []*lease) map[string]*lease {
+	byIP := map[string]*lease{}
+	for _, l := range list {
+		byIP[l.IP.String()] = l
+	}
+
+	byMAC := map[string]*lease{}
+	for _, l := range byIP {
+		if l.MAC == nil || !l.IsActive() {
+			continue
+		}
+		key := l.MAC.String()
+		old := byMAC[key]
+		if old == nil || old.Starts.Before(l.Starts) || (old.Starts.Equal(l.Starts) && old.index < l.index) {
+			byMAC[key] = l
+		}
+	}
+	return byMAC
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package iscleases
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var leasefile = `
+# The format of this file is documented in the dhcpd.leases(5) manual page.
+# This lease file was written by isc-dhcp-4.4.1
+
+# authoring-byte-order entry is generated, DO NOT DELETE
+authoring-byte-order little-endian;
+
+server-duid "\000\001\000\001&\351\2335\000\025]\000\001\002";
+
+lease 10.0.0.10 {
+  starts 4 2020/12/17 10:00:00;
+  ends 4 2020/12/17 22:00:00;
+  cltt 4 2020/12/17 10:00:00;
+  binding state active;
+  next binding state free;
+  rewind binding state free;
+  hardware ethernet 00:11:22:33:44:55;
+  uid "\001\000\021\"3DU";
+  client-hostname "node-a";
+}
+lease 10.0.0.11 {
+  starts epoch 1608199200; # Thu Dec 17 10:00:00 2020
+  ends never;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:66;
+  client-hostname "node-b";
+}
+lease 10.0.0.10 {
+  starts 4 2020/12/17 12:00:00;
+  ends 5 2020/12/18 00:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "node-a";
+}
+lease 10.0.0.12 {
+  starts 4 2020/12/17 11:00:00;
+  ends 4 2020/12/17 23:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:77;
+}
+lease 10.0.0.12 {
+  starts 4 2020/12/17 11:00:00;
+  ends 4 2020/12/17 13:00:00;
+  binding state free;
+  hardware ethernet 00:11:22:33:44:77;
+}
+`
+
+var _ = Describe("ISC Lease File", func() {
+
+	Context("parse", func() {
+		It("parses all lease declarations", func() {
+			list, err := parseLeases(leasefile)
+			Expect(err).To(BeNil())
+			Expect(len(list)).To(Equal(5))
+
+			l := list[0]
+			Expect(l.IP.String()).To(Equal("10.0.0.10"))
+			Expect(l.MAC.String()).To(Equal("00:11:22:33:44:55"))
+			Expect(l.Hostname).To(Equal("node-a"))
+			Expect(l.BindingState).To(Equal(STATE_ACTIVE))
+			Expect(l.Starts).To(Equal(time.Date(2020, 12, 17, 10, 0, 0, 0, time.UTC)))
+			Expect(l.Ends).To(Equal(time.Date(2020, 12, 17, 22, 0, 0, 0, time.UTC)))
+		})
+		It("handles epoch and never", func() {
+			list, err := parseLeases(leasefile)
+			Expect(err).To(BeNil())
+
+			l := list[1]
+			Expect(l.Starts).To(Equal(time.Date(2020, 12, 17, 10, 0, 0, 0, time.UTC)))
+			Expect(l.Ends.IsZero()).To(BeTrue())
+		})
+		It("rejects unterminated blocks", func() {
+			_, err := parseLeases("lease 10.0.0.1 {\n starts never;\n")
+			Expect(err).NotTo(BeNil())
+		})
+		It("rejects invalid addresses", func() {
+			_, err := parseLeases("lease 10.0.0 {\n}\n")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("deduplicate", func() {
+		It("uses the last declaration and skips free leases", func() {
+			list, err := parseLeases(leasefile)
+			Expect(err).To(BeNil())
+
+			effective := effectiveLeases(list)
+			Expect(len(effective)).To(Equal(2))
+			Expect(effective["00:11:22:33:44:55"].Starts).To(Equal(time.Date(2020, 12, 17, 12, 0, 0, 0, time.UTC)))
+			Expect(effective["00:11:22:33:44:66"].IP.String()).To(Equal("10.0.0.11"))
+			Expect(effective["00:11:22:33:44:77"]).To(BeNil())
+		})
+	})
+
+	Context("write", func() {
+		It("formats parsable declarations", func() {
+			mac, _ := net.ParseMAC("00:11:22:33:44:88")
+			l := &lease{
+				IP:           net.ParseIP("10.0.0.20"),
+				Starts:       time.Date(2020, 12, 17, 10, 0, 0, 0, time.UTC),
+				Ends:         time.Date(2020, 12, 17, 22, 0, 0, 0, time.UTC),
+				BindingState: STATE_ACTIVE,
+				MAC:          mac,
+				Hostname:     "node \"c\"",
+			}
+			list, err := parseLeases(formatLease(l))
+			Expect(err).To(BeNil())
+			Expect(len(list)).To(Equal(1))
+			Expect(list[0]).To(Equal(l))
+		})
+	})
+})
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package iscleases
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	t = t.UTC()
+	return fmt.Sprintf("%d %s", t.Weekday(), t.Format(TIME_FORMAT))
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	return "\"" + strings.ReplaceAll(s, "\"", "\\\"") + "\""
+}
+
+// formatLease formats a lease declaration in the syntax used by dhcpd.
+func formatLease(l *lease) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "lease %s {\n", l.IP)
+	fmt.Fprintf(&b, "  starts %s;\n", formatTime(l.Starts))
+	fmt.Fprintf(&b, "  ends %s;\n", formatTime(l.Ends))
+	fmt.Fprintf(&b, "  cltt %s;\n", formatTime(l.Starts))
+	fmt.Fprintf(&b, "  binding state %s;\n", l.BindingState)
+	fmt.Fprintf(&b, "  next binding state %s;\n", STATE_FREE)
+	if l.MAC != nil {
+		fmt.Fprintf(&b, "  hardware ethernet %s;\n", l.MAC)
+	}
+	if l.Hostname != "" {
+		fmt.Fprintf(&b, "  client-hostname %s;\n", quote(l.Hostname))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
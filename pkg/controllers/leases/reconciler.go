@@ -35,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 
 	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
 )
 
 type reconciler struct {
@@ -68,7 +69,7 @@ func (this *reconciler) Reconcile(logger logger.LogContext, obj resources.Object
 	var err error
 
 	l := obj.Data().(*api.DHCPLease)
-	old := this.leases.Get(l.Spec.MAC)
+	old := this.leases.Get(macKey(l))
 	if old != nil {
 		logger.Infof("reconcile existing lease for mac %s", l.Spec.MAC)
 	} else {
@@ -134,7 +135,7 @@ func (this *reconciler) Reconcile(logger logger.LogContext, obj resources.Object
 func (this *reconciler) Delete(logger logger.LogContext, obj resources.Object) reconcile.Status {
 	logger.Infof("delete")
 	l := obj.Data().(*api.DHCPLease)
-	err := this.leases.Delete(l.Spec.MAC)
+	err := this.leases.Delete(macKey(l))
 	if err != nil {
 		return reconcile.Delay(logger, err)
 	}
@@ -158,12 +159,23 @@ func (this *reconciler) updateObject(o *api.DHCPLease, l *Lease) bool {
 	if l.IP.String() != o.Spec.IP {
 		return true
 	}
-	if l.MAC.String() != o.Spec.MAC {
+	if l.MAC.String() != macKey(o) {
 		return true
 	}
 	return false
 }
 
+// macKey returns the canonical mac address of a lease object used
+// to match it with the leases of the lease management. Invalid
+// addresses are kept as they are.
+func macKey(o *api.DHCPLease) string {
+	mac, err := machines.NormalizeMAC(o.Spec.MAC)
+	if err != nil {
+		return o.Spec.MAC
+	}
+	return mac
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 func (this *reconciler) Update() error {
@@ -187,7 +199,7 @@ func (this *reconciler) Update() error {
 	oldindex := map[string]resources.Object{}
 	for _, e := range cur {
 		l := e.Data().(*api.DHCPLease)
-		key := l.Spec.MAC
+		key := macKey(l)
 
 		new := index[key]
 		oldindex[key] = e
@@ -252,7 +264,7 @@ func (this *reconciler) createLeaseFor(obj *api.DHCPLease) (*Lease, error) {
 	if obj.Spec.MAC == "" {
 		return nil, fmt.Errorf("mac missing")
 	}
-	mac, err := net.ParseMAC(obj.Spec.MAC)
+	mac, err := machines.ParseMAC(obj.Spec.MAC)
 	if err != nil {
 		return nil, fmt.Errorf("invalid mac address %q: %s", obj.Spec.MAC, err)
 	}
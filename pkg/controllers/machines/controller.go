@@ -34,7 +34,7 @@ func init() {
 		Reconciler(Create).
 		DefaultWorkerPool(2, 0).
 		OptionsByExample("options", &Config{}).
-		MainResourceByGK(api.MACHINEINFO).
+		MainResourceByGK(api.MACHINEINFO, controllers.NamespaceSelection).
 		MustRegister(controllers.GROUP_MACHINES)
 }
 
@@ -47,7 +47,10 @@ func Create(controller controller.Interface) (reconcile.Interface, error) {
 	this := &reconciler{
 		controller: controller,
 		config:     config,
+		scope:      config.Scope(controller),
 		indexer:    controllers.GetOrCreateMachineIndex(controller.GetEnvironment(), func() machines.MachineIndex { return machines.NewFullIndexer() }).(machines.MachineIndexer),
+		secondary:  controllers.GetOrCreateSecondaryIndices(controller.GetEnvironment()),
 	}
+	this.remote = controllers.NewRemoteWatches(controller, api.MACHINEINFO, &remoteHandler{this.indexer})
 	return this, nil
 }
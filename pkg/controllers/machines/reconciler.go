@@ -27,7 +27,9 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/utils"
 
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
 	"github.com/onmetal/k8s-machines/pkg/controllers"
 	"github.com/onmetal/k8s-machines/pkg/machines"
 )
@@ -37,16 +39,29 @@ type reconciler struct {
 
 	controller controller.Interface
 	config     *Config
+	scope      *machines.Scope
 
-	indexer machines.MachineIndexer
+	indexer   machines.MachineIndexer
+	secondary *machines.SecondaryIndices
+	remote    *controllers.RemoteWatches
 }
 
 var _ reconcile.Interface = &reconciler{}
 
 func (this *reconciler) Setup() error {
-	err := this.indexer.Setup(this.controller, this.controller.GetMainCluster())
+	controllers.LoadSnapshot(this.controller, this.indexer, this.config.SnapshotFile)
+	if this.indexer.IsInitialized() {
+		// serve the stale snapshot until the setup is done
+		controllers.PropagateMachineIndex(this.indexer)
+	}
+	err := this.indexer.Setup(this.controller, this.controller.GetMainCluster(), this.scope)
+	if err == nil {
+		err = this.secondary.Setup(this.controller, this.controller.GetMainCluster(), api.MACHINEINFO, this.scope)
+	}
 	if err == nil {
 		controllers.PropagateMachineIndex(this.indexer)
+		controllers.WriteSnapshots(this.controller, this.indexer, this.config.SnapshotFile, this.config.SnapshotInterval)
+		err = this.remote.Setup(this.config.RemoteClusters, this.scope)
 	}
 	return err
 }
@@ -58,17 +73,74 @@ func (this *reconciler) Config() *Config {
 }
 
 func (this *reconciler) Reconcile(logger logger.LogContext, obj resources.Object) reconcile.Status {
+	if !this.scope.Contains(obj.GetNamespace()) {
+		return reconcile.Succeeded(logger)
+	}
 	logger.Infof("reconcile")
 
-	m, err, err2 := machines.ValidateMachine(logger, obj)
-	if err == nil {
-		this.indexer.Set(m)
-	}
-	return reconcile.DelayOnError(logger, err2)
+	affected, err := machines.IndexMachine(logger, this.indexer, obj)
+	this.secondary.Set(api.MACHINEINFO, obj.Data())
+	this.remote.EnqueueObjects(logger, obj.ClusterKey(), affected)
+	return reconcile.DelayOnError(logger, err)
 }
 
 func (this *reconciler) Deleted(logger logger.LogContext, key resources.ClusterObjectKey) reconcile.Status {
 	logger.Infof("deleted")
+	affected := this.indexer.Conflicts(key.ObjectName())
 	this.indexer.Delete(key.ObjectName())
+	this.secondary.Delete(api.MACHINEINFO, key.ObjectName())
+	this.remote.EnqueueObjects(logger, key, affected)
 	return reconcile.Succeeded(logger)
 }
+
+///////////////////////////////////////////////////////////////////////////////
+
+// remoteHandler indexes the objects of additional clusters.
+// They are not maintained in the secondary indices.
+type remoteHandler struct {
+	indexer machines.MachineIndexer
+}
+
+var _ controllers.RemoteHandler = &remoteHandler{}
+
+func (this *remoteHandler) Update(logger logger.LogContext, obj resources.Object) []resources.ObjectName {
+	affected, err := machines.IndexMachine(logger, this.indexer, obj)
+	if err != nil {
+		logger.Warnf("%s", err)
+	}
+	return affected
+}
+
+func (this *remoteHandler) Delete(logger logger.LogContext, name resources.ObjectName) []resources.ObjectName {
+	affected := this.indexer.Conflicts(name)
+	this.indexer.Delete(name)
+	return affected
+}
+
+func (this *remoteHandler) Names(cluster string) []resources.ObjectName {
+	lister, ok := this.indexer.(machines.MachineLister)
+	if !ok {
+		return nil
+	}
+	var names []resources.ObjectName
+	for _, e := range lister.List() {
+		if machines.ClusterOf(e.Name) == cluster {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+func (this *remoteHandler) Clusters() []string {
+	lister, ok := this.indexer.(machines.MachineLister)
+	if !ok {
+		return nil
+	}
+	clusters := utils.StringSet{}
+	for _, e := range lister.List() {
+		if c := machines.ClusterOf(e.Name); c != "" {
+			clusters.Add(c)
+		}
+	}
+	return clusters.AsArray()
+}
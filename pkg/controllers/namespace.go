@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/config"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// NamespaceConfig restricts the namespaces watched and indexed by
+// an inventory controller.
+type NamespaceConfig struct {
+	LocalNamespaceOnly bool
+	Namespaces         []string
+}
+
+func (this *NamespaceConfig) AddOptionsToSet(set config.OptionSet) {
+	set.AddBoolOption(&this.LocalNamespaceOnly, "local-namespace-only", "", false, "server only resources in local namespace")
+	set.AddStringArrayOption(&this.Namespaces, "namespaces", "", nil, "namespaces to index (default: all namespaces)")
+}
+
+func (this *NamespaceConfig) Prepare() error {
+	if this.LocalNamespaceOnly && len(this.Namespaces) > 0 {
+		return fmt.Errorf("local namespace only cannot be combined with a namespace list")
+	}
+	for _, n := range this.Namespaces {
+		if n == "" {
+			return fmt.Errorf("empty namespace in namespace list")
+		}
+	}
+	return nil
+}
+
+// Scope returns the namespace scope of a controller.
+func (this *NamespaceConfig) Scope(c controller.Interface) *machines.Scope {
+	if this.LocalNamespaceOnly {
+		return machines.NewScope(c.GetEnvironment().Namespace())
+	}
+	return machines.NewScope(this.Namespaces...)
+}
+
+// NamespaceScoped is implemented by controller configurations
+// restricting the indexed namespaces.
+type NamespaceScoped interface {
+	Scope(c controller.Interface) *machines.Scope
+}
+
+// NamespaceSelection restricts the watch of the main resource of a
+// controller to the namespace of a scope with a single namespace.
+// Scopes with several namespaces require a watch for all namespaces.
+func NamespaceSelection(c controller.Interface) (string, resources.TweakListOptionsFunc) {
+	cfg, err := c.GetOptionSource("options")
+	if err != nil {
+		return "", nil
+	}
+	if s, ok := cfg.(NamespaceScoped); ok {
+		return s.Scope(c).Watch(), nil
+	}
+	return "", nil
+}
@@ -33,7 +33,7 @@ import (
 
 ////////////////////////////////////////////////////////////////////////////////
 
-var typekey = ctxutil.SimpleKey("machineindex")
+var typekey = ctxutil.SimpleKey("machinetypeindex")
 
 func GetOrCreateMachineTypeIndex(env extension.Environment, indexcreator func() machines.MachineTypeIndex) machines.MachineTypeIndex {
 	return env.ControllerManager().GetOrCreateSharedValue(typekey, func() interface{} {
@@ -87,6 +87,39 @@ func GetBMCIndex(env extension.Environment) machines.BMCIndex {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+var leasekey = ctxutil.SimpleKey("leaseindex")
+
+func GetOrCreateLeaseIndex(env extension.Environment, indexcreator func() machines.LeaseIndex) machines.LeaseIndex {
+	return env.ControllerManager().GetOrCreateSharedValue(leasekey, func() interface{} {
+		return indexcreator()
+	}).(machines.LeaseIndex)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+var feedkey = ctxutil.SimpleKey("changefeed")
+
+// GetOrCreateChangeFeed provides the change feed shared by all index servers.
+func GetOrCreateChangeFeed(env extension.Environment) *machines.ChangeFeed {
+	return env.ControllerManager().GetOrCreateSharedValue(feedkey, func() interface{} {
+		return machines.NewChangeFeed()
+	}).(*machines.ChangeFeed)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+var secondarykey = ctxutil.SimpleKey("secondaryindices")
+
+// GetOrCreateSecondaryIndices provides the registered secondary indices
+// maintained by the inventory controllers.
+func GetOrCreateSecondaryIndices(env extension.Environment) *machines.SecondaryIndices {
+	return env.ControllerManager().GetOrCreateSharedValue(secondarykey, func() interface{} {
+		return machines.NewSecondaryIndices()
+	}).(*machines.SecondaryIndices)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 type Client interface{}
 
 type MachineClient interface {
@@ -0,0 +1,274 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/extension"
+	"github.com/gardener/controller-manager-library/pkg/ctxutil"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// ParseRemoteClusters parses the additional cluster specifications
+// of the form <cluster identity>=<kubeconfig file>.
+func ParseRemoteClusters(specs []string) (map[string]string, error) {
+	clusters := map[string]string{}
+	for _, s := range specs {
+		i := strings.Index(s, "=")
+		if i <= 0 || i == len(s)-1 {
+			return nil, fmt.Errorf("invalid cluster %q: <identity>=<kubeconfig> required", s)
+		}
+		id := s[:i]
+		if strings.ContainsAny(id, ":/") {
+			return nil, fmt.Errorf("invalid cluster identity %q", id)
+		}
+		if _, ok := clusters[id]; ok {
+			return nil, fmt.Errorf("duplicate cluster identity %q", id)
+		}
+		clusters[id] = s[i+1:]
+	}
+	return clusters, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+var remotekey = ctxutil.SimpleKey("remoteclusters")
+
+// RemoteClusters are the additional clusters watched by the inventory
+// controllers. Every cluster is created only once and shared by all
+// controllers using it.
+type RemoteClusters struct {
+	lock        sync.Mutex
+	clusters    map[string]cluster.Interface
+	kubeconfigs map[string]string
+}
+
+func GetOrCreateRemoteClusters(env extension.Environment) *RemoteClusters {
+	return env.ControllerManager().GetOrCreateSharedValue(remotekey, func() interface{} {
+		return &RemoteClusters{
+			clusters:    map[string]cluster.Interface{},
+			kubeconfigs: map[string]string{},
+		}
+	}).(*RemoteClusters)
+}
+
+// Get provides the additional cluster with the given identity.
+func (this *RemoteClusters) Get(c controller.Interface, id, kubeconfig string) (cluster.Interface, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if found := this.clusters[id]; found != nil {
+		if this.kubeconfigs[id] != kubeconfig {
+			return nil, fmt.Errorf("cluster %q already configured with kubeconfig %q", id, this.kubeconfigs[id])
+		}
+		return found, nil
+	}
+	def := cluster.Configure(id, "", "cluster "+id).
+		Scheme(c.GetMainCluster().Definition().Scheme()).
+		Definition()
+	remote, err := cluster.CreateCluster(c.GetEnvironment().GetContext(), c, def, id, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	remote.SetAttr(machines.ATTR_CLUSTER, id)
+	this.clusters[id] = remote
+	this.kubeconfigs[id] = kubeconfig
+	return remote, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// RemoteHandler indexes the objects of additional clusters. Update and
+// Delete return the objects whose conflict state has changed.
+type RemoteHandler interface {
+	Update(logger logger.LogContext, obj resources.Object) []resources.ObjectName
+	Delete(logger logger.LogContext, name resources.ObjectName) []resources.ObjectName
+	// Names returns the indexed objects of a cluster, if supported
+	// by the index.
+	Names(cluster string) []resources.ObjectName
+	// Clusters returns the identities of the additional clusters
+	// of the indexed objects, if supported by the index.
+	Clusters() []string
+}
+
+// RemoteWatches feeds the objects of the main resource of a controller
+// found in additional clusters into the index of the controller.
+// Those objects are not handled by the reconcile queue of the
+// controller, they are indexed directly by the watch handlers.
+type RemoteWatches struct {
+	lock       sync.Mutex
+	controller controller.Interface
+	gk         schema.GroupKind
+	handler    RemoteHandler
+	scope      *machines.Scope
+	resources  map[string]resources.Interface
+}
+
+func NewRemoteWatches(c controller.Interface, gk schema.GroupKind, handler RemoteHandler) *RemoteWatches {
+	return &RemoteWatches{
+		controller: c,
+		gk:         gk,
+		handler:    handler,
+		resources:  map[string]resources.Interface{},
+	}
+}
+
+// Setup starts the watches for the given cluster specifications and
+// removes objects of those clusters from the index, which do not
+// exist anymore. Objects of other clusters, for example restored
+// from a snapshot, are removed, too. Only objects of the given scope
+// are indexed.
+func (this *RemoteWatches) Setup(specs []string, scope *machines.Scope) error {
+	clusters, err := ParseRemoteClusters(specs)
+	if err != nil {
+		return err
+	}
+	this.scope = scope
+	for _, id := range this.handler.Clusters() {
+		if _, ok := clusters[id]; ok {
+			continue
+		}
+		for _, n := range this.handler.Names(id) {
+			this.controller.Infof("%s %s of unconfigured cluster %s removed", this.gk.Kind, n, id)
+			this.revalidate(this.controller, this.handler.Delete(this.controller, n))
+		}
+	}
+	shared := GetOrCreateRemoteClusters(this.controller.GetEnvironment())
+	for id, kubeconfig := range clusters {
+		remote, err := shared.Get(this.controller, id, kubeconfig)
+		if err != nil {
+			return err
+		}
+		resc, err := remote.Resources().Get(this.gk)
+		if err != nil {
+			return err
+		}
+		this.lock.Lock()
+		this.resources[id] = resc
+		this.lock.Unlock()
+
+		err = resc.AddSelectedEventHandler(resources.ResourceEventHandlerFuncs{
+			AddFunc:    this.update,
+			UpdateFunc: func(old, new resources.Object) { this.update(new) },
+			DeleteFunc: this.delete,
+		}, scope.Watch(), nil)
+		if err != nil {
+			return err
+		}
+		this.cleanup(id, resc)
+	}
+	return nil
+}
+
+func (this *RemoteWatches) cleanup(id string, resc resources.Interface) {
+	list, err := this.scope.ListCached(resc)
+	if err != nil {
+		this.controller.Warnf("cannot list %s of cluster %s: %s", this.gk.Kind, id, err)
+		return
+	}
+	live := map[string]struct{}{}
+	for _, l := range list {
+		live[machines.ObjectNameFor(l).String()] = struct{}{}
+	}
+	for _, n := range this.handler.Names(id) {
+		if _, ok := live[n.String()]; !ok {
+			this.controller.Infof("%s %s not found anymore", this.gk.Kind, n)
+			this.revalidate(this.controller, this.handler.Delete(this.controller, n))
+		}
+	}
+}
+
+func (this *RemoteWatches) update(obj resources.Object) {
+	if !this.scope.Contains(obj.GetNamespace()) {
+		return
+	}
+	logger := this.controller.NewContext("object", machines.ObjectNameFor(obj).String())
+	logger.Infof("update")
+	this.revalidate(logger, this.handler.Update(logger, obj))
+}
+
+func (this *RemoteWatches) delete(obj resources.Object) {
+	name := machines.ObjectNameFor(obj)
+	logger := this.controller.NewContext("object", name.String())
+	logger.Infof("deleted")
+	this.revalidate(logger, this.handler.Delete(logger, name))
+}
+
+// EnqueueObjects revalidates the given objects. Objects of the main
+// cluster are enqueued into the controller, objects of additional
+// clusters are indexed again.
+func (this *RemoteWatches) EnqueueObjects(logger logger.LogContext, key resources.ClusterObjectKey, names []resources.ObjectName) {
+	var local []resources.ObjectName
+	var remote []resources.ObjectName
+	for _, n := range names {
+		if machines.ClusterOf(n) == "" {
+			local = append(local, n)
+		} else {
+			remote = append(remote, n)
+		}
+	}
+	EnqueueObjects(logger, this.controller, key, local)
+	this.revalidate(logger, remote)
+}
+
+func (this *RemoteWatches) revalidate(logger logger.LogContext, names []resources.ObjectName) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	main := this.controller.GetMainCluster().GetId()
+	for len(names) > 0 {
+		n := names[0]
+		names = names[1:]
+		id := machines.ClusterOf(n)
+		if id == "" {
+			logger.Infof("revalidate %s", n)
+			this.controller.EnqueueKey(resources.NewClusterKey(main, this.gk, n.Namespace(), n.Name()))
+			continue
+		}
+		resc := this.resources[id]
+		if resc == nil {
+			continue
+		}
+		logger.Infof("revalidate %s", n)
+		obj, err := resc.GetCached(n)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				names = append(names, this.handler.Delete(logger, n)...)
+			} else {
+				logger.Warnf("cannot get %s: %s", n, err)
+			}
+			continue
+		}
+		names = append(names, this.handler.Update(logger, obj)...)
+	}
+}
@@ -34,7 +34,7 @@ func init() {
 		Reconciler(Create).
 		DefaultWorkerPool(1, 0).
 		OptionsByExample("options", &Config{}).
-		MainResourceByGK(api.MACHINETYPE).
+		MainResourceByGK(api.MACHINETYPE, controllers.NamespaceSelection).
 		MustRegister(controllers.GROUP_MACHINES)
 }
 
@@ -47,7 +47,9 @@ func Create(controller controller.Interface) (reconcile.Interface, error) {
 	this := &reconciler{
 		controller: controller,
 		config:     config,
+		scope:      config.Scope(controller),
 		indexer:    controllers.GetOrCreateMachineTypeIndex(controller.GetEnvironment(), func() machines.MachineTypeIndex { return machines.NewTypeFullIndexer() }).(machines.MachineTypeIndexer),
 	}
+	this.remote = controllers.NewRemoteWatches(controller, api.MACHINETYPE, &remoteHandler{this.indexer})
 	return this, nil
 }
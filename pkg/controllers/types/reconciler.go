@@ -27,7 +27,9 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller/reconcile"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/utils"
 
+	"github.com/onmetal/k8s-machines/pkg/controllers"
 	"github.com/onmetal/k8s-machines/pkg/machines"
 )
 
@@ -36,14 +38,21 @@ type reconciler struct {
 
 	controller controller.Interface
 	config     *Config
+	scope      *machines.Scope
 
 	indexer machines.MachineTypeIndexer
+	remote  *controllers.RemoteWatches
 }
 
 var _ reconcile.Interface = &reconciler{}
 
 func (this *reconciler) Setup() error {
-	err := this.indexer.Setup(this.controller, this.controller.GetMainCluster())
+	controllers.LoadSnapshot(this.controller, this.indexer, this.config.SnapshotFile)
+	err := this.indexer.Setup(this.controller, this.controller.GetMainCluster(), this.scope)
+	if err == nil {
+		controllers.WriteSnapshots(this.controller, this.indexer, this.config.SnapshotFile, this.config.SnapshotInterval)
+		err = this.remote.Setup(this.config.RemoteClusters, this.scope)
+	}
 	return err
 }
 
@@ -54,6 +63,9 @@ func (this *reconciler) Config() *Config {
 }
 
 func (this *reconciler) Reconcile(logger logger.LogContext, obj resources.Object) reconcile.Status {
+	if !this.scope.Contains(obj.GetNamespace()) {
+		return reconcile.Succeeded(logger)
+	}
 	logger.Infof("reconcile")
 
 	m, err, err2 := machines.ValidateMachineType(logger, obj)
@@ -68,3 +80,58 @@ func (this *reconciler) Deleted(logger logger.LogContext, key resources.ClusterO
 	this.indexer.Delete(key.ObjectName())
 	return reconcile.Succeeded(logger)
 }
+
+///////////////////////////////////////////////////////////////////////////////
+
+// remoteHandler indexes the machine types of additional clusters.
+type remoteHandler struct {
+	indexer machines.MachineTypeIndexer
+}
+
+var _ controllers.RemoteHandler = &remoteHandler{}
+
+func (this *remoteHandler) Update(logger logger.LogContext, obj resources.Object) []resources.ObjectName {
+	m, err, err2 := machines.ValidateMachineType(logger, obj)
+	if err == nil {
+		this.indexer.Set(m)
+	} else {
+		this.indexer.Delete(machines.ObjectNameFor(obj))
+	}
+	if err2 != nil {
+		logger.Warnf("%s", err2)
+	}
+	return nil
+}
+
+func (this *remoteHandler) Delete(logger logger.LogContext, name resources.ObjectName) []resources.ObjectName {
+	this.indexer.Delete(name)
+	return nil
+}
+
+func (this *remoteHandler) Names(cluster string) []resources.ObjectName {
+	lister, ok := this.indexer.(machines.MachineTypeLister)
+	if !ok {
+		return nil
+	}
+	var names []resources.ObjectName
+	for _, e := range lister.List() {
+		if machines.ClusterOf(e.Name) == cluster {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+func (this *remoteHandler) Clusters() []string {
+	lister, ok := this.indexer.(machines.MachineTypeLister)
+	if !ok {
+		return nil
+	}
+	clusters := utils.StringSet{}
+	for _, e := range lister.List() {
+		if c := machines.ClusterOf(e.Name); c != "" {
+			clusters.Add(c)
+		}
+	}
+	return clusters.AsArray()
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package controllers
+
+import (
+	"os"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/controller"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// EnqueueObjects enqueues the objects with the given names and the
+// group kind of the given key, for example to revalidate objects
+// affected by changed index conflicts.
+func EnqueueObjects(logger logger.LogContext, c controller.Interface, key resources.ClusterObjectKey, names []resources.ObjectName) {
+	for _, n := range names {
+		logger.Infof("revalidate %s", n)
+		c.EnqueueKey(resources.NewClusterKey(key.Cluster(), key.GroupKind(), n.Namespace(), n.Name()))
+	}
+}
+
+// LoadSnapshot warm starts an index from a snapshot file, if the index
+// supports snapshots and the file exists. Failures are only logged,
+// the index is then initialized by a regular setup.
+func LoadSnapshot(logger logger.LogContext, index interface{}, path string) {
+	s, ok := index.(machines.SnapshotIndex)
+	if !ok || path == "" {
+		return
+	}
+	err := s.LoadSnapshot(logger, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Infof("no snapshot %s found", path)
+		} else {
+			logger.Warnf("cannot load snapshot: %s", err)
+		}
+	}
+}
+
+// WriteSnapshots periodically writes snapshots of an index until the
+// controller is stopped. A final snapshot is written on shutdown.
+func WriteSnapshots(c controller.Interface, index interface{}, path string, interval time.Duration) {
+	s, ok := index.(machines.SnapshotIndex)
+	if !ok || path == "" {
+		return
+	}
+	write := func() {
+		if err := s.WriteSnapshot(path); err != nil {
+			c.Warnf("cannot write snapshot %s: %s", path, err)
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.GetContext().Done():
+				write()
+				return
+			case <-ticker.C:
+				write()
+			}
+		}
+	}()
+}
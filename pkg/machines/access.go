@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// AccessConfig describes the access to a secured index server.
+type AccessConfig struct {
+	// UseTLS enforces https, it is implied by a CA or client certificate.
+	UseTLS bool
+	// CAFile is used to verify the server certificate.
+	CAFile string
+	// CertFile and KeyFile describe the client certificate.
+	CertFile string
+	KeyFile  string
+	// Token is used as bearer token.
+	Token string
+	// TokenFile is read for every request to support token rotation.
+	TokenFile string
+}
+
+func (this *AccessConfig) Scheme() string {
+	if this != nil && (this.UseTLS || this.CAFile != "" || this.CertFile != "") {
+		return "https"
+	}
+	return "http"
+}
+
+// Client provides an http client for the described access.
+func (this *AccessConfig) Client() (*http.Client, error) {
+	if this == nil {
+		return http.DefaultClient, nil
+	}
+	if this.Token != "" && this.TokenFile != "" {
+		return nil, fmt.Errorf("only one of token or token file possible")
+	}
+	if (this.CertFile == "") != (this.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate requires certificate and key file")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if this.Scheme() == "https" {
+		tlscfg := &tls.Config{}
+		if this.CAFile != "" {
+			data, err := ioutil.ReadFile(this.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read ca file: %s", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in ca file %q", this.CAFile)
+			}
+			tlscfg.RootCAs = pool
+		}
+		if this.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(this.CertFile, this.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("cannot load client certificate: %s", err)
+			}
+			tlscfg.Certificates = []tls.Certificate{cert}
+		}
+		transport.TLSClientConfig = tlscfg
+	}
+
+	var rt http.RoundTripper = transport
+	if this.Token != "" || this.TokenFile != "" {
+		rt = &tokenTransport{transport: transport, token: this.Token, file: this.TokenFile}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+type tokenTransport struct {
+	transport http.RoundTripper
+	token     string
+	file      string
+}
+
+func (this *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token := this.token
+	if this.file != "" {
+		data, err := ioutil.ReadFile(this.file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read token file: %s", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	return this.transport.RoundTrip(r)
+}
@@ -23,6 +23,9 @@
 package machines
 
 import (
+	"fmt"
+	"net"
+
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/types"
@@ -34,42 +37,81 @@ import (
 func NewBaseBoardManagementController(m *api.BaseBoardManagementControllerInfo) (*BaseBoardManagementController, error) {
 	setDefaults(&m.Spec.Values)
 
+	var serials, assettags []string
 	for _, fru := range m.Spec.FRUs {
-		setDefaults(&fru.Product.Values)
-		setDefaults(&fru.Chassis.Values)
-		setDefaults(&fru.Board.Values)
+		for _, info := range []*api.FieldReplacableUnitInfo{fru.Chassis, fru.Board, fru.Product} {
+			if info == nil {
+				continue
+			}
+			setDefaults(&info.Values)
+			serials = appendKeys(serials, info.Serial)
+			assettags = appendKeys(assettags, info.AssetTag)
+		}
 	}
 
+	mac := ""
+	if m.Spec.MAC != "" {
+		var err error
+		mac, err = NormalizeMAC(m.Spec.MAC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mac address %q: %s", m.Spec.MAC, err)
+		}
+	}
 	return &BaseBoardManagementController{
 		Name:                                  resources.NewObjectName(m.Namespace, m.Name),
+		ResourceVersion:                       m.ResourceVersion,
 		BaseBoardManagementControllerInfoSpec: &m.Spec,
+		mac:                                   mac,
+		ip:                                    canonicalIP(m.Spec.IP),
+		serials:                               serials,
+		assettags:                             assettags,
+		labels:                                m.Labels,
 	}, nil
 }
 
+// canonicalIP returns the canonical representation of an IP address.
+// Other values are kept as they are.
+func canonicalIP(s string) string {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.String()
+	}
+	return s
+}
+
 func setDefaults(values *types.Values) {
 	if values.Values == nil {
 		values.Values = simple.Values{}
 	}
 }
 
-func ValidateBMC(logger logger.LogContext, obj resources.Object) (*BaseBoardManagementController, error, error) {
+// IndexBMC validates a BMC info object, updates the index and the
+// status of the object like IndexMachine.
+func IndexBMC(logger logger.LogContext, index BMCIndexer, obj resources.Object) ([]resources.ObjectName, error) {
+	name := ObjectNameFor(obj)
+	before := index.Conflicts(name)
 	m, err := NewBaseBoardManagementController(obj.Data().(*api.BaseBoardManagementControllerInfo))
-
 	if err != nil {
 		logger.Errorf("invalid bmc info: %s", err)
-		_, err2 := resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
-			m := mod.Data().(*api.BaseBoardManagementControllerInfo)
-			mod.AssureStringValue(&m.Status.State, api.STATE_INVALID)
-			mod.AssureStringValue(&m.Status.Message, err.Error())
-			return nil
-		})
-		return nil, err, err2
+		index.Delete(name)
+	} else {
+		m.Name = name
+		err = index.Set(m)
+		if err != nil {
+			logger.Errorf("conflicting bmc info: %s", err)
+		}
 	}
-	_, err = resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
-		m := mod.Data().(*api.BaseBoardManagementControllerInfo)
-		mod.AssureStringValue(&m.Status.State, api.STATE_OK)
-		mod.AssureStringValue(&m.Status.Message, "machine ok")
+	return changedConflicts(before, index.Conflicts(name)), updateStatus(obj, err, "machine ok")
+}
+
+// RedactBMC returns a copy of a BMC without credentials. It is used
+// for all BMC objects returned by the index server.
+func RedactBMC(b *BaseBoardManagementController) *BaseBoardManagementController {
+	if b == nil {
 		return nil
-	})
-	return m, nil, err
+	}
+	spec := *b.BaseBoardManagementControllerInfoSpec
+	spec.Credentials = nil
+	r := *b
+	r.BaseBoardManagementControllerInfoSpec = &spec
+	return &r
 }
@@ -23,13 +23,16 @@
 package machines
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
-	"k8s.io/apimachinery/pkg/labels"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
 )
@@ -41,24 +44,51 @@ import (
  */
 
 type BMCFullIndexer struct {
+	listeners
+	snapshotState
 	initlock    sync.RWMutex
 	lock        sync.RWMutex
 	initialized int32
+	lastSync    time.Time
 	elements    map[resources.ObjectName]*BaseBoardManagementController
-	byMACs      map[string]*BaseBoardManagementController
-	byUUIDs     map[string]*BaseBoardManagementController
+	byMACs      claims
+	byUUIDs     claims
+	byIPs       claims
+	bySerials   claims
+	byAssetTags claims
 }
 
 func NewBMCFullIndexer() BMCIndexer {
 	m := &BMCFullIndexer{
-		elements: map[resources.ObjectName]*BaseBoardManagementController{},
-		byMACs:   map[string]*BaseBoardManagementController{},
-		byUUIDs:  map[string]*BaseBoardManagementController{},
+		elements:    map[resources.ObjectName]*BaseBoardManagementController{},
+		byMACs:      claims{},
+		byUUIDs:     claims{},
+		byIPs:       claims{},
+		bySerials:   claims{},
+		byAssetTags: claims{},
 	}
 	m.initlock.Lock()
 	return m
 }
 
+var _ IndexStatistics = &BMCFullIndexer{}
+var _ SnapshotIndex = &BMCFullIndexer{}
+var _ BMCClaimIndex = &BMCFullIndexer{}
+
+func (this *BMCFullIndexer) Size() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return len(this.elements)
+}
+
+func (this *BMCFullIndexer) LastSync() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.lastSync
+}
+
 func (this *BMCFullIndexer) Wait() {
 	this.initlock.RLock()
 	this.initlock.RUnlock()
@@ -72,14 +102,108 @@ func (this *BMCFullIndexer) GetByMAC(mac string) *BaseBoardManagementController
 	this.lock.RLock()
 	defer this.lock.RUnlock()
 
-	return this.byMACs[mac]
+	return this.get(this.byMACs.get(canonicalMAC(mac)))
 }
 
 func (this *BMCFullIndexer) GetByUUID(uuid string) *BaseBoardManagementController {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
 
-	return this.byUUIDs[uuid]
+	return this.get(this.byUUIDs.get(uuid))
+}
+
+func (this *BMCFullIndexer) GetByIP(ip string) *BaseBoardManagementController {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.get(this.byIPs.get(canonicalIP(ip)))
+}
+
+func (this *BMCFullIndexer) GetBySerial(serial string) *BaseBoardManagementController {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.get(this.bySerials.get(serial))
+}
+
+func (this *BMCFullIndexer) GetByAssetTag(tag string) *BaseBoardManagementController {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.get(this.byAssetTags.get(tag))
+}
+
+func (this *BMCFullIndexer) get(name resources.ObjectName) *BaseBoardManagementController {
+	if name == nil {
+		return nil
+	}
+	return this.elements[name]
+}
+
+func (this *BMCFullIndexer) ClaimsForMAC(mac string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byMACs.claimants(canonicalMAC(mac))
+}
+
+func (this *BMCFullIndexer) ClaimsForUUID(uuid string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byUUIDs.claimants(uuid)
+}
+
+func (this *BMCFullIndexer) ClaimsForIP(ip string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byIPs.claimants(canonicalIP(ip))
+}
+
+func (this *BMCFullIndexer) ClaimsForSerial(serial string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.bySerials.claimants(serial)
+}
+
+func (this *BMCFullIndexer) ClaimsForAssetTag(tag string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byAssetTags.claimants(tag)
+}
+
+func (this *BMCFullIndexer) Conflicts(name resources.ObjectName) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	m := this.elements[name]
+	if m == nil {
+		return nil
+	}
+	set := map[resources.ObjectName]struct{}{}
+	if m.mac != "" {
+		addNames(set, others(this.byMACs[m.mac], name))
+	}
+	if m.UUID != "" {
+		addNames(set, others(this.byUUIDs[m.UUID], name))
+	}
+	return sortedNames(set)
+}
+
+// List returns all indexed objects ordered by name.
+func (this *BMCFullIndexer) List() []*BaseBoardManagementController {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	list := make([]*BaseBoardManagementController, 0, len(this.elements))
+	for _, e := range this.elements {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name.String() < list[j].Name.String() })
+	return list
 }
 
 func (this *BMCFullIndexer) GetByName(name resources.ObjectName) *BaseBoardManagementController {
@@ -89,13 +213,14 @@ func (this *BMCFullIndexer) GetByName(name resources.ObjectName) *BaseBoardManag
 	return this.elements[name]
 }
 
-func (this *BMCFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface) error {
-	if atomic.LoadInt32(&this.initialized) != 0 {
-		logger.Infof("machine cache already initialized")
+func (this *BMCFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error {
+	stale := this.IsStale()
+	if atomic.LoadInt32(&this.initialized) != 0 && !stale {
+		logger.Infof("bmc cache already initialized")
 		return nil
 	}
 	if cluster == nil {
-		logger.Infof("waiting for machine cache")
+		logger.Infof("waiting for bmc cache")
 		this.Wait()
 		return nil
 	}
@@ -104,58 +229,203 @@ func (this *BMCFullIndexer) Setup(logger logger.LogContext, cluster cluster.Inte
 	if err != nil {
 		return err
 	}
-	logger.Infof("setup machines")
-	list, _ := resc.ListCached(labels.Everything())
+	logger.Infof("setup bmcs")
+	list, _ := scope.ListCached(resc)
 
+	live := map[resources.ObjectName]struct{}{}
 	for _, l := range list {
-		elem, err, _ := ValidateBMC(logger, l)
+		elem, err := NewBaseBoardManagementController(l.Data().(*api.BaseBoardManagementControllerInfo))
 		if elem != nil {
-			this.Set(elem)
-			logger.Infof("found machine %s", elem.Name)
+			live[elem.Name] = struct{}{}
+			if old := this.GetByName(elem.Name); stale && old != nil && old.ResourceVersion == elem.ResourceVersion {
+				continue
+			}
+			err = this.Set(elem)
+			logger.Infof("found bmc %s", elem.Name)
 		}
 		if err != nil {
-			logger.Infof("errorneous machine %s: %s", l.GetName(), err)
+			logger.Infof("errorneous bmc %s: %s", l.GetName(), err)
 		}
 	}
-	logger.Infof("machine cache setup done")
-	atomic.StoreInt32(&this.initialized, 1)
-	this.initlock.Unlock()
+	if stale {
+		for _, e := range this.List() {
+			// objects of additional clusters are reconciled by the remote
+			// watches, which drop the objects of unconfigured clusters
+			if _, ok := live[e.Name]; !ok && ClusterOf(e.Name) == "" {
+				logger.Infof("bmc %s from snapshot not found anymore", e.Name)
+				this.Delete(e.Name)
+			}
+		}
+	}
+	logger.Infof("bmc cache setup done")
+	this.lock.Lock()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	this.setConsistent()
+	if stale {
+		logger.Infof("bmc cache is consistent now (snapshot from %s)", this.SnapshotTime().Format(time.RFC3339))
+	} else {
+		atomic.StoreInt32(&this.initialized, 1)
+		this.initlock.Unlock()
+	}
 	return nil
 }
 
 func (this *BMCFullIndexer) Set(m *BaseBoardManagementController) error {
 	this.lock.Lock()
-	defer this.lock.Unlock()
-
 	old := this.elements[m.Name]
 	if old != nil {
 		this.cleanup(old)
 	}
-	this.set(m)
+	conflicts := this.set(m)
+	this.updateMetrics()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_BMCINFO, OP_SET).Inc()
+
+	c := newChange(old != nil, m.Name, m.ResourceVersion)
+	if old != nil {
+		c.addKeys(old)
+	}
+	c.addKeys(m)
+	this.notify(c)
+	if len(conflicts) > 0 {
+		return &ConflictError{Name: m.Name, Conflicts: conflicts}
+	}
 	return nil
 }
 
 func (this *BMCFullIndexer) Delete(name resources.ObjectName) {
 	this.lock.Lock()
-	defer this.lock.Unlock()
 	old := this.elements[name]
 	if old != nil {
 		this.cleanup(old)
+		this.updateMetrics()
+	}
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_BMCINFO, OP_DELETE).Inc()
+
+	if old != nil {
+		c := &IndexChange{Type: CHANGE_DELETED, Name: name, ResourceVersion: old.ResourceVersion}
+		c.addKeys(old)
+		this.notify(c)
 	}
 }
 
 func (this *BMCFullIndexer) cleanup(m *BaseBoardManagementController) {
-	delete(this.byMACs, m.MAC)
-	delete(this.byUUIDs, m.UUID)
+	if m.mac != "" {
+		this.byMACs.remove(m.mac, m.Name)
+	}
+	if m.UUID != "" {
+		this.byUUIDs.remove(m.UUID, m.Name)
+	}
+	if m.ip != "" {
+		this.byIPs.remove(m.ip, m.Name)
+	}
+	for _, s := range m.serials {
+		this.bySerials.remove(s, m.Name)
+	}
+	for _, t := range m.assettags {
+		this.byAssetTags.remove(t, m.Name)
+	}
 	delete(this.elements, m.Name)
 }
 
-func (this *BMCFullIndexer) set(m *BaseBoardManagementController) {
-	if m.MAC != "" {
-		this.byMACs[m.MAC] = m
+// set indexes a BMC and returns the other objects claiming
+// its MAC or UUID. Ambiguous IPs, serials and asset tags are
+// only reported by lookups.
+func (this *BMCFullIndexer) set(m *BaseBoardManagementController) []resources.ObjectName {
+	set := map[resources.ObjectName]struct{}{}
+	if m.mac != "" {
+		if o := this.byMACs.add(m.mac, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_BMCINFO, KEY_MAC).Inc()
+			addNames(set, o)
+		}
 	}
 	if m.UUID != "" {
-		this.byUUIDs[m.UUID] = m
+		if o := this.byUUIDs.add(m.UUID, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_BMCINFO, KEY_UUID).Inc()
+			addNames(set, o)
+		}
+	}
+	if m.ip != "" {
+		if o := this.byIPs.add(m.ip, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_BMCINFO, KEY_IP).Inc()
+		}
+	}
+	for _, s := range m.serials {
+		if o := this.bySerials.add(s, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_BMCINFO, KEY_SERIAL).Inc()
+		}
+	}
+	for _, t := range m.assettags {
+		if o := this.byAssetTags.add(t, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_BMCINFO, KEY_ASSETTAG).Inc()
+		}
 	}
 	this.elements[m.Name] = m
+	return sortedNames(set)
+}
+
+func (this *BMCFullIndexer) updateMetrics() {
+	indexElements.WithLabelValues(PATH_BMCINFO).Set(float64(len(this.elements)))
+	indexKeys.WithLabelValues(PATH_BMCINFO, KEY_MAC).Set(float64(len(this.byMACs)))
+	indexKeys.WithLabelValues(PATH_BMCINFO, KEY_UUID).Set(float64(len(this.byUUIDs)))
+	indexKeys.WithLabelValues(PATH_BMCINFO, KEY_IP).Set(float64(len(this.byIPs)))
+	indexKeys.WithLabelValues(PATH_BMCINFO, KEY_SERIAL).Set(float64(len(this.bySerials)))
+	indexKeys.WithLabelValues(PATH_BMCINFO, KEY_ASSETTAG).Set(float64(len(this.byAssetTags)))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// snapshots
+
+// LoadSnapshot fills the uninitialized index with the objects of
+// a snapshot file. The index is then initialized, but stale until
+// the next Setup.
+func (this *BMCFullIndexer) LoadSnapshot(logger logger.LogContext, path string) error {
+	if atomic.LoadInt32(&this.initialized) != 0 {
+		return fmt.Errorf("bmc index already initialized")
+	}
+	var list []*api.BaseBoardManagementControllerInfo
+	created, err := readSnapshot(path, "BaseBoardManagementControllerInfo", &list)
+	if err != nil {
+		return err
+	}
+	for _, o := range list {
+		elem, err := NewBaseBoardManagementController(o)
+		if elem != nil {
+			elem.Name = NewClusterObjectName(o.ClusterName, o.Namespace, o.Name)
+			err = this.Set(elem)
+		}
+		if err != nil {
+			logger.Infof("errorneous bmc %s in snapshot: %s", o.Name, err)
+		}
+	}
+	this.lock.Lock()
+	this.lastSync = created
+	this.lock.Unlock()
+	this.setStale(created)
+	logger.Infof("loaded %d bmc(s) from snapshot %s created %s", len(list), path, created.Format(time.RFC3339))
+	atomic.StoreInt32(&this.initialized, 1)
+	this.initlock.Unlock()
+	return nil
+}
+
+// WriteSnapshot writes all indexed objects to a snapshot file.
+func (this *BMCFullIndexer) WriteSnapshot(path string) error {
+	list := []*api.BaseBoardManagementControllerInfo{}
+	for _, e := range this.List() {
+		list = append(list, &api.BaseBoardManagementControllerInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     ClusterOf(e.Name),
+				Namespace:       e.Name.Namespace(),
+				Name:            e.Name.Name(),
+				ResourceVersion: e.ResourceVersion,
+				Labels:          e.labels,
+			},
+			Spec: *e.BaseBoardManagementControllerInfoSpec,
+		})
+	}
+	return writeSnapshot(path, "BaseBoardManagementControllerInfo", list)
 }
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func newBMCInfo(name, ip, serial, tag string) *api.BaseBoardManagementControllerInfo {
+	m := &api.BaseBoardManagementControllerInfo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+	m.Spec.IP = ip
+	m.Spec.FRUs = []api.FieldReplacableUnit{
+		{
+			Board:   &api.FieldReplacableUnitInfo{Serial: serial},
+			Product: &api.FieldReplacableUnitInfo{AssetTag: tag},
+		},
+		{},
+	}
+	return m
+}
+
+var _ = Describe("BMC Full Indexer", func() {
+	It("finds bmcs by ip, serial and asset tag", func() {
+		index := NewBMCFullIndexer()
+		m, err := NewBaseBoardManagementController(newBMCInfo("b1", "2001:db8::0001", "S1", "T1"))
+		Expect(err).To(BeNil())
+		Expect(index.Set(m)).To(BeNil())
+
+		Expect(index.GetByIP("2001:db8::1")).To(Equal(m))
+		Expect(index.GetBySerial("S1")).To(Equal(m))
+		Expect(index.GetByAssetTag("T1")).To(Equal(m))
+		Expect(index.GetBySerial("T1")).To(BeNil())
+
+		index.Delete(m.Name)
+		Expect(index.GetByIP("2001:db8::1")).To(BeNil())
+		Expect(index.GetBySerial("S1")).To(BeNil())
+	})
+
+	It("reports ambiguous keys without marking conflicts", func() {
+		index := NewBMCFullIndexer()
+		m1, _ := NewBaseBoardManagementController(newBMCInfo("b1", "10.0.0.1", "S1", "T1"))
+		m2, _ := NewBaseBoardManagementController(newBMCInfo("b2", "10.0.0.2", "S1", "T2"))
+		Expect(index.Set(m1)).To(BeNil())
+		Expect(index.Set(m2)).To(BeNil())
+
+		Expect(index.GetBySerial("S1")).To(BeNil())
+		Expect(index.GetByIP("10.0.0.2")).To(Equal(m2))
+		Expect(index.(BMCClaimIndex).ClaimsForSerial("S1")).To(Equal([]resources.ObjectName{m1.Name, m2.Name}))
+	})
+})
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+// claims maps index keys to the names of all objects claiming
+// them, ordered by name. A key is unique if it is claimed by
+// a single object only. Namespaces separate the inventories of
+// different tenants of a cluster, therefore only claims of objects
+// in the same namespace of a cluster are conflicts. Different clusters
+// describe different hardware, so claims of objects of other clusters
+// are conflicts regardless of their namespace. Keys claimed in several
+// namespaces are still ambiguous for lookups not restricted to a
+// namespace.
+type claims map[string][]resources.ObjectName
+
+// add adds a claim for a key and returns the conflicting claimants.
+func (this claims) add(key string, name resources.ObjectName) []resources.ObjectName {
+	list := this[key]
+	for _, n := range list {
+		if n == name {
+			return others(list, name)
+		}
+	}
+	list = append(list, name)
+	sort.Slice(list, func(i, j int) bool { return list[i].String() < list[j].String() })
+	this[key] = list
+	return others(list, name)
+}
+
+// remove removes the claim of an object for a key.
+func (this claims) remove(key string, name resources.ObjectName) {
+	list := this[key]
+	for i, n := range list {
+		if n == name {
+			list = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(this, key)
+	} else {
+		this[key] = list
+	}
+}
+
+// get returns the claimant for a key if the key is unique.
+func (this claims) get(key string) resources.ObjectName {
+	if list := this[key]; len(list) == 1 {
+		return list[0]
+	}
+	return nil
+}
+
+// claimants returns a copy of the claimants for a key.
+func (this claims) claimants(key string) []resources.ObjectName {
+	return append([]resources.ObjectName(nil), this[key]...)
+}
+
+// others returns the other claimants in the namespace of an object
+// and the claimants of other clusters.
+func others(list []resources.ObjectName, name resources.ObjectName) []resources.ObjectName {
+	var result []resources.ObjectName
+	for _, n := range list {
+		if n != name && (n.Namespace() == name.Namespace() || ClusterOf(n) != ClusterOf(name)) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// inNamespace returns the names of a namespace.
+func inNamespace(list []resources.ObjectName, namespace string) []resources.ObjectName {
+	var result []resources.ObjectName
+	for _, n := range list {
+		if n.Namespace() == namespace {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func addNames(set map[resources.ObjectName]struct{}, names []resources.ObjectName) {
+	for _, n := range names {
+		set[n] = struct{}{}
+	}
+}
+
+func sortedNames(set map[resources.ObjectName]struct{}) []resources.ObjectName {
+	if len(set) == 0 {
+		return nil
+	}
+	result := make([]resources.ObjectName, 0, len(set))
+	for n := range set {
+		result = append(result, n)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].String() < result[j].String() })
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ConflictError is returned when updating an index with an object
+// claiming keys already claimed by other objects. The object is
+// indexed anyway, but the conflicting keys are not unique anymore
+// until the conflict is resolved.
+type ConflictError struct {
+	Name      resources.ObjectName
+	Conflicts []resources.ObjectName
+}
+
+func (this *ConflictError) Error() string {
+	names := make([]string, len(this.Conflicts))
+	for i, n := range this.Conflicts {
+		names[i] = n.String()
+	}
+	return fmt.Sprintf("index keys of %s also claimed by %s", this.Name, strings.Join(names, ", "))
+}
+
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ATTR_CLUSTER is the cluster attribute holding the identity of an
+// additional cluster watched by the inventory controllers. Objects of
+// the main cluster are not qualified by a cluster identity.
+const ATTR_CLUSTER = "machines.onmetal.de/cluster"
+
+// ClusterIdentity returns the identity of an additional cluster or an
+// empty string for the main cluster.
+func ClusterIdentity(cluster resources.Cluster) string {
+	if cluster == nil {
+		return ""
+	}
+	if id, ok := cluster.GetAttr(ATTR_CLUSTER).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// ObjectNameFor returns the index name of an object. Objects of
+// additional clusters are qualified by the cluster identity.
+func ObjectNameFor(obj resources.Object) resources.ObjectName {
+	return NewClusterObjectName(ClusterIdentity(obj.GetCluster()), obj.GetNamespace(), obj.GetName())
+}
+
+// NewClusterObjectName returns an object name qualified by a cluster
+// identity. Without cluster identity a regular object name is returned.
+func NewClusterObjectName(cluster, namespace, name string) resources.ObjectName {
+	if cluster == "" {
+		return resources.NewObjectName(namespace, name)
+	}
+	return clusterObjectName{cluster: cluster, namespace: namespace, name: name}
+}
+
+// ClusterOf returns the cluster identity of an object name, or an empty
+// string for objects of the main cluster.
+func ClusterOf(name resources.ObjectName) string {
+	if n, ok := name.(clusterObjectName); ok {
+		return n.cluster
+	}
+	return ""
+}
+
+type clusterObjectName struct {
+	cluster   string
+	namespace string
+	name      string
+}
+
+func (this clusterObjectName) Namespace() string {
+	return this.namespace
+}
+
+func (this clusterObjectName) Name() string {
+	return this.name
+}
+
+func (this clusterObjectName) ForGroupKind(gk schema.GroupKind) resources.ObjectKey {
+	return resources.NewKey(gk, this.namespace, this.name)
+}
+
+func (this clusterObjectName) String() string {
+	return fmt.Sprintf("%s:%s/%s", this.cluster, this.namespace, this.name)
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newClusterMachine(cluster, name string, macs ...string) *Machine {
+	m, err := NewMachine(newMachineInfo(name, macs...))
+	Expect(err).To(BeNil())
+	m.Name = NewClusterObjectName(cluster, "default", name)
+	return m
+}
+
+var _ = Describe("Cluster Names", func() {
+	It("qualifies names of additional clusters", func() {
+		Expect(NewClusterObjectName("", "default", "m1")).To(Equal(resources.NewObjectName("default", "m1")))
+		n := NewClusterObjectName("dc1", "default", "m1")
+		Expect(n.String()).To(Equal("dc1:default/m1"))
+		Expect(ClusterOf(n)).To(Equal("dc1"))
+		Expect(ClusterOf(resources.NewObjectName("default", "m1"))).To(Equal(""))
+
+		r := NewIndexResponse(n, "1")
+		Expect(r.Cluster).To(Equal("dc1"))
+		Expect(r.Namespace).To(Equal("default"))
+	})
+
+	It("detects conflicts across clusters", func() {
+		index := NewFullIndexer()
+		m1 := newClusterMachine("", "m1", "aa:bb:cc:dd:ee:01")
+		m2 := newClusterMachine("dc1", "m1", "aa:bb:cc:dd:ee:01")
+		Expect(index.Set(m1)).To(BeNil())
+
+		err := index.Set(m2)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:01")).To(BeNil())
+		Expect(index.(ClaimIndex).ClaimsForMAC("aa:bb:cc:dd:ee:01")).To(ConsistOf(m1.Name, m2.Name))
+
+		index.Delete(m1.Name)
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:01")).To(Equal(m2))
+	})
+
+	It("detects conflicts across clusters with different namespaces", func() {
+		index := NewFullIndexer()
+		m1 := newClusterMachine("", "m1", "aa:bb:cc:dd:ee:01")
+		m2 := newClusterMachine("dc1", "m2", "aa:bb:cc:dd:ee:01")
+		m2.Name = NewClusterObjectName("dc1", "tenant", "m2")
+		Expect(index.Set(m1)).To(BeNil())
+
+		err := index.Set(m2)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(err.(*ConflictError).Conflicts).To(ConsistOf(m1.Name))
+
+		m3 := newClusterMachine("", "m3", "aa:bb:cc:dd:ee:01")
+		m3.Name = resources.NewObjectName("other", "m3")
+		err = index.Set(m3)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(err.(*ConflictError).Conflicts).To(ConsistOf(m2.Name))
+	})
+
+	It("keeps the cluster identity in snapshots", func() {
+		dir, err := ioutil.TempDir("", "snapshot")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "machines.json")
+
+		index := NewFullIndexer()
+		index.Set(newClusterMachine("dc1", "m1", "aa:bb:cc:dd:ee:01"))
+		Expect(index.(SnapshotIndex).WriteSnapshot(path)).To(BeNil())
+
+		warm := NewFullIndexer()
+		Expect(warm.(SnapshotIndex).LoadSnapshot(logger.New(), path)).To(BeNil())
+		found := warm.GetByMAC("aa:bb:cc:dd:ee:01")
+		Expect(found).NotTo(BeNil())
+		Expect(found.Name).To(Equal(NewClusterObjectName("dc1", "default", "m1")))
+	})
+})
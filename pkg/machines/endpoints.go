@@ -0,0 +1,298 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+)
+
+// PATH_READY is the path of the readiness endpoint of the index server.
+const PATH_READY = "ready"
+
+type endpoint struct {
+	host    string
+	breaker *circuitBreaker
+	healthy bool
+}
+
+// Endpoints is the set of index servers used by index server clients.
+// The endpoints are given explicitly or discovered by a DNS SRV lookup.
+// Requests are distributed round robin over the healthy endpoints and
+// fail over to the next endpoint. Every endpoint has its own circuit
+// breaker. Endpoints can be shared by several clients.
+type Endpoints struct {
+	lock   sync.Mutex
+	logger logger.LogContext
+	scheme string
+	client *http.Client
+	config *TransportConfig
+	srv    string
+	once   sync.Once
+
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+
+	list []*endpoint
+	next int
+}
+
+// NewEndpoints creates an endpoint set for a list of host:port
+// entries or, if given, the targets of the SRV records of a DNS name.
+func NewEndpoints(logger logger.LogContext, hosts []string, srv string, access *AccessConfig, config *TransportConfig) (*Endpoints, error) {
+	client, err := access.Client()
+	if err != nil {
+		return nil, err
+	}
+	this := &Endpoints{
+		logger:    logger,
+		scheme:    access.Scheme(),
+		client:    client,
+		config:    config,
+		srv:       srv,
+		lookupSRV: net.LookupSRV,
+	}
+	if srv != "" {
+		if err := this.Resolve(); err != nil {
+			logger.Warnf("%s", err)
+		}
+		return this, nil
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no index server endpoint configured")
+	}
+	this.set(hosts)
+	return this, nil
+}
+
+// Hosts returns the host:port entries of the current endpoints.
+func (this *Endpoints) Hosts() []string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	hosts := make([]string, len(this.list))
+	for i, e := range this.list {
+		hosts[i] = e.host
+	}
+	return hosts
+}
+
+// Resolve updates the endpoints from the SRV records. The state
+// of endpoints still found is kept.
+func (this *Endpoints) Resolve() error {
+	if this.srv == "" {
+		return nil
+	}
+	_, records, err := this.lookupSRV("", "", this.srv)
+	if err != nil {
+		return fmt.Errorf("cannot resolve index server endpoints %q: %s", this.srv, err)
+	}
+	var hosts []string
+	for _, r := range records {
+		hosts = append(hosts, net.JoinHostPort(strings.TrimSuffix(r.Target, "."), fmt.Sprintf("%d", r.Port)))
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("no index server endpoints found for %q", this.srv)
+	}
+	this.set(hosts)
+	return nil
+}
+
+func (this *Endpoints) set(hosts []string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	old := map[string]*endpoint{}
+	for _, e := range this.list {
+		old[e.host] = e
+	}
+	list := make([]*endpoint, 0, len(hosts))
+	for _, h := range hosts {
+		e := old[h]
+		if e == nil {
+			e = &endpoint{host: h, breaker: this.config.breaker(), healthy: true}
+			this.logger.Infof("using index server %s", h)
+		}
+		list = append(list, e)
+	}
+	this.list = list
+	if this.next >= len(list) {
+		this.next = 0
+	}
+}
+
+// pick selects the next endpoint not yet tried for a request, healthy
+// endpoints are preferred. If no endpoint is allowed by its circuit
+// breaker, the shortest remaining time the breakers stay open is returned.
+func (this *Endpoints) pick(tried map[*endpoint]bool) (*endpoint, time.Duration) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var wait time.Duration
+	n := len(this.list)
+	for _, healthy := range []bool{true, false} {
+		for i := 0; i < n; i++ {
+			e := this.list[(this.next+i)%n]
+			if tried[e] || e.healthy != healthy {
+				continue
+			}
+			ok, w := e.breaker.allow()
+			if ok {
+				this.next = (this.next + i + 1) % n
+				return e, 0
+			}
+			if wait == 0 || w < wait {
+				wait = w
+			}
+		}
+	}
+	return nil, wait
+}
+
+// watchEndpoint selects an endpoint for a long running watch request.
+// The circuit breakers are only checked but not involved.
+func (this *Endpoints) watchEndpoint(prefer string) *endpoint {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var found *endpoint
+	for _, e := range this.list {
+		if !e.healthy || e.breaker.isOpen() {
+			continue
+		}
+		if e.host == prefer {
+			return e
+		}
+		if found == nil {
+			found = e
+		}
+	}
+	return found
+}
+
+func (this *Endpoints) succeeded(e *endpoint) {
+	e.breaker.success()
+	this.lock.Lock()
+	e.healthy = true
+	this.lock.Unlock()
+}
+
+// failed records a failed request and reports whether the circuit
+// breaker of the endpoint has been opened by it.
+func (this *Endpoints) failed(e *endpoint) bool {
+	this.lock.Lock()
+	e.healthy = false
+	this.lock.Unlock()
+	return e.breaker.failure()
+}
+
+// isOpen reports whether the circuit breakers of all endpoints are open.
+func (this *Endpoints) isOpen() bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, e := range this.list {
+		if !e.breaker.isOpen() {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *Endpoints) url(e *endpoint, path string, query url.Values) string {
+	u := url.URL{
+		Scheme:   this.scheme,
+		Host:     e.host,
+		Path:     "/" + path,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// Run periodically resolves the endpoints and checks their health
+// until the context is done. It is started only once for
+// shared endpoints.
+func (this *Endpoints) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	this.once.Do(func() {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+				if err := this.Resolve(); err != nil {
+					this.logger.Warnf("%s", err)
+				}
+				this.Check(ctx)
+			}
+		}()
+	})
+}
+
+// Check probes the readiness endpoints of all index servers.
+func (this *Endpoints) Check(ctx context.Context) {
+	this.lock.Lock()
+	list := append([]*endpoint{}, this.list...)
+	this.lock.Unlock()
+
+	for _, e := range list {
+		healthy := this.probe(ctx, e)
+		this.lock.Lock()
+		if e.healthy != healthy {
+			this.logger.Infof("index server %s healthy: %t", e.host, healthy)
+		}
+		e.healthy = healthy
+		this.lock.Unlock()
+		if healthy {
+			// let recovered servers be used again without waiting for the breaker
+			e.breaker.success()
+		}
+	}
+}
+
+func (this *Endpoints) probe(ctx context.Context, e *endpoint) bool {
+	if timeout := this.config.timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, this.url(e, PATH_READY, nil), nil)
+	if err != nil {
+		return false
+	}
+	r, err := this.client.Do(req)
+	if err != nil {
+		return false
+	}
+	r.Body.Close()
+	return r.StatusCode == http.StatusOK
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newTestClient creates a machine info client for the given test servers.
+func newTestClient(max int, ttl time.Duration, transport *TransportConfig, servers ...*httptest.Server) (*IndexServerClient, error) {
+	var hosts []string
+	for _, s := range servers {
+		u, _ := url.Parse(s.URL)
+		hosts = append(hosts, u.Host)
+	}
+	endpoints, err := NewEndpoints(logger.New(), hosts, "", nil, transport)
+	if err != nil {
+		return nil, err
+	}
+	return NewIndexServerClient(logger.New(), endpoints, PATH_MACHINEINFO, max, ttl, nil, transport)
+}
+
+type testServer struct {
+	*httptest.Server
+	name     string
+	status   int
+	ready    int
+	requests int
+}
+
+func newTestServer(name string) *testServer {
+	this := &testServer{name: name, status: http.StatusOK, ready: http.StatusOK}
+	this.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+PATH_READY {
+			w.WriteHeader(this.ready)
+			return
+		}
+		this.requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(this.status)
+		if this.status == http.StatusOK {
+			fmt.Fprintf(w, `{"name":"%s","namespace":"default"}`, this.name)
+		}
+	}))
+	return this
+}
+
+type testMachineIndex struct {
+	machine *Machine
+}
+
+func (this *testMachineIndex) IsInitialized() bool { return true }
+func (this *testMachineIndex) GetByMAC(mac string) *Machine {
+	return this.machine
+}
+func (this *testMachineIndex) GetByUUID(uuid string) *Machine {
+	return nil
+}
+func (this *testMachineIndex) GetByName(name resources.ObjectName) *Machine {
+	return nil
+}
+
+var _ = Describe("index server endpoints", func() {
+	var s1, s2 *testServer
+	var client *IndexServerClient
+
+	BeforeEach(func() {
+		s1 = newTestServer("m1")
+		s2 = newTestServer("m2")
+		var err error
+		client, err = newTestClient(0, 0, &TransportConfig{
+			Retries:          1,
+			FailureThreshold: 2,
+			OpenTimeout:      time.Hour,
+		}, s1.Server, s2.Server)
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		s1.Close()
+		s2.Close()
+	})
+
+	It("distributes requests round robin", func() {
+		for i := 0; i < 4; i++ {
+			_, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(err).To(Succeed())
+		}
+		Expect(s1.requests).To(Equal(2))
+		Expect(s2.requests).To(Equal(2))
+	})
+
+	It("fails over to next endpoint", func() {
+		s1.status = http.StatusBadGateway
+		for i := 0; i < 3; i++ {
+			n, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(err).To(Succeed())
+			Expect(n.Name()).To(Equal("m2"))
+		}
+		// the failed endpoint is not preferred anymore
+		Expect(s1.requests).To(Equal(1))
+		Expect(s2.requests).To(Equal(3))
+	})
+
+	It("reports unavailable servers", func() {
+		s1.status = http.StatusBadGateway
+		s2.status = http.StatusBadGateway
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		_, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(IsUnavailable(err)).To(BeTrue())
+		Expect(s1.requests + s2.requests).To(Equal(4))
+	})
+
+	It("uses fallback index while servers are unavailable", func() {
+		s1.status = http.StatusBadGateway
+		s2.status = http.StatusBadGateway
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+
+		index := &MachineIndexServerIndex{indexServerIndex: &indexServerIndex{access: client}}
+		m := &Machine{Name: resources.NewObjectName("default", "local")}
+		index.SetFallback(&testMachineIndex{machine: m})
+		Expect(index.GetByMAC("00:00:00:00:00:01")).To(BeIdenticalTo(m))
+	})
+
+	It("checks health of servers", func() {
+		s1.ready = http.StatusServiceUnavailable
+		client.endpoints.Check(context.Background())
+		for i := 0; i < 2; i++ {
+			n, _ := client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(n.Name()).To(Equal("m2"))
+		}
+		Expect(s1.requests).To(Equal(0))
+
+		s1.ready = http.StatusOK
+		client.endpoints.Check(context.Background())
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(s1.requests).To(Equal(1))
+	})
+
+	It("discovers endpoints by SRV records", func() {
+		u1, _ := url.Parse(s1.URL)
+		host, port, _ := net.SplitHostPort(u1.Host)
+		e := &Endpoints{logger: logger.New(), scheme: "http", srv: "_index._tcp.test"}
+		e.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+			Expect(name).To(Equal("_index._tcp.test"))
+			var p uint16
+			fmt.Sscanf(port, "%d", &p)
+			return "", []*net.SRV{{Target: host + ".", Port: p}}, nil
+		}
+		Expect(e.Resolve()).To(Succeed())
+		Expect(e.Hosts()).To(Equal([]string{u1.Host}))
+	})
+})
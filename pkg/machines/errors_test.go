@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("index server client errors", func() {
+	var server *httptest.Server
+	var client *IndexServerClient
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Query().Get("mac") {
+			case "00:00:00:00:00:01":
+				w.Write([]byte(`{"name":"m1","namespace":"default"}`))
+			case "00:00:00:00:00:02":
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"error":"keys match different objects","reason":"Ambiguous","conflicts":[` +
+					`{"kind":"mac","key":"00:00:00:00:00:02","name":"m1","namespace":"default"},` +
+					`{"kind":"uuid","key":"u2","name":"m2","namespace":"default"}]}`))
+			case "00:00:00:00:00:03":
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"error":"index info is syncing","reason":"NotInitialized"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		var err error
+		client, err = newTestClient(10, 0, nil, server)
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("finds object", func() {
+		n, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(err).To(Succeed())
+		Expect(n.Name()).To(Equal("m1"))
+	})
+
+	It("reports ambiguous keys", func() {
+		_, err := client.get(context.Background(), "00:00:00:00:00:02", "u2")
+		Expect(IsAmbiguous(err)).To(BeTrue())
+		Expect(err.(*IndexError).Conflicts).To(HaveLen(2))
+		Expect(err.Error()).To(ContainSubstring("uuid u2 -> default/m2"))
+	})
+
+	It("reports syncing index", func() {
+		_, err := client.get(context.Background(), "00:00:00:00:00:03", "")
+		Expect(IsNotInitialized(err)).To(BeTrue())
+		Expect(err.(*IndexError).RetryAfter).To(Equal(5 * time.Second))
+	})
+
+	It("reports missing object without body", func() {
+		_, err := client.get(context.Background(), "00:00:00:00:00:04", "")
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"sync"
+)
+
+// FEED_SIZE is the minimum number of changes kept for resuming watches.
+const FEED_SIZE = 10000
+
+// ChangeFeed keeps the last changes of a set of indices for watches.
+// Sequence numbers are assigned in the order the changes are reported.
+type ChangeFeed struct {
+	lock     sync.Mutex
+	sequence int64
+	events   []*IndexEvent
+	changed  chan struct{}
+	attached map[string]IndexNotifier
+}
+
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{
+		changed:  make(chan struct{}),
+		attached: map[string]IndexNotifier{},
+	}
+}
+
+// Attach registers the feed as listener for the given index.
+// Attaching the same notifier for an index multiple times is ignored.
+func (this *ChangeFeed) Attach(index string, n IndexNotifier) {
+	this.lock.Lock()
+	if this.attached[index] == n {
+		this.lock.Unlock()
+		return
+	}
+	this.attached[index] = n
+	this.lock.Unlock()
+
+	n.AddListener(func(c *IndexChange) {
+		this.add(index, c)
+	})
+}
+
+func (this *ChangeFeed) add(index string, c *IndexChange) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.sequence++
+	e := &IndexEvent{
+		Sequence:        this.sequence,
+		Index:           index,
+		Type:            c.Type,
+		Cluster:         ClusterOf(c.Name),
+		Name:            c.Name.Name(),
+		Namespace:       c.Name.Namespace(),
+		ResourceVersion: c.ResourceVersion,
+		MACs:            c.MACs,
+		UUIDs:           c.UUIDs,
+		Prefixes:        c.Prefixes,
+	}
+	if len(this.events) >= 2*FEED_SIZE {
+		this.events = append(this.events[:0], this.events[FEED_SIZE:]...)
+	}
+	this.events = append(this.events, e)
+	close(this.changed)
+	this.changed = make(chan struct{})
+}
+
+// Since returns the events following the given sequence number and a
+// channel closed on the next change. If the requested events are not
+// available anymore, a reset event is returned first.
+func (this *ChangeFeed) Since(seq int64) ([]*IndexEvent, <-chan struct{}) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var result []*IndexEvent
+	first := this.sequence - int64(len(this.events)) + 1
+	if seq > this.sequence || seq < first-1 {
+		result = append(result, &IndexEvent{Sequence: first - 1, Type: EVENT_RESET})
+		seq = first - 1
+	}
+	result = append(result, this.events[seq-first+1:]...)
+	return result, this.changed
+}
+
+// Current returns the sequence number of the latest change.
+func (this *ChangeFeed) Current() int64 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.sequence
+}
@@ -23,18 +23,19 @@
 package machines
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
-	"github.com/gardener/controller-manager-library/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
 
 	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
 )
@@ -42,141 +43,389 @@ import (
 const PATH_MACHINETYPE = "type"
 const PATH_MACHINEINFO = "info"
 const PATH_BMCINFO = "bmc"
-
-type entry struct {
-	name  resources.ObjectName
-	uuids utils.StringSet
-	macs  utils.StringSet
-	last  time.Time
+const PATH_MACHINE = "machine"
+const PATH_QUERY = "query"
+const PATH_BATCH = "batch"
+const PATH_WATCH = "watch"
+
+// ServerPaths returns the paths served by the index server
+// besides the registered secondary indices.
+func ServerPaths() []string {
+	return []string{
+		PATH_MACHINETYPE, PATH_MACHINEINFO, PATH_BMCINFO, PATH_MACHINE,
+		PATH_QUERY, PATH_BATCH, PATH_WATCH, PATH_READY,
+	}
 }
 
 type IndexServerClient struct {
-	logger   logger.LogContext
-	lock     sync.RWMutex
-	url      *url.URL
-	maxcache int
-
-	list  []entry
-	uuids map[string]*entry
-	macs  map[string]*entry
+	logger    logger.LogContext
+	lock      sync.Mutex
+	path      string
+	client    *http.Client
+	endpoints *Endpoints
+	transport *transport
+
+	cache *keyCache
+	// generation is increased by every invalidation to avoid
+	// caching query results that are outdated on arrival
+	generation int64
 }
 
-func NewIndexServerClient(logger logger.LogContext, url *url.URL, max int) *IndexServerClient {
-	return &IndexServerClient{
-		logger:   logger,
-		url:      url,
-		maxcache: max,
-		uuids:    map[string]*entry{},
-		macs:     map[string]*entry{},
+// NewIndexServerClient creates a client for an index path of the given
+// index servers caching up to max lookup results (0=no cache) for at
+// most ttl (0=no expiry).
+func NewIndexServerClient(logger logger.LogContext, endpoints *Endpoints, path string, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (*IndexServerClient, error) {
+	client, err := access.Client()
+	if err != nil {
+		return nil, err
+	}
+	this := &IndexServerClient{
+		logger:    logger,
+		path:      path,
+		client:    client,
+		endpoints: endpoints,
+		cache:     newKeyCache(max, ttl),
 	}
+	this.transport = newTransport(client, transport, endpoints, this.failed)
+	return this, nil
 }
 
-func (this *IndexServerClient) _add(name resources.ObjectName) *entry {
-	var found *entry
-	var oldest int
-	t := time.Now()
-	for i, e := range this.list {
-		if e.name.Namespace() == name.Namespace() && e.name.Name() == name.Name() {
-			found = &e
-		}
-		if !t.After(e.last) {
-			oldest = i
-		}
+// failed is called by the transport for every failed request attempt.
+func (this *IndexServerClient) failed(host string, err error, opened bool) {
+	clientErrors.WithLabelValues(this.indexName()).Inc()
+	this.logger.Warnf("request to index server %s failed: %s", host, err)
+	if opened {
+		clientBreakerOpened.WithLabelValues(this.indexName()).Inc()
+		this.logger.Warnf("index server %s unavailable -> circuit breaker opened", host)
 	}
-	if found == nil {
-		this.list = append(this.list, entry{name: name, macs: utils.StringSet{}, uuids: utils.StringSet{}})
-		found = &this.list[len(this.list)-1]
-	}
-	if len(this.list) > this.maxcache {
-		d := &this.list[oldest]
-		for m := range d.macs {
-			delete(this.macs, m)
-		}
-		for u := range d.uuids {
-			delete(this.uuids, u)
-		}
-		this.list = append(this.list[:oldest], this.list[oldest+1:]...)
-	}
-	return found
 }
 
-func (this *IndexServerClient) addMAC(mac string, name resources.ObjectName) {
-	found := this._add(name)
-	found.macs.Add(mac)
+func (this *IndexServerClient) indexName() string {
+	return this.path
+}
 
-	if this.maxcache > 0 {
-		this.list = append(this.list, *found)
-		found = &this.list[len(this.list)-1]
+// cached looks up a key in the cache and maintains the cache metrics.
+func (this *IndexServerClient) cached(kind, key string) resources.ObjectName {
+	name, expired := this.cache.get(kind, key)
+	if expired {
+		clientCache.WithLabelValues(this.indexName(), CACHE_EXPIRED).Inc()
 	}
-	this.macs[mac] = found
+	return name
 }
 
-func (this *IndexServerClient) addUUID(uuid string, name resources.ObjectName) {
-	found := this._add(name)
-	found.uuids.Add(uuid)
-
-	if this.maxcache > 0 {
-		this.list = append(this.list, *found)
-		found = &this.list[len(this.list)-1]
+func (this *IndexServerClient) put(kind, key string, name resources.ObjectName) {
+	if n := this.cache.put(kind, key, name); n > 0 {
+		clientEvictions.WithLabelValues(this.indexName()).Add(float64(n))
 	}
-	this.uuids[uuid] = found
 }
 
-func (this *IndexServerClient) get(mac string, uuid string) (resources.ObjectName, error) {
-	var found *entry
-
-	this.lock.RLock()
+func (this *IndexServerClient) get(ctx context.Context, mac string, uuid string) (resources.ObjectName, error) {
+	var found resources.ObjectName
 
+	this.lock.Lock()
 	if mac != "" {
-		found = this.macs[mac]
+		found = this.cached(KEY_MAC, mac)
 	}
 	if found == nil && uuid != "" {
-		found = this.uuids[uuid]
+		found = this.cached(KEY_UUID, uuid)
 	}
-	this.lock.RUnlock()
+	generation := this.generation
+	this.lock.Unlock()
 	if found != nil {
-		return found.name, nil
+		clientCache.WithLabelValues(this.indexName(), CACHE_HIT).Inc()
+		return found, nil
 	}
+	clientCache.WithLabelValues(this.indexName(), CACHE_MISS).Inc()
 
-	url := *this.url
-
-	q := url.Query()
+	q := url.Values{}
 	if mac != "" {
-		q.Set("mac", mac)
+		q.Set(KEY_MAC, mac)
 	}
 	if uuid != "" {
-		q.Set("uuid", uuid)
+		q.Set(KEY_UUID, uuid)
 	}
-	url.RawQuery = q.Encode()
-
-	this.logger.Infof("querying %s", url.String())
-	r, err := http.Get(url.String())
+	name, err := this.query(ctx, q)
 	if err != nil {
-		this.logger.Errorf("querying %s failed: %s", url.String(), err)
 		return nil, err
 	}
 
-	data, err := ioutil.ReadAll(r.Body)
-	r.Body.Close()
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if generation != this.generation {
+		// the index changed while querying, the result might already be outdated
+		return name, nil
+	}
+	if mac != "" {
+		this.put(KEY_MAC, mac, name)
+	}
+	if uuid != "" {
+		this.put(KEY_UUID, uuid, name)
+	}
+	return name, nil
+}
+
+// lookup queries the index server for an additional key kind.
+// The result is not cached, because index change events
+// only report MAC addresses and UUIDs.
+func (this *IndexServerClient) lookup(ctx context.Context, kind, key string) (resources.ObjectName, error) {
+	q := url.Values{}
+	q.Set(kind, key)
+	return this.query(ctx, q)
+}
+
+// query sends a lookup request with the given query parameters
+// to the index server.
+func (this *IndexServerClient) query(ctx context.Context, q url.Values) (resources.ObjectName, error) {
+	this.logger.Infof("querying %s?%s", this.path, q.Encode())
+	r, data, err := this.transport.do(ctx, http.MethodGet, this.path, q, nil)
 	if err != nil {
+		this.logger.Errorf("querying %s?%s failed: %s", this.path, q.Encode(), err)
 		return nil, err
 	}
+	if r.StatusCode != http.StatusOK {
+		if r.StatusCode != http.StatusNotFound && !retriable(r, data) {
+			// failed attempts are already counted by the transport
+			clientErrors.WithLabelValues(this.indexName()).Inc()
+		}
+		return nil, NewIndexError(r, errorResponse(data))
+	}
 	resp := &IndexResponse{}
 	err = json.Unmarshal(data, &resp)
 	if err != nil {
-		return nil, err
+		clientErrors.WithLabelValues(this.indexName()).Inc()
+		return nil, fmt.Errorf("invalid index server response: %s", err)
+	}
+	if resp.Name == "" {
+		clientErrors.WithLabelValues(this.indexName()).Inc()
+		return nil, fmt.Errorf("invalid index server response: object name missing")
+	}
+	return NewClusterObjectName(resp.Cluster, resp.Namespace, resp.Name), nil
+}
+
+// errorResponse decodes the body of an error response, if possible.
+func errorResponse(data []byte) *IndexResponse {
+	resp := &IndexResponse{}
+	if len(data) == 0 || json.Unmarshal(data, resp) != nil {
+		return nil
+	}
+	return resp
+}
+
+// Warmup resolves the given keys with batch requests for at most
+// MAX_BATCH_KEYS keys and adds the found entries to the cache.
+func (this *IndexServerClient) Warmup(ctx context.Context, macs []string, uuids []string) error {
+	if this.cache.max <= 0 || (len(macs) == 0 && len(uuids) == 0) {
+		return nil
+	}
+	// cache entries are always keyed by the canonical mac address
+	keys := make([]string, 0, len(macs))
+	for _, mac := range macs {
+		if mac = canonicalMAC(mac); mac != "" {
+			keys = append(keys, mac)
+		}
+	}
+	macs = keys
+	for len(macs) > 0 || len(uuids) > 0 {
+		m := len(macs)
+		if m > MAX_BATCH_KEYS {
+			m = MAX_BATCH_KEYS
+		}
+		u := len(uuids)
+		if u > MAX_BATCH_KEYS-m {
+			u = MAX_BATCH_KEYS - m
+		}
+		if err := this.warmup(ctx, macs[:m], uuids[:u]); err != nil {
+			return err
+		}
+		macs, uuids = macs[m:], uuids[u:]
+	}
+	return nil
+}
+
+func (this *IndexServerClient) warmup(ctx context.Context, macs []string, uuids []string) error {
+	index := this.path
+	this.lock.Lock()
+	generation := this.generation
+	this.lock.Unlock()
+	data, err := json.Marshal(BatchRequest{index: &BatchKeys{MACs: macs, UUIDs: uuids}})
+	if err != nil {
+		return err
+	}
+
+	this.logger.Infof("batch query for %d macs and %d uuids", len(macs), len(uuids))
+	r, data, err := this.transport.do(ctx, http.MethodPost, PATH_BATCH, nil, data)
+	if err != nil {
+		this.logger.Errorf("batch query failed: %s", err)
+		return err
+	}
+	if r.StatusCode != http.StatusOK {
+		if !retriable(r, data) {
+			clientErrors.WithLabelValues(this.indexName()).Inc()
+		}
+		return NewIndexError(r, errorResponse(data))
+	}
+	resp := BatchResponse{}
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return err
+	}
+	result := resp[index]
+	if result == nil {
+		return nil
 	}
-	name := resources.NewObjectName(resp.Namespace, resp.Name)
 
 	this.lock.Lock()
 	defer this.lock.Unlock()
-	if mac != "" {
-		this.addMAC(mac, name)
+	if generation != this.generation {
+		return nil
 	}
-	if uuid != "" {
-		this.addMAC(uuid, name)
+	for mac, e := range result.MACs {
+		if e.Status == STATUS_FOUND {
+			this.put(KEY_MAC, mac, NewClusterObjectName(e.Cluster, e.Namespace, e.Name))
+		}
+	}
+	for uuid, e := range result.UUIDs {
+		if e.Status == STATUS_FOUND {
+			this.put(KEY_UUID, uuid, NewClusterObjectName(e.Cluster, e.Namespace, e.Name))
+		}
+	}
+	return nil
+}
+
+// Watch starts watching the change feed of the index server
+// and invalidates affected cache entries until the context is done.
+func (this *IndexServerClient) Watch(ctx context.Context) {
+	go this.watch(ctx)
+}
+
+func (this *IndexServerClient) watch(ctx context.Context) {
+	since := int64(-1)
+	host := ""
+	for {
+		err := this.watchFeed(ctx, &host, &since)
+		if ctx.Err() != nil {
+			return
+		}
+		clientErrors.WithLabelValues(this.indexName()).Inc()
+		this.logger.Warnf("watching index server failed: %s", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
 	}
-	return name, nil
+}
+
+// watchFeed watches the change feed of a single index server. The
+// sequence numbers are only valid for the server used before.
+func (this *IndexServerClient) watchFeed(ctx context.Context, host *string, since *int64) error {
+	e := this.endpoints.watchEndpoint(*host)
+	if e == nil {
+		return fmt.Errorf("no index server available")
+	}
+	if e.host != *host {
+		*host = e.host
+		*since = -1
+	}
+	q := url.Values{}
+	q.Set(QUERY_INDEX, this.path)
+	if *since >= 0 {
+		q.Set(QUERY_SINCE, strconv.FormatInt(*since, 10))
+	}
+	url := this.endpoints.url(e, PATH_WATCH, q)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	this.logger.Infof("watching %s", url)
+	r, err := this.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("watch %s failed: %s", url, r.Status)
+	}
+	if *since < 0 {
+		// changes before the watch has been established may be missed
+		this.refresh(ctx)
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	for {
+		e := &IndexEvent{}
+		err := decoder.Decode(e)
+		if err != nil {
+			return err
+		}
+		*since = e.Sequence
+		this.handleEvent(ctx, e)
+	}
+}
+
+func (this *IndexServerClient) handleEvent(ctx context.Context, e *IndexEvent) {
+	if e.Type == EVENT_RESET {
+		this.logger.Infof("index feed reset -> refresh cache")
+		this.refresh(ctx)
+		return
+	}
+	name := NewClusterObjectName(e.Cluster, e.Namespace, e.Name)
+	this.logger.Infof("%s %s -> invalidate cache", e.Type, name)
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.generation++
+	this.cache.removeName(name)
+	for _, mac := range e.MACs {
+		this.cache.remove(KEY_MAC, mac)
+	}
+	for _, uuid := range e.UUIDs {
+		this.cache.remove(KEY_UUID, uuid)
+	}
+	// MAC addresses covered by a changed prefix may now match another type
+	for _, p := range e.Prefixes {
+		if prefix, err := ParseMACPrefix(p); err == nil {
+			this.cache.removeKeys(KEY_MAC, func(key string) bool {
+				mac, err := ParseMAC(key)
+				return err == nil && prefix.Contains(mac)
+			})
+		}
+	}
+}
+
+// Invalidate removes the cache entries for a key, which may be
+// a MAC address or a UUID.
+func (this *IndexServerClient) Invalidate(key string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.generation++
+	if mac := canonicalMAC(key); mac != "" {
+		this.cache.remove(KEY_MAC, mac)
+	}
+	this.cache.remove(KEY_UUID, key)
+}
+
+// refresh replaces all cache entries by the current results of
+// the index server for their keys, fetched with batch requests.
+func (this *IndexServerClient) refresh(ctx context.Context) {
+	this.lock.Lock()
+	macs, uuids := this.cache.keys(KEY_MAC), this.cache.keys(KEY_UUID)
+	this.generation++
+	this.cache.flush()
+	this.lock.Unlock()
+
+	if err := this.Warmup(ctx, macs, uuids); err != nil {
+		this.logger.Warnf("refreshing cache failed: %s", err)
+	}
+}
+
+// Flush removes all cache entries.
+func (this *IndexServerClient) Flush() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.generation++
+	this.cache.flush()
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -186,123 +435,316 @@ type indexServerIndex struct {
 	resource resources.Interface
 }
 
-func NewIndexServerIndex(logger logger.LogContext, url *url.URL, res resources.Interface, max int) *indexServerIndex {
+var _ IndexWarmer = &indexServerIndex{}
+var _ IndexWatcher = &indexServerIndex{}
+var _ IndexCache = &indexServerIndex{}
+
+func NewIndexServerIndex(logger logger.LogContext, endpoints *Endpoints, path string, res resources.Interface, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (*indexServerIndex, error) {
+	client, err := NewIndexServerClient(logger, endpoints, path, max, ttl, access, transport)
+	if err != nil {
+		return nil, err
+	}
 	return &indexServerIndex{
-		access:   NewIndexServerClient(logger, url, max),
+		access:   client,
 		resource: res,
-	}
+	}, nil
 }
 
 func (this *indexServerIndex) IsInitialized() bool {
 	return true
 }
 
+func (this *indexServerIndex) Warmup(ctx context.Context, macs []string, uuids []string) error {
+	return this.access.Warmup(ctx, macs, uuids)
+}
+
+func (this *indexServerIndex) Watch(ctx context.Context) {
+	this.access.Watch(ctx)
+}
+
+// get looks up the name of the object for a key. Only MAC addresses
+// and UUIDs are cached. A missing object is not reported as error.
+func (this *indexServerIndex) get(ctx context.Context, kind, key string) (resources.ObjectName, error) {
+	var n resources.ObjectName
+	var err error
+	switch kind {
+	case KEY_MAC:
+		if key = canonicalMAC(key); key == "" {
+			return nil, nil
+		}
+		n, err = this.access.get(ctx, key, "")
+	case KEY_UUID:
+		if key == "" {
+			return nil, nil
+		}
+		n, err = this.access.get(ctx, "", key)
+	default:
+		if kind == KEY_IP {
+			key = canonicalIP(key)
+		}
+		if key == "" {
+			return nil, nil
+		}
+		n, err = this.access.lookup(ctx, kind, key)
+	}
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return n, err
+}
+
+// object reads an object found by the index server from the local cluster.
+// A missing object is not reported as error.
+func (this *indexServerIndex) object(name resources.ObjectName) (resources.Object, error) {
+	if ClusterOf(name) != "" {
+		// objects of additional clusters cannot be read from the local cluster
+		return nil, nil
+	}
+	o, err := this.resource.Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+func (this *indexServerIndex) Invalidate(key string) {
+	this.access.Invalidate(key)
+}
+
+func (this *indexServerIndex) Flush() {
+	this.access.Flush()
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type MachineIndexServerIndex struct {
 	*indexServerIndex
+	fallback MachineIndex
 }
 
 var _ MachineIndex = &MachineIndexServerIndex{}
 
-func NewMachineIndexServerIndex(logger logger.LogContext, cluster cluster.Interface, host string, port int, max int) (MachineIndex, error) {
-	f, err := NewMachineIndexServerIndexCreator(logger, cluster, host, port, max)
+func NewMachineIndexServerIndex(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (MachineIndex, error) {
+	f, err := NewMachineIndexServerIndexCreator(logger, cluster, endpoints, max, ttl, access, transport)
 	if err != nil {
 		return nil, err
 	}
 	return f(), nil
 }
 
-func NewMachineIndexServerIndexCreator(logger logger.LogContext, cluster cluster.Interface, host string, port int, max int) (func() MachineIndex, error) {
-	var url url.URL
-	url.Scheme = "http"
-	url.Host = fmt.Sprintf("%s:%d", host, port)
-	url.Path = "/" + PATH_MACHINEINFO
-
+func NewMachineIndexServerIndexCreator(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (func() MachineIndex, error) {
 	res, err := cluster.Resources().Get(api.MACHINEINFO)
 	if err != nil {
 		return nil, err
 	}
+	index, err := NewIndexServerIndex(logger, endpoints, PATH_MACHINEINFO, res, max, ttl, access, transport)
+	if err != nil {
+		return nil, err
+	}
 	return func() MachineIndex {
-		return &MachineIndexServerIndex{
-			NewIndexServerIndex(logger, &url, res, max),
-		}
+		return &MachineIndexServerIndex{indexServerIndex: index}
 	}, nil
 }
 
+var _ MachineContextIndex = &MachineIndexServerIndex{}
+
 func (this *MachineIndexServerIndex) GetByUUID(uuid string) *Machine {
-	n, _ := this.access.get("", uuid)
-	if n == nil {
-		return nil
-	}
-	return this.GetByName(n)
+	m, _ := this.LookupMachine(context.Background(), KEY_UUID, uuid)
+	return m
 }
 
 func (this *MachineIndexServerIndex) GetByMAC(mac string) *Machine {
-	n, _ := this.access.get(mac, "")
+	m, _ := this.LookupMachine(context.Background(), KEY_MAC, mac)
+	return m
+}
+
+// SetFallback sets an index used while no index server is available.
+// It must be set before the index is used.
+func (this *MachineIndexServerIndex) SetFallback(index MachineIndex) {
+	this.fallback = index
+}
+
+func (this *MachineIndexServerIndex) LookupMachine(ctx context.Context, kind, key string) (*Machine, error) {
+	n, err := this.get(ctx, kind, key)
+	if IsUnavailable(err) && this.fallback != nil && this.fallback.IsInitialized() {
+		clientFallbacks.WithLabelValues(this.access.indexName()).Inc()
+		return LookupMachine(ctx, this.fallback, kind, key)
+	}
 	if n == nil {
-		return nil
+		return nil, err
 	}
-	return this.GetByName(n)
+	return this.getByName(n)
 }
 
 func (this *MachineIndexServerIndex) GetByName(name resources.ObjectName) *Machine {
-	o, _ := this.resource.Get(name)
-	m, _ := NewMachine(o.Data().(*api.MachineInfo))
+	m, _ := this.getByName(name)
 	return m
 }
 
+func (this *MachineIndexServerIndex) getByName(name resources.ObjectName) (*Machine, error) {
+	o, err := this.object(name)
+	if o == nil {
+		return nil, err
+	}
+	return NewMachine(o.Data().(*api.MachineInfo))
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type BMCIndexServerIndex struct {
 	*indexServerIndex
+	fallback BMCIndex
 }
 
 var _ BMCIndex = &BMCIndexServerIndex{}
 
-func NewBMCIndexServerIndex(logger logger.LogContext, cluster cluster.Interface, host string, port int, max int) (BMCIndex, error) {
-	f, err := NewBMCIndexServerIndexCreator(logger, cluster, host, port, max)
+func NewBMCIndexServerIndex(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (BMCIndex, error) {
+	f, err := NewBMCIndexServerIndexCreator(logger, cluster, endpoints, max, ttl, access, transport)
 	if err != nil {
 		return nil, err
 	}
 	return f(), nil
 }
 
-func NewBMCIndexServerIndexCreator(logger logger.LogContext, cluster cluster.Interface, host string, port int, max int) (func() BMCIndex, error) {
-	var url url.URL
-	url.Scheme = "http"
-	url.Host = fmt.Sprintf("%s:%d", host, port)
-	url.Path = "/" + PATH_BMCINFO
-
+func NewBMCIndexServerIndexCreator(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (func() BMCIndex, error) {
 	res, err := cluster.Resources().Get(api.BASEBOARDMANAGEMENTCONTROLLERINFO)
 	if err != nil {
 		return nil, err
 	}
+	index, err := NewIndexServerIndex(logger, endpoints, PATH_BMCINFO, res, max, ttl, access, transport)
+	if err != nil {
+		return nil, err
+	}
 	return func() BMCIndex {
-		return &BMCIndexServerIndex{
-			NewIndexServerIndex(logger, &url, res, max),
-		}
+		return &BMCIndexServerIndex{indexServerIndex: index}
 	}, nil
 }
 
+var _ BMCContextIndex = &BMCIndexServerIndex{}
+
 func (this *BMCIndexServerIndex) GetByUUID(uuid string) *BaseBoardManagementController {
-	n, _ := this.access.get("", uuid)
-	if n == nil {
-		return nil
-	}
-	return this.GetByName(n)
+	return this.getBy(KEY_UUID, uuid)
 }
 
 func (this *BMCIndexServerIndex) GetByMAC(mac string) *BaseBoardManagementController {
-	n, _ := this.access.get(mac, "uuid")
+	return this.getBy(KEY_MAC, mac)
+}
+
+func (this *BMCIndexServerIndex) GetByIP(ip string) *BaseBoardManagementController {
+	return this.getBy(KEY_IP, ip)
+}
+
+func (this *BMCIndexServerIndex) GetBySerial(serial string) *BaseBoardManagementController {
+	return this.getBy(KEY_SERIAL, serial)
+}
+
+func (this *BMCIndexServerIndex) GetByAssetTag(tag string) *BaseBoardManagementController {
+	return this.getBy(KEY_ASSETTAG, tag)
+}
+
+func (this *BMCIndexServerIndex) getBy(kind, key string) *BaseBoardManagementController {
+	m, _ := this.LookupBMC(context.Background(), kind, key)
+	return m
+}
+
+// SetFallback sets an index used while no index server is available.
+// It must be set before the index is used.
+func (this *BMCIndexServerIndex) SetFallback(index BMCIndex) {
+	this.fallback = index
+}
+
+func (this *BMCIndexServerIndex) LookupBMC(ctx context.Context, kind, key string) (*BaseBoardManagementController, error) {
+	n, err := this.get(ctx, kind, key)
+	if IsUnavailable(err) && this.fallback != nil && this.fallback.IsInitialized() {
+		clientFallbacks.WithLabelValues(this.access.indexName()).Inc()
+		return LookupBMC(ctx, this.fallback, kind, key)
+	}
 	if n == nil {
-		return nil
+		return nil, err
 	}
-	return this.GetByName(n)
+	return this.getByName(n)
 }
 
 func (this *BMCIndexServerIndex) GetByName(name resources.ObjectName) *BaseBoardManagementController {
-	o, _ := this.resource.Get(name)
-	m, _ := NewBaseBoardManagementController(o.Data().(*api.BaseBoardManagementControllerInfo))
+	m, _ := this.getByName(name)
 	return m
 }
+
+func (this *BMCIndexServerIndex) getByName(name resources.ObjectName) (*BaseBoardManagementController, error) {
+	o, err := this.object(name)
+	if o == nil {
+		return nil, err
+	}
+	return NewBaseBoardManagementController(o.Data().(*api.BaseBoardManagementControllerInfo))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type MachineTypeIndexServerIndex struct {
+	*indexServerIndex
+	fallback MachineTypeIndex
+}
+
+var _ MachineTypeIndex = &MachineTypeIndexServerIndex{}
+var _ MachineTypeContextIndex = &MachineTypeIndexServerIndex{}
+
+func NewMachineTypeIndexServerIndex(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (MachineTypeIndex, error) {
+	f, err := NewMachineTypeIndexServerIndexCreator(logger, cluster, endpoints, max, ttl, access, transport)
+	if err != nil {
+		return nil, err
+	}
+	return f(), nil
+}
+
+func NewMachineTypeIndexServerIndexCreator(logger logger.LogContext, cluster cluster.Interface, endpoints *Endpoints, max int, ttl time.Duration, access *AccessConfig, transport *TransportConfig) (func() MachineTypeIndex, error) {
+	res, err := cluster.Resources().Get(api.MACHINETYPE)
+	if err != nil {
+		return nil, err
+	}
+	index, err := NewIndexServerIndex(logger, endpoints, PATH_MACHINETYPE, res, max, ttl, access, transport)
+	if err != nil {
+		return nil, err
+	}
+	return func() MachineTypeIndex {
+		return &MachineTypeIndexServerIndex{indexServerIndex: index}
+	}, nil
+}
+
+// SetFallback sets an index used while no index server is available.
+// It must be set before the index is used.
+func (this *MachineTypeIndexServerIndex) SetFallback(index MachineTypeIndex) {
+	this.fallback = index
+}
+
+func (this *MachineTypeIndexServerIndex) GetByMAC(mac string) *MachineType {
+	m, _ := this.LookupMachineType(context.Background(), mac)
+	return m
+}
+
+func (this *MachineTypeIndexServerIndex) LookupMachineType(ctx context.Context, mac string) (*MachineType, error) {
+	n, err := this.get(ctx, KEY_MAC, mac)
+	if IsUnavailable(err) && this.fallback != nil && this.fallback.IsInitialized() {
+		clientFallbacks.WithLabelValues(this.access.indexName()).Inc()
+		return LookupMachineType(ctx, this.fallback, mac)
+	}
+	if n == nil {
+		return nil, err
+	}
+	return this.getByName(n)
+}
+
+func (this *MachineTypeIndexServerIndex) GetByName(name resources.ObjectName) *MachineType {
+	m, _ := this.getByName(name)
+	return m
+}
+
+func (this *MachineTypeIndexServerIndex) getByName(name resources.ObjectName) (*MachineType, error) {
+	o, err := this.object(name)
+	if o == nil {
+		return nil, err
+	}
+	return NewMachineType(o.Data().(*api.MachineType))
+}
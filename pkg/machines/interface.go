@@ -23,6 +23,9 @@
 package machines
 
 import (
+	"context"
+	"time"
+
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
@@ -34,14 +37,21 @@ import (
 // MachineType Info
 
 type MachineType struct {
-	Name resources.ObjectName
+	Name            resources.ObjectName
+	ResourceVersion string
 	*api.MachineTypeSpec
 	prefixes []*MACPrefix
 }
 
+// MachineTypeLister is implemented by machine type indices able to
+// list all indexed machine types.
+type MachineTypeLister interface {
+	List() []*MachineType
+}
+
 type MachineTypeIndexer interface {
 	MachineTypeIndex
-	Setup(logger logger.LogContext, cluster cluster.Interface) error
+	Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error
 	Set(m *MachineType) error
 	Delete(name resources.ObjectName)
 }
@@ -56,15 +66,35 @@ type MachineTypeIndex interface {
 // Machine Info
 
 type Machine struct {
-	Name resources.ObjectName
+	Name            resources.ObjectName
+	ResourceVersion string
 	*api.MachineInfoSpec
+	macs   []string
+	labels map[string]string
+}
+
+// MACs returns the canonical MAC addresses of the NICs.
+func (this *Machine) MACs() []string {
+	return this.macs
+}
+
+// Labels returns the labels of the machine info object.
+func (this *Machine) Labels() map[string]string {
+	return this.labels
 }
 
 type MachineIndexer interface {
 	MachineIndex
-	Setup(logger logger.LogContext, cluster cluster.Interface) error
+	Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error
 	Set(m *Machine) error
 	Delete(name resources.ObjectName)
+	Conflicts(name resources.ObjectName) []resources.ObjectName
+}
+
+// MachineLister is implemented by machine indices able to list
+// all indexed machines.
+type MachineLister interface {
+	List() []*Machine
 }
 
 type MachineIndex interface {
@@ -74,19 +104,65 @@ type MachineIndex interface {
 	GetByName(name resources.ObjectName) *Machine
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// DHCP Lease
+
+type Lease struct {
+	Name            resources.ObjectName
+	ResourceVersion string
+	*api.DHCPLeaseSpec
+	mac string
+}
+
+type LeaseIndexer interface {
+	LeaseIndex
+	Setup(logger logger.LogContext, cluster cluster.Interface) error
+	Set(l *Lease) error
+	Delete(name resources.ObjectName)
+}
+
+type LeaseIndex interface {
+	IsInitialized() bool
+	GetByMAC(mac string) *Lease
+	GetByName(name resources.ObjectName) *Lease
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // BMC Info
 
 type BaseBoardManagementController struct {
-	Name resources.ObjectName
+	Name            resources.ObjectName
+	ResourceVersion string
 	*api.BaseBoardManagementControllerInfoSpec
+	mac       string
+	ip        string
+	serials   []string
+	assettags []string
+	labels    map[string]string
+}
+
+// CanonicalMAC returns the canonical MAC address of the BMC.
+func (this *BaseBoardManagementController) CanonicalMAC() string {
+	return this.mac
+}
+
+// Labels returns the labels of the BMC info object.
+func (this *BaseBoardManagementController) Labels() map[string]string {
+	return this.labels
 }
 
 type BMCIndexer interface {
 	BMCIndex
-	Setup(logger logger.LogContext, cluster cluster.Interface) error
+	Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error
 	Set(m *BaseBoardManagementController) error
 	Delete(name resources.ObjectName)
+	Conflicts(name resources.ObjectName) []resources.ObjectName
+}
+
+// BMCLister is implemented by BMC indices able to list all
+// indexed BMCs.
+type BMCLister interface {
+	List() []*BaseBoardManagementController
 }
 
 type BMCIndex interface {
@@ -94,4 +170,88 @@ type BMCIndex interface {
 	GetByMAC(mac string) *BaseBoardManagementController
 	GetByUUID(uuid string) *BaseBoardManagementController
 	GetByName(name resources.ObjectName) *BaseBoardManagementController
+	// GetByIP looks up a BMC by its IP address
+	GetByIP(ip string) *BaseBoardManagementController
+	// GetBySerial looks up a BMC by a chassis, board or product serial
+	GetBySerial(serial string) *BaseBoardManagementController
+	// GetByAssetTag looks up a BMC by a chassis, board or product asset tag
+	GetByAssetTag(tag string) *BaseBoardManagementController
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// IndexWarmer is implemented by indices able to prefetch
+// the index entries for a set of keys with a single request.
+type IndexWarmer interface {
+	Warmup(ctx context.Context, macs []string, uuids []string) error
+}
+
+// ClaimIndex is implemented by indices tracking all objects claiming
+// a key. A key claimed by more than one object is ambiguous and not
+// found by the regular lookup methods.
+type ClaimIndex interface {
+	ClaimsForMAC(mac string) []resources.ObjectName
+	ClaimsForUUID(uuid string) []resources.ObjectName
+}
+
+// BMCClaimIndex is implemented by BMC indices tracking all objects
+// claiming the additional BMC keys.
+type BMCClaimIndex interface {
+	ClaimsForIP(ip string) []resources.ObjectName
+	ClaimsForSerial(serial string) []resources.ObjectName
+	ClaimsForAssetTag(tag string) []resources.ObjectName
+}
+
+// SnapshotIndex is implemented by indices that can be warm started
+// from a snapshot file. After loading a snapshot the index is
+// initialized but stale until the next Setup has reconciled it with
+// the objects of the cluster.
+type SnapshotIndex interface {
+	LoadSnapshot(logger logger.LogContext, path string) error
+	WriteSnapshot(path string) error
+	IsStale() bool
+	SnapshotTime() time.Time
+	ConsistentSince() time.Time
+}
+
+// IndexStatistics is implemented by indices able to report the
+// number of indexed objects and the time of the last update.
+type IndexStatistics interface {
+	Size() int
+	LastSync() time.Time
+}
+
+// IndexWatcher is implemented by remote indices able to
+// track changes of the original index.
+type IndexWatcher interface {
+	Watch(ctx context.Context)
+}
+
+// MachineContextIndex is implemented by machine indices with blocking
+// lookups, for example remote indices. The lookup (KEY_MAC or KEY_UUID)
+// is bound to the given context. Failures other than a missing machine
+// are reported as error.
+type MachineContextIndex interface {
+	LookupMachine(ctx context.Context, kind, key string) (*Machine, error)
+}
+
+// BMCContextIndex is implemented by BMC indices with blocking lookups.
+// In addition to MAC addresses and UUIDs the BMC specific key kinds
+// KEY_IP, KEY_SERIAL and KEY_ASSETTAG are supported.
+type BMCContextIndex interface {
+	LookupBMC(ctx context.Context, kind, key string) (*BaseBoardManagementController, error)
+}
+
+// MachineTypeContextIndex is implemented by machine type indices
+// with blocking lookups of the type matching a MAC address.
+type MachineTypeContextIndex interface {
+	LookupMachineType(ctx context.Context, mac string) (*MachineType, error)
+}
+
+// IndexCache is implemented by remote indices caching lookup results.
+// Invalidate removes the cached entries for a MAC address or UUID,
+// Flush removes all cached entries.
+type IndexCache interface {
+	Invalidate(key string)
+	Flush()
 }
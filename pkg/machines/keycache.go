@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+// cacheKey identifies a cached lookup result by key kind
+// (KEY_MAC or KEY_UUID) and key value.
+type cacheKey struct {
+	kind string
+	key  string
+}
+
+type keyEntry struct {
+	key     cacheKey
+	name    resources.ObjectName
+	expires time.Time
+}
+
+// keyCache is a bounded LRU cache mapping MAC addresses and UUIDs
+// to the object names found by the index server.
+// Entries expire after a ttl (0=no expiry). The cache is not
+// synchronized, the owner has to provide the locking.
+type keyCache struct {
+	max int
+	ttl time.Duration
+	now func() time.Time
+
+	list    *list.List
+	entries map[cacheKey]*list.Element
+	names   map[string]map[cacheKey]struct{}
+}
+
+func newKeyCache(max int, ttl time.Duration) *keyCache {
+	return &keyCache{
+		max:     max,
+		ttl:     ttl,
+		now:     time.Now,
+		list:    list.New(),
+		entries: map[cacheKey]*list.Element{},
+		names:   map[string]map[cacheKey]struct{}{},
+	}
+}
+
+func (this *keyCache) len() int {
+	return this.list.Len()
+}
+
+// get returns the cached name for a key and marks it as recently used.
+// The second result reports whether an expired entry has been dropped.
+func (this *keyCache) get(kind, key string) (resources.ObjectName, bool) {
+	elem := this.entries[cacheKey{kind, key}]
+	if elem == nil {
+		return nil, false
+	}
+	e := elem.Value.(*keyEntry)
+	if this.ttl > 0 && !this.now().Before(e.expires) {
+		this.removeElement(elem)
+		return nil, true
+	}
+	this.list.MoveToFront(elem)
+	return e.name, false
+}
+
+// put sets the name for a key. An existing entry for the key is
+// replaced, for example if a NIC has been moved to another object.
+// It returns the number of entries evicted to respect the size limit.
+func (this *keyCache) put(kind, key string, name resources.ObjectName) int {
+	if this.max <= 0 {
+		return 0
+	}
+	k := cacheKey{kind, key}
+	if elem := this.entries[k]; elem != nil {
+		this.removeElement(elem)
+	}
+	e := &keyEntry{key: k, name: name}
+	if this.ttl > 0 {
+		e.expires = this.now().Add(this.ttl)
+	}
+	this.entries[k] = this.list.PushFront(e)
+	n := name.String()
+	set := this.names[n]
+	if set == nil {
+		set = map[cacheKey]struct{}{}
+		this.names[n] = set
+	}
+	set[k] = struct{}{}
+
+	evicted := 0
+	for this.list.Len() > this.max {
+		this.removeElement(this.list.Back())
+		evicted++
+	}
+	return evicted
+}
+
+// remove drops the entry for a key.
+func (this *keyCache) remove(kind, key string) {
+	if elem := this.entries[cacheKey{kind, key}]; elem != nil {
+		this.removeElement(elem)
+	}
+}
+
+// removeName drops all entries pointing to the given object.
+func (this *keyCache) removeName(name resources.ObjectName) {
+	for k := range this.names[name.String()] {
+		this.removeElement(this.entries[k])
+	}
+}
+
+// removeKeys drops all entries of a key kind with keys
+// accepted by the given function.
+func (this *keyCache) removeKeys(kind string, match func(key string) bool) {
+	for k, elem := range this.entries {
+		if k.kind == kind && match(k.key) {
+			this.removeElement(elem)
+		}
+	}
+}
+
+// keys returns the keys of a key kind.
+func (this *keyCache) keys(kind string) []string {
+	var result []string
+	for k := range this.entries {
+		if k.kind == kind {
+			result = append(result, k.key)
+		}
+	}
+	return result
+}
+
+func (this *keyCache) flush() {
+	this.list.Init()
+	this.entries = map[cacheKey]*list.Element{}
+	this.names = map[string]map[cacheKey]struct{}{}
+}
+
+func (this *keyCache) removeElement(elem *list.Element) {
+	e := this.list.Remove(elem).(*keyEntry)
+	delete(this.entries, e.key)
+	n := e.name.String()
+	if set := this.names[n]; set != nil {
+		delete(set, e.key)
+		if len(set) == 0 {
+			delete(this.names, n)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("index client cache", func() {
+	m1 := resources.NewObjectName("default", "m1")
+	m2 := resources.NewObjectName("default", "m2")
+	m3 := resources.NewObjectName("default", "m3")
+
+	Context("lru", func() {
+		var cache *keyCache
+		var now time.Time
+
+		BeforeEach(func() {
+			now = time.Now()
+			cache = newKeyCache(2, time.Minute)
+			cache.now = func() time.Time { return now }
+		})
+
+		It("evicts least recently used entry", func() {
+			Expect(cache.put(KEY_MAC, "a", m1)).To(Equal(0))
+			Expect(cache.put(KEY_MAC, "b", m2)).To(Equal(0))
+			n, _ := cache.get(KEY_MAC, "a")
+			Expect(n).To(Equal(m1))
+			Expect(cache.put(KEY_UUID, "c", m3)).To(Equal(1))
+
+			Expect(cache.len()).To(Equal(2))
+			n, _ = cache.get(KEY_MAC, "b")
+			Expect(n).To(BeNil())
+			n, _ = cache.get(KEY_MAC, "a")
+			Expect(n).To(Equal(m1))
+		})
+
+		It("expires entries", func() {
+			cache.put(KEY_MAC, "a", m1)
+			now = now.Add(time.Minute)
+			n, expired := cache.get(KEY_MAC, "a")
+			Expect(n).To(BeNil())
+			Expect(expired).To(BeTrue())
+			Expect(cache.len()).To(Equal(0))
+		})
+
+		It("moves key to another object", func() {
+			cache.put(KEY_MAC, "a", m1)
+			cache.put(KEY_MAC, "a", m2)
+			Expect(cache.len()).To(Equal(1))
+			n, _ := cache.get(KEY_MAC, "a")
+			Expect(n).To(Equal(m2))
+
+			cache.removeName(m1)
+			n, _ = cache.get(KEY_MAC, "a")
+			Expect(n).To(Equal(m2))
+			cache.removeName(m2)
+			Expect(cache.len()).To(Equal(0))
+		})
+
+		It("separates key kinds", func() {
+			cache.put(KEY_MAC, "a", m1)
+			cache.put(KEY_UUID, "a", m2)
+			cache.remove(KEY_MAC, "a")
+			n, _ := cache.get(KEY_UUID, "a")
+			Expect(n).To(Equal(m2))
+		})
+	})
+
+	Context("client", func() {
+		var server *httptest.Server
+		var client *IndexServerClient
+		var owner string
+		var requests int
+
+		BeforeEach(func() {
+			owner = "m1"
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodPost {
+					// batch request: every key is found
+					req := BatchRequest{}
+					json.NewDecoder(r.Body).Decode(&req)
+					resp := BatchResponse{}
+					for path, keys := range req {
+						result := &BatchKeyResults{MACs: map[string]*BatchResult{}, UUIDs: map[string]*BatchResult{}}
+						for _, k := range keys.MACs {
+							result.MACs[k] = NewBatchResult(resources.NewObjectName("default", owner), "1")
+						}
+						for _, k := range keys.UUIDs {
+							result.UUIDs[k] = NewBatchResult(resources.NewObjectName("default", owner), "1")
+						}
+						resp[path] = result
+					}
+					json.NewEncoder(w).Encode(resp)
+					return
+				}
+				w.Write([]byte(`{"name":"` + owner + `","namespace":"default"}`))
+			}))
+			var err error
+			client, err = newTestClient(10, time.Minute, nil, server)
+			Expect(err).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("caches uuid lookups by uuid", func() {
+			client.get(context.Background(), "", "u1")
+			n, err := client.get(context.Background(), "", "u1")
+			Expect(err).To(Succeed())
+			Expect(n.Name()).To(Equal("m1"))
+			Expect(requests).To(Equal(1))
+		})
+
+		It("follows moved key after invalidation", func() {
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			owner = "m2"
+			n, _ := client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(n.Name()).To(Equal("m1"))
+
+			client.Invalidate("00-00-00-00-00-01")
+			n, _ = client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(n.Name()).To(Equal("m2"))
+			Expect(requests).To(Equal(2))
+		})
+
+		It("invalidates keys of changed objects", func() {
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			client.get(context.Background(), "", "u1")
+			client.handleEvent(context.Background(), &IndexEvent{Type: "updated", Namespace: "default", Name: "m1"})
+			Expect(client.cache.len()).To(Equal(0))
+		})
+
+		It("invalidates keys covered by changed prefixes", func() {
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			client.get(context.Background(), "00:00:01:00:00:01", "")
+			client.handleEvent(context.Background(), &IndexEvent{Type: "added", Namespace: "default", Name: "t2", Prefixes: []string{"00:00:00:00:00:00/24"}})
+			Expect(client.cache.len()).To(Equal(1))
+			n, _ := client.cache.get(KEY_MAC, "00:00:01:00:00:01")
+			Expect(n.Name()).To(Equal("m1"))
+		})
+
+		It("flushes cache", func() {
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			client.Flush()
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			Expect(requests).To(Equal(2))
+		})
+
+		It("refreshes the cache on a feed reset with a batch request", func() {
+			client.get(context.Background(), "00:00:00:00:00:01", "")
+			client.get(context.Background(), "", "u1")
+			owner = "m2"
+			client.handleEvent(context.Background(), &IndexEvent{Type: EVENT_RESET})
+			Expect(requests).To(Equal(3))
+			n, _ := client.cache.get(KEY_MAC, "00:00:00:00:00:01")
+			Expect(n.Name()).To(Equal("m2"))
+			n, _ = client.cache.get(KEY_UUID, "u1")
+			Expect(n.Name()).To(Equal("m2"))
+		})
+
+		It("splits warmups into batch requests of limited size", func() {
+			var uuids []string
+			for i := 0; i < MAX_BATCH_KEYS+1; i++ {
+				uuids = append(uuids, fmt.Sprintf("u%d", i))
+			}
+			Expect(client.Warmup(context.Background(), nil, uuids)).To(Succeed())
+			Expect(requests).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func NewLease(l *api.DHCPLease) (*Lease, error) {
+	mac, err := NormalizeMAC(l.Spec.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac address %q: %s", l.Spec.MAC, err)
+	}
+	return &Lease{
+		Name:            resources.NewObjectName(l.Namespace, l.Name),
+		ResourceVersion: l.ResourceVersion,
+		DHCPLeaseSpec:   &l.Spec,
+		mac:             mac,
+	}, nil
+}
+
+// CanonicalMAC returns the canonical MAC address of the lease.
+func (this *Lease) CanonicalMAC() string {
+	return this.mac
+}
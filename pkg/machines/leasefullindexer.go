@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/labels"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+// LeaseFullIndexer indexes the DHCP lease objects by MAC address.
+// Several lease objects may exist for the same MAC address, for
+// example after a lease has been moved to another namespace. A lookup
+// then returns the lease with the latest expire time.
+type LeaseFullIndexer struct {
+	initlock    sync.RWMutex
+	lock        sync.RWMutex
+	initialized int32
+	elements    map[resources.ObjectName]*Lease
+	byMACs      claims
+}
+
+func NewLeaseFullIndexer() LeaseIndexer {
+	m := &LeaseFullIndexer{
+		elements: map[resources.ObjectName]*Lease{},
+		byMACs:   claims{},
+	}
+	m.initlock.Lock()
+	return m
+}
+
+func (this *LeaseFullIndexer) Wait() {
+	this.initlock.RLock()
+	this.initlock.RUnlock()
+}
+
+func (this *LeaseFullIndexer) IsInitialized() bool {
+	return atomic.LoadInt32(&this.initialized) != 0
+}
+
+func (this *LeaseFullIndexer) GetByMAC(mac string) *Lease {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	var found *Lease
+	for _, n := range this.byMACs[canonicalMAC(mac)] {
+		l := this.elements[n]
+		if found == nil || found.ExpireTime.Before(&l.ExpireTime) {
+			found = l
+		}
+	}
+	return found
+}
+
+func (this *LeaseFullIndexer) GetByName(name resources.ObjectName) *Lease {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.elements[name]
+}
+
+func (this *LeaseFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface) error {
+	if atomic.LoadInt32(&this.initialized) != 0 {
+		logger.Infof("lease cache already initialized")
+		return nil
+	}
+	if cluster == nil {
+		logger.Infof("waiting for lease cache")
+		this.Wait()
+		return nil
+	}
+
+	resc, err := cluster.Resources().Get(api.DHCPLEASE)
+	if err != nil {
+		return err
+	}
+	logger.Infof("setup leases")
+	list, _ := resc.ListCached(labels.Everything())
+
+	for _, l := range list {
+		elem, err := NewLease(l.Data().(*api.DHCPLease))
+		if elem != nil {
+			this.Set(elem)
+			logger.Infof("found lease %s", elem.Name)
+		}
+		if err != nil {
+			logger.Infof("errorneous lease %s: %s", l.GetName(), err)
+		}
+	}
+	logger.Infof("lease cache setup done")
+	atomic.StoreInt32(&this.initialized, 1)
+	this.initlock.Unlock()
+	return nil
+}
+
+func (this *LeaseFullIndexer) Set(l *Lease) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if old := this.elements[l.Name]; old != nil {
+		this.cleanup(old)
+	}
+	this.byMACs.add(l.mac, l.Name)
+	this.elements[l.Name] = l
+	return nil
+}
+
+func (this *LeaseFullIndexer) Delete(name resources.ObjectName) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if old := this.elements[name]; old != nil {
+		this.cleanup(old)
+	}
+}
+
+func (this *LeaseFullIndexer) cleanup(l *Lease) {
+	this.byMACs.remove(l.mac, l.Name)
+	delete(this.elements, l.Name)
+}
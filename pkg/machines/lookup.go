@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"fmt"
+)
+
+// LookupMachine looks up a machine by a key kind (KEY_MAC or KEY_UUID).
+// Indices implementing MachineContextIndex are asked with the given
+// context, all other indices are used with their regular lookup methods.
+func LookupMachine(ctx context.Context, index MachineIndex, kind, key string) (*Machine, error) {
+	if c, ok := index.(MachineContextIndex); ok {
+		return c.LookupMachine(ctx, kind, key)
+	}
+	switch kind {
+	case KEY_MAC:
+		return index.GetByMAC(key), nil
+	case KEY_UUID:
+		return index.GetByUUID(key), nil
+	}
+	return nil, fmt.Errorf("unsupported machine key %q", kind)
+}
+
+// LookupBMC looks up a BMC by a key kind. Indices implementing
+// BMCContextIndex are asked with the given context, all other
+// indices are used with their regular lookup methods.
+func LookupBMC(ctx context.Context, index BMCIndex, kind, key string) (*BaseBoardManagementController, error) {
+	if c, ok := index.(BMCContextIndex); ok {
+		return c.LookupBMC(ctx, kind, key)
+	}
+	switch kind {
+	case KEY_MAC:
+		return index.GetByMAC(key), nil
+	case KEY_UUID:
+		return index.GetByUUID(key), nil
+	case KEY_IP:
+		return index.GetByIP(key), nil
+	case KEY_SERIAL:
+		return index.GetBySerial(key), nil
+	case KEY_ASSETTAG:
+		return index.GetByAssetTag(key), nil
+	}
+	return nil, fmt.Errorf("unsupported bmc key %q", kind)
+}
+
+// LookupMachineType looks up the machine type matching a MAC address.
+// Indices implementing MachineTypeContextIndex are asked with the
+// given context.
+func LookupMachineType(ctx context.Context, index MachineTypeIndex, mac string) (*MachineType, error) {
+	if c, ok := index.(MachineTypeContextIndex); ok {
+		return c.LookupMachineType(ctx, mac)
+	}
+	return index.GetByMAC(mac), nil
+}
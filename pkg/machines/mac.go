@@ -34,8 +34,39 @@ type MAC = net.HardwareAddr
 const EUI48_LEN = 6
 const EUI64_LEN = 8
 
-func ParseMAC(s string) (net.HardwareAddr, error) {
-	return net.ParseMAC(s)
+const EUI_MAX_LEN = 20
+
+// ParseMAC parses an EUI-48, EUI-64 or 20 byte IP over InfiniBand
+// link-layer address given in colon or hyphen separated or in
+// Cisco dotted notation.
+func ParseMAC(s string) (MAC, error) {
+	hw, err := parseMACPart(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	switch len(hw) {
+	case EUI48_LEN, EUI64_LEN, EUI_MAX_LEN:
+		return hw, nil
+	}
+	return nil, &net.AddrError{Err: "invalid MAC address", Addr: s}
+}
+
+// NormalizeMAC returns the canonical (lower case and colon separated)
+// representation of a MAC address. It is used for all index keys,
+// so that different notations of the same address match.
+func NormalizeMAC(s string) (string, error) {
+	hw, err := ParseMAC(s)
+	if err != nil {
+		return "", err
+	}
+	return hw.String(), nil
+}
+
+// canonicalMAC returns the canonical representation of a MAC address
+// used as lookup key or an empty string for an invalid address.
+func canonicalMAC(s string) string {
+	n, _ := NormalizeMAC(s)
+	return n
 }
 
 type MACPrefix struct {
@@ -56,7 +87,7 @@ func (this *MACPrefix) Contains(m MAC) bool {
 	mask := byte(0xFF)
 	for b < this.Bits {
 		if this.Bits-b < 8 {
-			mask = ^byte(0xff>>this.Bits - b)
+			mask = ^byte(0xff >> uint(this.Bits-b))
 		}
 		if m[b/8]&mask != this.Address[b/8]&mask {
 			return false
@@ -81,16 +112,13 @@ func ParseMACPrefix(s string) (*MACPrefix, error) {
 	if i == len(s) {
 		l = len(m) * 8
 	} else {
-		var _l int64
-		_l, err = strconv.ParseInt(s[i+1:], 10, 8)
-		l = int(_l)
-
+		l, err = strconv.Atoi(s[i+1:])
 	}
 
-	if err != nil || l > 20*8 || l < 0 {
+	if err != nil || l > EUI_MAX_LEN*8 || l < 0 {
 		return nil, fmt.Errorf("incalid length %q", s[i+1:])
 	}
-	r := 20
+	r := EUI_MAX_LEN
 	if l < EUI48_LEN*8 {
 		r = EUI48_LEN
 	} else {
@@ -104,7 +132,7 @@ func ParseMACPrefix(s string) (*MACPrefix, error) {
 			if b >= l {
 				m[b/8] = 0
 			} else {
-				m[b/8] = m[b/8] &^ byte(0xff>>b+8-l)
+				m[b/8] = m[b/8] &^ byte(0xff>>uint(l-b))
 			}
 		}
 		b += 8
@@ -124,11 +152,14 @@ func parseMACPart(s string) (hw MAC, err error) {
 	}
 	if len(s) == 2 || s[2] == ':' || s[2] == '-' {
 		sep := byte(':')
+		if len(s) > 2 {
+			sep = s[2]
+		}
 		if (len(s)+1)%3 != 0 {
 			goto error
 		}
 		n := (len(s) + 1) / 3
-		if n > 20 {
+		if n > EUI_MAX_LEN {
 			goto error
 		}
 		hw = make(MAC, n)
@@ -139,12 +170,12 @@ func parseMACPart(s string) (hw MAC, err error) {
 			}
 			x += 3
 		}
-	} else if s[4] == '.' {
+	} else if len(s) > 4 && s[4] == '.' {
 		if (len(s)+1)%5 != 0 {
 			goto error
 		}
 		n := 2 * (len(s) + 1) / 5
-		if n != 6 && n != 8 && n != 20 {
+		if n != EUI48_LEN && n != EUI64_LEN && n != EUI_MAX_LEN {
 			goto error
 		}
 		hw = make(MAC, n)
@@ -56,6 +56,47 @@ var _ = Describe("Mac Addresses", func() {
 
 			Expect(p.String()).To(Equal("20:21:00:00:00:00/16"))
 		})
+		It("handle prefixes of 20 byte addresses longer than 127 bits", func() {
+			p, err := ParseMACPrefix("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33/152")
+			Expect(err).To(BeNil())
+			Expect(p.String()).To(Equal("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:00/152"))
+			Expect(p.Contains(mustParseMAC("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:ff"))).To(BeTrue())
+			Expect(p.Contains(mustParseMAC("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:23:ff"))).To(BeFalse())
+
+			p, err = ParseMACPrefix("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33/160")
+			Expect(err).To(BeNil())
+			Expect(p.String()).To(Equal("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33/160"))
+
+			_, err = ParseMACPrefix("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33/161")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("Normalize", func() {
+		It("handles colon notation", func() {
+			Expect(NormalizeMAC("AA:BB:CC:DD:EE:0F")).To(Equal("aa:bb:cc:dd:ee:0f"))
+		})
+		It("handles hyphen notation", func() {
+			Expect(NormalizeMAC("aa-bb-cc-dd-ee-0f")).To(Equal("aa:bb:cc:dd:ee:0f"))
+		})
+		It("handles dotted notation", func() {
+			Expect(NormalizeMAC("aabb.ccdd.ee0f")).To(Equal("aa:bb:cc:dd:ee:0f"))
+		})
+		It("handles EUI-64 addresses", func() {
+			Expect(NormalizeMAC("AA-BB-CC-DD-EE-FF-00-11")).To(Equal("aa:bb:cc:dd:ee:ff:00:11"))
+		})
+		It("rejects mixed separators", func() {
+			_, err := NormalizeMAC("aa:bb-cc:dd:ee:0f")
+			Expect(err).NotTo(BeNil())
+		})
+		It("rejects invalid lengths", func() {
+			_, err := NormalizeMAC("aa:bb:cc")
+			Expect(err).NotTo(BeNil())
+		})
+		It("rejects garbage", func() {
+			_, err := NormalizeMAC("abc")
+			Expect(err).NotTo(BeNil())
+		})
 	})
 
 	Context("Prefix Contains", func() {
@@ -23,6 +23,8 @@
 package machines
 
 import (
+	"fmt"
+
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
 	"github.com/gardener/controller-manager-library/pkg/types/infodata/simple"
@@ -40,30 +42,41 @@ func NewMachine(m *api.MachineInfo) (*Machine, error) {
 	if nics == nil {
 		nics = []api.NIC{}
 	}
+	macs := make([]string, 0, len(nics))
+	for _, n := range nics {
+		mac, err := NormalizeMAC(n.MAC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mac address %q for nic %q: %s", n.MAC, n.Name, err)
+		}
+		macs = append(macs, mac)
+	}
 	return &Machine{
 		Name:            resources.NewObjectName(m.Namespace, m.Name),
+		ResourceVersion: m.ResourceVersion,
 		MachineInfoSpec: &m.Spec,
+		macs:            macs,
+		labels:          m.Labels,
 	}, nil
 }
 
-func ValidateMachine(logger logger.LogContext, obj resources.Object) (*Machine, error, error) {
+// IndexMachine validates a machine info object, updates the index and
+// the status of the object. Invalid objects and objects claiming keys
+// of other objects are marked as invalid. Invalid objects are removed
+// from the index. It returns the other objects whose conflict state has
+// changed and which have to be revalidated.
+func IndexMachine(logger logger.LogContext, index MachineIndexer, obj resources.Object) ([]resources.ObjectName, error) {
+	name := ObjectNameFor(obj)
+	before := index.Conflicts(name)
 	m, err := NewMachine(obj.Data().(*api.MachineInfo))
-
 	if err != nil {
 		logger.Errorf("invalid machine: %s", err)
-		_, err2 := resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
-			m := mod.Data().(*api.MachineInfo)
-			mod.AssureStringValue(&m.Status.State, api.STATE_INVALID)
-			mod.AssureStringValue(&m.Status.Message, err.Error())
-			return nil
-		})
-		return nil, err, err2
+		index.Delete(name)
+	} else {
+		m.Name = name
+		err = index.Set(m)
+		if err != nil {
+			logger.Errorf("conflicting machine: %s", err)
+		}
 	}
-	_, err = resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
-		m := mod.Data().(*api.MachineInfo)
-		mod.AssureStringValue(&m.Status.State, api.STATE_OK)
-		mod.AssureStringValue(&m.Status.Message, "machine ok")
-		return nil
-	})
-	return m, nil, err
+	return changedConflicts(before, index.Conflicts(name)), updateStatus(obj, err, "machine ok")
 }
@@ -23,13 +23,16 @@
 package machines
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
-	"k8s.io/apimachinery/pkg/labels"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
 )
@@ -41,24 +44,44 @@ import (
  */
 
 type MachineFullIndexer struct {
+	listeners
+	snapshotState
 	initlock    sync.RWMutex
 	lock        sync.RWMutex
 	initialized int32
+	lastSync    time.Time
 	elements    map[resources.ObjectName]*Machine
-	byMACs      map[string]*Machine
-	byUUIDs     map[string]*Machine
+	byMACs      claims
+	byUUIDs     claims
 }
 
 func NewFullIndexer() MachineIndexer {
 	m := &MachineFullIndexer{
 		elements: map[resources.ObjectName]*Machine{},
-		byMACs:   map[string]*Machine{},
-		byUUIDs:  map[string]*Machine{},
+		byMACs:   claims{},
+		byUUIDs:  claims{},
 	}
 	m.initlock.Lock()
 	return m
 }
 
+var _ IndexStatistics = &MachineFullIndexer{}
+var _ SnapshotIndex = &MachineFullIndexer{}
+
+func (this *MachineFullIndexer) Size() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return len(this.elements)
+}
+
+func (this *MachineFullIndexer) LastSync() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.lastSync
+}
+
 func (this *MachineFullIndexer) Wait() {
 	this.initlock.RLock()
 	this.initlock.RUnlock()
@@ -72,14 +95,66 @@ func (this *MachineFullIndexer) GetByMAC(mac string) *Machine {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
 
-	return this.byMACs[mac]
+	return this.get(this.byMACs.get(canonicalMAC(mac)))
 }
 
 func (this *MachineFullIndexer) GetByUUID(uuid string) *Machine {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
 
-	return this.byUUIDs[uuid]
+	return this.get(this.byUUIDs.get(uuid))
+}
+
+func (this *MachineFullIndexer) get(name resources.ObjectName) *Machine {
+	if name == nil {
+		return nil
+	}
+	return this.elements[name]
+}
+
+func (this *MachineFullIndexer) ClaimsForMAC(mac string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byMACs.claimants(canonicalMAC(mac))
+}
+
+func (this *MachineFullIndexer) ClaimsForUUID(uuid string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.byUUIDs.claimants(uuid)
+}
+
+func (this *MachineFullIndexer) Conflicts(name resources.ObjectName) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	m := this.elements[name]
+	if m == nil {
+		return nil
+	}
+	set := map[resources.ObjectName]struct{}{}
+	for _, mac := range m.macs {
+		addNames(set, others(this.byMACs[mac], name))
+	}
+	if m.UUID != "" {
+		addNames(set, others(this.byUUIDs[m.UUID], name))
+	}
+	return sortedNames(set)
+}
+
+// List returns all indexed objects ordered by name.
+func (this *MachineFullIndexer) List() []*Machine {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	list := make([]*Machine, 0, len(this.elements))
+	for _, e := range this.elements {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name.String() < list[j].Name.String() })
+	return list
 }
 
 func (this *MachineFullIndexer) GetByName(name resources.ObjectName) *Machine {
@@ -89,8 +164,9 @@ func (this *MachineFullIndexer) GetByName(name resources.ObjectName) *Machine {
 	return this.elements[name]
 }
 
-func (this *MachineFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface) error {
-	if atomic.LoadInt32(&this.initialized) != 0 {
+func (this *MachineFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error {
+	stale := this.IsStale()
+	if atomic.LoadInt32(&this.initialized) != 0 && !stale {
 		logger.Infof("machine cache already initialized")
 		return nil
 	}
@@ -105,59 +181,174 @@ func (this *MachineFullIndexer) Setup(logger logger.LogContext, cluster cluster.
 		return err
 	}
 	logger.Infof("setup machines")
-	list, _ := resc.ListCached(labels.Everything())
+	list, _ := scope.ListCached(resc)
 
+	live := map[resources.ObjectName]struct{}{}
 	for _, l := range list {
-		elem, err, _ := ValidateMachine(logger, l)
+		elem, err := NewMachine(l.Data().(*api.MachineInfo))
 		if elem != nil {
-			this.Set(elem)
+			live[elem.Name] = struct{}{}
+			if old := this.GetByName(elem.Name); stale && old != nil && old.ResourceVersion == elem.ResourceVersion {
+				continue
+			}
+			err = this.Set(elem)
 			logger.Infof("found machine %s", elem.Name)
 		}
 		if err != nil {
 			logger.Infof("errorneous machine %s: %s", l.GetName(), err)
 		}
 	}
+	if stale {
+		for _, e := range this.List() {
+			// objects of additional clusters are reconciled by the remote
+			// watches, which drop the objects of unconfigured clusters
+			if _, ok := live[e.Name]; !ok && ClusterOf(e.Name) == "" {
+				logger.Infof("machine %s from snapshot not found anymore", e.Name)
+				this.Delete(e.Name)
+			}
+		}
+	}
 	logger.Infof("machine cache setup done")
-	atomic.StoreInt32(&this.initialized, 1)
-	this.initlock.Unlock()
+	this.lock.Lock()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	this.setConsistent()
+	if stale {
+		logger.Infof("machine cache is consistent now (snapshot from %s)", this.SnapshotTime().Format(time.RFC3339))
+	} else {
+		atomic.StoreInt32(&this.initialized, 1)
+		this.initlock.Unlock()
+	}
 	return nil
 }
 
 func (this *MachineFullIndexer) Set(m *Machine) error {
 	this.lock.Lock()
-	defer this.lock.Unlock()
-
 	old := this.elements[m.Name]
 	if old != nil {
 		this.cleanup(old)
 	}
-	this.set(m)
+	conflicts := this.set(m)
+	this.updateMetrics()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_MACHINEINFO, OP_SET).Inc()
+
+	c := newChange(old != nil, m.Name, m.ResourceVersion)
+	if old != nil {
+		c.addKeys(old)
+	}
+	c.addKeys(m)
+	this.notify(c)
+	if len(conflicts) > 0 {
+		return &ConflictError{Name: m.Name, Conflicts: conflicts}
+	}
 	return nil
 }
 
 func (this *MachineFullIndexer) Delete(name resources.ObjectName) {
 	this.lock.Lock()
-	defer this.lock.Unlock()
 	old := this.elements[name]
 	if old != nil {
 		this.cleanup(old)
+		this.updateMetrics()
+	}
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_MACHINEINFO, OP_DELETE).Inc()
+
+	if old != nil {
+		c := &IndexChange{Type: CHANGE_DELETED, Name: name, ResourceVersion: old.ResourceVersion}
+		c.addKeys(old)
+		this.notify(c)
 	}
 }
 
 func (this *MachineFullIndexer) cleanup(m *Machine) {
-	for _, n := range m.NICs {
-		delete(this.byMACs, n.MAC)
+	for _, mac := range m.macs {
+		this.byMACs.remove(mac, m.Name)
+	}
+	if m.UUID != "" {
+		this.byUUIDs.remove(m.UUID, m.Name)
 	}
-	delete(this.byUUIDs, m.UUID)
 	delete(this.elements, m.Name)
 }
 
-func (this *MachineFullIndexer) set(m *Machine) {
-	for _, n := range m.NICs {
-		this.byMACs[n.MAC] = m
+// set indexes a machine and returns the other objects
+// claiming keys of this machine.
+func (this *MachineFullIndexer) set(m *Machine) []resources.ObjectName {
+	set := map[resources.ObjectName]struct{}{}
+	for _, mac := range m.macs {
+		if o := this.byMACs.add(mac, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_MACHINEINFO, KEY_MAC).Inc()
+			addNames(set, o)
+		}
 	}
 	if m.UUID != "" {
-		this.byUUIDs[m.UUID] = m
+		if o := this.byUUIDs.add(m.UUID, m.Name); len(o) > 0 {
+			indexConflicts.WithLabelValues(PATH_MACHINEINFO, KEY_UUID).Inc()
+			addNames(set, o)
+		}
 	}
 	this.elements[m.Name] = m
+	return sortedNames(set)
+}
+
+func (this *MachineFullIndexer) updateMetrics() {
+	indexElements.WithLabelValues(PATH_MACHINEINFO).Set(float64(len(this.elements)))
+	indexKeys.WithLabelValues(PATH_MACHINEINFO, KEY_MAC).Set(float64(len(this.byMACs)))
+	indexKeys.WithLabelValues(PATH_MACHINEINFO, KEY_UUID).Set(float64(len(this.byUUIDs)))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// snapshots
+
+// LoadSnapshot fills the uninitialized index with the objects of
+// a snapshot file. The index is then initialized, but stale until
+// the next Setup.
+func (this *MachineFullIndexer) LoadSnapshot(logger logger.LogContext, path string) error {
+	if atomic.LoadInt32(&this.initialized) != 0 {
+		return fmt.Errorf("machine index already initialized")
+	}
+	var list []*api.MachineInfo
+	created, err := readSnapshot(path, "MachineInfo", &list)
+	if err != nil {
+		return err
+	}
+	for _, o := range list {
+		elem, err := NewMachine(o)
+		if elem != nil {
+			elem.Name = NewClusterObjectName(o.ClusterName, o.Namespace, o.Name)
+			err = this.Set(elem)
+		}
+		if err != nil {
+			logger.Infof("errorneous machine %s in snapshot: %s", o.Name, err)
+		}
+	}
+	this.lock.Lock()
+	this.lastSync = created
+	this.lock.Unlock()
+	this.setStale(created)
+	logger.Infof("loaded %d machine(s) from snapshot %s created %s", len(list), path, created.Format(time.RFC3339))
+	atomic.StoreInt32(&this.initialized, 1)
+	this.initlock.Unlock()
+	return nil
+}
+
+// WriteSnapshot writes all indexed objects to a snapshot file.
+func (this *MachineFullIndexer) WriteSnapshot(path string) error {
+	list := []*api.MachineInfo{}
+	for _, e := range this.List() {
+		list = append(list, &api.MachineInfo{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     ClusterOf(e.Name),
+				Namespace:       e.Name.Namespace(),
+				Name:            e.Name.Name(),
+				ResourceVersion: e.ResourceVersion,
+				Labels:          e.labels,
+			},
+			Spec: *e.MachineInfoSpec,
+		})
+	}
+	return writeSnapshot(path, "MachineInfo", list)
 }
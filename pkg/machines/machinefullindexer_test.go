@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func newMachineInfo(name string, macs ...string) *api.MachineInfo {
+	m := &api.MachineInfo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+	for i, mac := range macs {
+		m.Spec.NICs = append(m.Spec.NICs, api.NIC{Name: string(rune('a' + i)), MAC: mac})
+	}
+	return m
+}
+
+var _ = Describe("Machine Full Indexer", func() {
+	It("finds machines for all mac notations", func() {
+		index := NewFullIndexer()
+		m, err := NewMachine(newMachineInfo("m1", "AA-BB-CC-DD-EE-0F"))
+		Expect(err).To(BeNil())
+		index.Set(m)
+
+		for _, mac := range []string{"aa:bb:cc:dd:ee:0f", "AA:BB:CC:DD:EE:0F", "aabb.ccdd.ee0f"} {
+			Expect(index.GetByMAC(mac)).To(Equal(m))
+		}
+		Expect(index.GetByMAC("garbage")).To(BeNil())
+	})
+
+	It("tracks conflicting machines", func() {
+		index := NewFullIndexer()
+		m1, _ := NewMachine(newMachineInfo("m1", "aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:0f"))
+		m2, _ := NewMachine(newMachineInfo("m2", "aa:bb:cc:dd:ee:02", "AA-BB-CC-DD-EE-0F"))
+		Expect(index.Set(m1)).To(BeNil())
+
+		err := index.Set(m2)
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(err.(*ConflictError).Conflicts).To(Equal([]resources.ObjectName{m1.Name}))
+		Expect(index.Conflicts(m1.Name)).To(Equal([]resources.ObjectName{m2.Name}))
+
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:0f")).To(BeNil())
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:01")).To(Equal(m1))
+		Expect(index.(ClaimIndex).ClaimsForMAC("aa:bb:cc:dd:ee:0f")).To(Equal([]resources.ObjectName{m1.Name, m2.Name}))
+
+		index.Delete(m1.Name)
+		Expect(index.Conflicts(m2.Name)).To(BeNil())
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:0f")).To(Equal(m2))
+	})
+
+	It("resolves conflicts on update", func() {
+		index := NewFullIndexer()
+		m1, _ := NewMachine(newMachineInfo("m1", "aa:bb:cc:dd:ee:0f"))
+		m2, _ := NewMachine(newMachineInfo("m2", "aa:bb:cc:dd:ee:0f"))
+		index.Set(m1)
+		index.Set(m2)
+
+		m2, _ = NewMachine(newMachineInfo("m2", "aa:bb:cc:dd:ee:02"))
+		Expect(index.Set(m2)).To(BeNil())
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:0f")).To(Equal(m1))
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:02")).To(Equal(m2))
+		Expect(changedConflicts([]resources.ObjectName{m1.Name}, index.Conflicts(m2.Name))).To(Equal([]resources.ObjectName{m1.Name}))
+	})
+
+	It("rejects machines with invalid nic macs", func() {
+		_, err := NewMachine(newMachineInfo("m1", "aa:bb:cc:dd:ee:0f", "garbage"))
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/onmetal/k8s-machines/pkg/metrics"
+)
+
+const (
+	KEY_MAC      = "mac"
+	KEY_UUID     = "uuid"
+	KEY_PREFIX   = "prefix"
+	KEY_IP       = "ip"
+	KEY_SERIAL   = "serial"
+	KEY_ASSETTAG = "assettag"
+
+	OP_SET    = "set"
+	OP_DELETE = "delete"
+
+	CACHE_HIT     = "hit"
+	CACHE_MISS    = "miss"
+	CACHE_EXPIRED = "expired"
+)
+
+var (
+	indexElements = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "machines_index_elements",
+		Help: "Number of objects in an index.",
+	}, []string{"index"})
+	indexKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "machines_index_keys",
+		Help: "Number of keys in an index.",
+	}, []string{"index", "key"})
+	indexConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_index_conflicts_total",
+		Help: "Number of keys claimed by more than one object when updating an index.",
+	}, []string{"index", "key"})
+	indexUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_index_updates_total",
+		Help: "Number of index updates.",
+	}, []string{"index", "operation"})
+
+	clientCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexclient_cache_total",
+		Help: "Number of cache lookups of index server clients.",
+	}, []string{"index", "result"})
+	clientEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexclient_cache_evictions_total",
+		Help: "Number of cache entries evicted by index server clients.",
+	}, []string{"index"})
+	clientErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexclient_remote_errors_total",
+		Help: "Number of failed requests of index server clients.",
+	}, []string{"index"})
+	clientBreakerOpened = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexclient_circuit_breaker_opened_total",
+		Help: "Number of times index server clients stopped sending requests after consecutive failures.",
+	}, []string{"index"})
+	clientFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexclient_fallback_lookups_total",
+		Help: "Number of lookups answered by the local fallback index while no index server was available.",
+	}, []string{"index"})
+)
+
+func init() {
+	metrics.MustRegister(indexElements, indexKeys, indexConflicts, indexUpdates)
+	metrics.MustRegister(clientCache, clientEvictions, clientErrors, clientBreakerOpened, clientFallbacks)
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+// NamespaceTypeIndex is implemented by machine type indices able to
+// look up the machine types of a namespace.
+type NamespaceTypeIndex interface {
+	GetByMACInNamespace(namespace, mac string) *MachineType
+}
+
+// MachineIndexForNamespace returns a view of a machine index restricted
+// to the objects of a namespace. Keys are resolved in the namespace
+// only, claims of other namespaces are ignored. It returns nil if the
+// index does not support namespace lookups.
+func MachineIndexForNamespace(index MachineIndex, namespace string) MachineIndex {
+	c, ok := index.(ClaimIndex)
+	if !ok {
+		return nil
+	}
+	return &machineNamespaceView{index: index, claims: c, namespace: namespace}
+}
+
+// BMCIndexForNamespace returns a view of a BMC index restricted to the
+// objects of a namespace. It returns nil if the index does not support
+// namespace lookups.
+func BMCIndexForNamespace(index BMCIndex, namespace string) BMCIndex {
+	c, ok := index.(ClaimIndex)
+	if !ok {
+		return nil
+	}
+	b, ok := index.(BMCClaimIndex)
+	if !ok {
+		return nil
+	}
+	return &bmcNamespaceView{index: index, claims: c, bmcclaims: b, namespace: namespace}
+}
+
+// MachineTypeIndexForNamespace returns a view of a machine type index
+// restricted to the types of a namespace. It returns nil if the index
+// does not support namespace lookups.
+func MachineTypeIndexForNamespace(index MachineTypeIndex, namespace string) MachineTypeIndex {
+	n, ok := index.(NamespaceTypeIndex)
+	if !ok {
+		return nil
+	}
+	return &typeNamespaceView{index: index, lookup: n, namespace: namespace}
+}
+
+// unique returns the single name of a list.
+func unique(names []resources.ObjectName) resources.ObjectName {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type machineNamespaceView struct {
+	index     MachineIndex
+	claims    ClaimIndex
+	namespace string
+}
+
+var _ MachineIndex = &machineNamespaceView{}
+var _ ClaimIndex = &machineNamespaceView{}
+
+func (this *machineNamespaceView) IsInitialized() bool {
+	return this.index.IsInitialized()
+}
+
+func (this *machineNamespaceView) GetByMAC(mac string) *Machine {
+	return this.get(unique(this.ClaimsForMAC(mac)))
+}
+
+func (this *machineNamespaceView) GetByUUID(uuid string) *Machine {
+	return this.get(unique(this.ClaimsForUUID(uuid)))
+}
+
+func (this *machineNamespaceView) GetByName(name resources.ObjectName) *Machine {
+	if name == nil || name.Namespace() != this.namespace {
+		return nil
+	}
+	return this.index.GetByName(name)
+}
+
+func (this *machineNamespaceView) get(name resources.ObjectName) *Machine {
+	if name == nil {
+		return nil
+	}
+	return this.index.GetByName(name)
+}
+
+func (this *machineNamespaceView) ClaimsForMAC(mac string) []resources.ObjectName {
+	return inNamespace(this.claims.ClaimsForMAC(mac), this.namespace)
+}
+
+func (this *machineNamespaceView) ClaimsForUUID(uuid string) []resources.ObjectName {
+	return inNamespace(this.claims.ClaimsForUUID(uuid), this.namespace)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type bmcNamespaceView struct {
+	index     BMCIndex
+	claims    ClaimIndex
+	bmcclaims BMCClaimIndex
+	namespace string
+}
+
+var _ BMCIndex = &bmcNamespaceView{}
+var _ BMCClaimIndex = &bmcNamespaceView{}
+
+func (this *bmcNamespaceView) IsInitialized() bool {
+	return this.index.IsInitialized()
+}
+
+func (this *bmcNamespaceView) GetByMAC(mac string) *BaseBoardManagementController {
+	return this.get(unique(this.ClaimsForMAC(mac)))
+}
+
+func (this *bmcNamespaceView) GetByUUID(uuid string) *BaseBoardManagementController {
+	return this.get(unique(this.ClaimsForUUID(uuid)))
+}
+
+func (this *bmcNamespaceView) GetByIP(ip string) *BaseBoardManagementController {
+	return this.get(unique(this.ClaimsForIP(ip)))
+}
+
+func (this *bmcNamespaceView) GetBySerial(serial string) *BaseBoardManagementController {
+	return this.get(unique(this.ClaimsForSerial(serial)))
+}
+
+func (this *bmcNamespaceView) GetByAssetTag(tag string) *BaseBoardManagementController {
+	return this.get(unique(this.ClaimsForAssetTag(tag)))
+}
+
+func (this *bmcNamespaceView) GetByName(name resources.ObjectName) *BaseBoardManagementController {
+	if name == nil || name.Namespace() != this.namespace {
+		return nil
+	}
+	return this.index.GetByName(name)
+}
+
+func (this *bmcNamespaceView) get(name resources.ObjectName) *BaseBoardManagementController {
+	if name == nil {
+		return nil
+	}
+	return this.index.GetByName(name)
+}
+
+func (this *bmcNamespaceView) ClaimsForMAC(mac string) []resources.ObjectName {
+	return inNamespace(this.claims.ClaimsForMAC(mac), this.namespace)
+}
+
+func (this *bmcNamespaceView) ClaimsForUUID(uuid string) []resources.ObjectName {
+	return inNamespace(this.claims.ClaimsForUUID(uuid), this.namespace)
+}
+
+func (this *bmcNamespaceView) ClaimsForIP(ip string) []resources.ObjectName {
+	return inNamespace(this.bmcclaims.ClaimsForIP(ip), this.namespace)
+}
+
+func (this *bmcNamespaceView) ClaimsForSerial(serial string) []resources.ObjectName {
+	return inNamespace(this.bmcclaims.ClaimsForSerial(serial), this.namespace)
+}
+
+func (this *bmcNamespaceView) ClaimsForAssetTag(tag string) []resources.ObjectName {
+	return inNamespace(this.bmcclaims.ClaimsForAssetTag(tag), this.namespace)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type typeNamespaceView struct {
+	index     MachineTypeIndex
+	lookup    NamespaceTypeIndex
+	namespace string
+}
+
+var _ MachineTypeIndex = &typeNamespaceView{}
+
+func (this *typeNamespaceView) IsInitialized() bool {
+	return this.index.IsInitialized()
+}
+
+func (this *typeNamespaceView) GetByMAC(mac string) *MachineType {
+	return this.lookup.GetByMACInNamespace(this.namespace, mac)
+}
+
+func (this *typeNamespaceView) GetByName(name resources.ObjectName) *MachineType {
+	if name == nil || name.Namespace() != this.namespace {
+		return nil
+	}
+	return this.index.GetByName(name)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newTenantMachine(namespace, name string, macs ...string) *Machine {
+	info := newMachineInfo(name, macs...)
+	info.Namespace = namespace
+	m, err := NewMachine(info)
+	Expect(err).To(BeNil())
+	return m
+}
+
+var _ = Describe("Namespaces", func() {
+	It("checks scopes", func() {
+		var all *Scope
+		Expect(all.Contains("a")).To(BeTrue())
+		Expect(NewScope().Contains("a")).To(BeTrue())
+
+		scope := NewScope("b", "a", "b")
+		Expect(scope.Namespaces()).To(Equal([]string{"a", "b"}))
+		Expect(scope.Contains("a")).To(BeTrue())
+		Expect(scope.Contains("c")).To(BeFalse())
+		Expect(scope.Watch()).To(Equal(""))
+		Expect(NewScope("a").Watch()).To(Equal("a"))
+	})
+
+	It("separates the keys of tenants", func() {
+		index := NewFullIndexer()
+		a := newTenantMachine("tenant-a", "m1", "aa:bb:cc:dd:ee:01")
+		b := newTenantMachine("tenant-b", "m1", "aa:bb:cc:dd:ee:01")
+		Expect(index.Set(a)).To(BeNil())
+		Expect(index.Set(b)).To(BeNil())
+		Expect(index.Conflicts(a.Name)).To(BeNil())
+
+		// ambiguous without namespace
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:01")).To(BeNil())
+		Expect(index.(ClaimIndex).ClaimsForMAC("aa:bb:cc:dd:ee:01")).To(Equal([]resources.ObjectName{a.Name, b.Name}))
+
+		view := MachineIndexForNamespace(index, "tenant-b")
+		Expect(view.GetByMAC("AA-BB-CC-DD-EE-01")).To(Equal(b))
+		Expect(view.GetByName(a.Name)).To(BeNil())
+		Expect(view.(ClaimIndex).ClaimsForMAC("aa:bb:cc:dd:ee:01")).To(Equal([]resources.ObjectName{b.Name}))
+		Expect(MachineIndexForNamespace(index, "tenant-c").GetByMAC("aa:bb:cc:dd:ee:01")).To(BeNil())
+	})
+
+	It("detects conflicts inside a tenant", func() {
+		index := NewFullIndexer()
+		m1 := newTenantMachine("tenant-a", "m1", "aa:bb:cc:dd:ee:01")
+		m2 := newTenantMachine("tenant-a", "m2", "aa:bb:cc:dd:ee:01")
+		index.Set(m1)
+		Expect(IsConflict(index.Set(m2))).To(BeTrue())
+		Expect(MachineIndexForNamespace(index, "tenant-a").GetByMAC("aa:bb:cc:dd:ee:01")).To(BeNil())
+	})
+
+	It("looks up machine types of a namespace", func() {
+		index := NewTypeFullIndexer()
+		t1 := newType("t1", "aa:bb:cc")
+		t2 := newType("t2", "aa:bb:cc:dd")
+		t2.Name = resources.NewObjectName("tenant-a", "t2")
+		index.Set(t1)
+		index.Set(t2)
+
+		Expect(index.GetByMAC("aa:bb:cc:dd:ee:01")).To(Equal(t2))
+		Expect(MachineTypeIndexForNamespace(index, "default").GetByMAC("aa:bb:cc:dd:ee:01")).To(Equal(t1))
+		Expect(MachineTypeIndexForNamespace(index, "tenant-a").GetByMAC("aa:bb:cc:dd:ee:01")).To(Equal(t2))
+
+		index.Delete(t2.Name)
+		Expect(MachineTypeIndexForNamespace(index, "tenant-a").GetByMAC("aa:bb:cc:dd:ee:01")).To(BeNil())
+	})
+})
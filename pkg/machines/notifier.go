@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"sync"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+const CHANGE_ADDED = "added"
+const CHANGE_UPDATED = "updated"
+const CHANGE_DELETED = "deleted"
+
+// IndexChange describes a modification of an index. The key lists
+// contain the keys of the old and the new version of the object.
+type IndexChange struct {
+	Type            string
+	Name            resources.ObjectName
+	ResourceVersion string
+	MACs            []string
+	UUIDs           []string
+	Prefixes        []string
+}
+
+// IndexListener is called for every change of an index.
+type IndexListener func(c *IndexChange)
+
+// IndexNotifier is implemented by indexers propagating their changes.
+type IndexNotifier interface {
+	AddListener(l IndexListener)
+}
+
+type listeners struct {
+	lock sync.RWMutex
+	list []IndexListener
+}
+
+func (this *listeners) AddListener(l IndexListener) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.list = append(this.list, l)
+}
+
+func (this *listeners) notify(c *IndexChange) {
+	if c == nil {
+		return
+	}
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	for _, l := range this.list {
+		l(c)
+	}
+}
+
+func newChange(old bool, name resources.ObjectName, version string) *IndexChange {
+	t := CHANGE_ADDED
+	if old {
+		t = CHANGE_UPDATED
+	}
+	return &IndexChange{Type: t, Name: name, ResourceVersion: version}
+}
+
+// addKeys adds the index keys of the given element (*Machine,
+// *BaseBoardManagementController or *MachineType) to the change.
+func (this *IndexChange) addKeys(elem interface{}) {
+	switch e := elem.(type) {
+	case *Machine:
+		this.addMACs(e.macs...)
+		this.addUUIDs(e.UUID)
+	case *BaseBoardManagementController:
+		this.addMACs(e.mac)
+		this.addUUIDs(e.UUID)
+	case *MachineType:
+		for _, p := range e.prefixes {
+			this.Prefixes = appendKeys(this.Prefixes, p.String())
+		}
+	}
+}
+
+func (this *IndexChange) addMACs(macs ...string) {
+	this.MACs = appendKeys(this.MACs, macs...)
+}
+
+func (this *IndexChange) addUUIDs(uuids ...string) {
+	this.UUIDs = appendKeys(this.UUIDs, uuids...)
+}
+
+func appendKeys(list []string, keys ...string) []string {
+outer:
+	for _, k := range keys {
+		if k == "" {
+			continue
+		}
+		for _, e := range list {
+			if e == k {
+				continue outer
+			}
+		}
+		list = append(list, k)
+	}
+	return list
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package query
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Error describes an invalid expression or query.
+type Error struct {
+	// Position is the offset of the error in the expression
+	// or -1 if not related to a dedicated position.
+	Position int
+	Message  string
+	// missing is set for evaluation errors caused by a missing map key
+	missing bool
+}
+
+func newError(pos int, msg string, args ...interface{}) *Error {
+	return &Error{Position: pos, Message: fmt.Sprintf(msg, args...)}
+}
+
+func (this *Error) Error() string {
+	if this.Position < 0 {
+		return this.Message
+	}
+	return fmt.Sprintf("%s (at position %d)", this.Message, this.Position)
+}
+
+// IsInvalid reports whether an error is caused by an invalid
+// expression or query.
+func IsInvalid(err error) bool {
+	_, ok := err.(*Error)
+	return ok
+}
+
+// IsMissing reports whether an evaluation error is caused by a missing
+// map key, for example an omitted optional field of a spec.
+func IsMissing(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.missing
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewEnv creates a CEL environment with the given variable declarations,
+// the string extensions and the function sum for numeric lists.
+func NewEnv(opts ...cel.EnvOption) (*cel.Env, error) {
+	opts = append([]cel.EnvOption{
+		ext.Strings(),
+		cel.Function("sum",
+			cel.Overload("sum_list", []*cel.Type{cel.ListType(cel.DynType)}, cel.DynType,
+				cel.UnaryBinding(sum),
+			),
+		),
+	}, opts...)
+	return cel.NewEnv(opts...)
+}
+
+func sum(v ref.Val) ref.Val {
+	l, ok := v.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(v)
+	}
+	var result ref.Val
+	for it := l.Iterator(); it.HasNext() == types.True; {
+		e := it.Next()
+		switch e.Type() {
+		case types.IntType, types.UintType, types.DoubleType:
+		default:
+			return types.NewErr("sum requires a list of numbers, but got %s", e.Type().TypeName())
+		}
+		if result == nil {
+			result = e
+		} else if result = result.(traits.Adder).Add(e); types.IsError(result) {
+			return result
+		}
+	}
+	if result == nil {
+		return types.Int(0)
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// COST_LIMIT limits the evaluation cost of an expression for
+// a single object.
+const COST_LIMIT = 1000000
+
+// INTERRUPT_CHECK_FREQUENCY is the number of comprehension iterations
+// after which the evaluation checks for a cancelled context.
+const INTERRUPT_CHECK_FREQUENCY = 100
+
+// Program is a compiled expression.
+type Program struct {
+	expression string
+	program    cel.Program
+}
+
+// Compile parses and checks an expression in the given environment.
+func Compile(env *cel.Env, expression string) (*Program, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, newError(-1, "empty expression")
+	}
+	src := common.NewTextSource(expression)
+	ast, issues := env.CompileSource(src)
+	if issues != nil && issues.Err() != nil {
+		e := issues.Errors()[0]
+		pos := -1
+		if o, ok := src.LocationOffset(e.Location); ok {
+			pos = int(o)
+		}
+		return nil, newError(pos, "%s", e.Message)
+	}
+	prg, err := env.Program(ast, cel.CostLimit(COST_LIMIT), cel.InterruptCheckFrequency(INTERRUPT_CHECK_FREQUENCY))
+	if err != nil {
+		return nil, newError(-1, "%s", err)
+	}
+	return &Program{expression: expression, program: prg}, nil
+}
+
+func (this *Program) String() string {
+	return this.expression
+}
+
+func (this *Program) eval(ctx context.Context, vars map[string]interface{}) (ref.Val, error) {
+	v, _, err := this.program.ContextEval(ctx, vars)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		msg := err.Error()
+		return nil, &Error{
+			Position: -1,
+			Message:  msg,
+			missing:  strings.HasPrefix(msg, "no such key") || strings.HasPrefix(msg, "no such attribute"),
+		}
+	}
+	return v, nil
+}
+
+// Eval evaluates the program for the given variable values and returns
+// the result as JSON compatible value. Evaluation errors, for example
+// for a missing map key (see IsMissing), are reported as errors.
+// The evaluation is aborted if the context is cancelled.
+func (this *Program) Eval(ctx context.Context, vars map[string]interface{}) (interface{}, error) {
+	v, err := this.eval(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+	n, err := v.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, newError(-1, "cannot convert %s result: %s", v.Type().TypeName(), err)
+	}
+	return n.(*structpb.Value).AsInterface(), nil
+}
+
+// Matches evaluates a filter program, which must yield a bool.
+func (this *Program) Matches(ctx context.Context, vars map[string]interface{}) (bool, error) {
+	v, err := this.eval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(types.Bool)
+	if !ok {
+		return false, newError(-1, "expression must evaluate to bool, but got %s", v.Type().TypeName())
+	}
+	return bool(b), nil
+}
+
+// Value converts an object into the representation used for
+// evaluation by a JSON round trip, so field names are the
+// JSON names of the object.
+func Value(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+
+func normalize(v interface{}) interface{} {
+	switch o := v.(type) {
+	case json.Number:
+		if i, err := o.Int64(); err == nil {
+			return i
+		}
+		f, _ := o.Float64()
+		return f
+	case []interface{}:
+		for i, e := range o {
+			o[i] = normalize(e)
+		}
+	case map[string]interface{}:
+		for k, e := range o {
+			o[k] = normalize(e)
+		}
+	}
+	return v
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Cache keeps the most recently used compiled programs.
+type Cache struct {
+	lock     sync.Mutex
+	max      int
+	env      *cel.Env
+	order    *list.List
+	programs map[string]*list.Element
+}
+
+func NewCache(max int, env *cel.Env) *Cache {
+	return &Cache{
+		max:      max,
+		env:      env,
+		order:    list.New(),
+		programs: map[string]*list.Element{},
+	}
+}
+
+// Compile returns the cached program for an expression or compiles
+// and caches it. Invalid expressions are not cached.
+func (this *Cache) Compile(expression string) (*Program, error) {
+	this.lock.Lock()
+	if e := this.programs[expression]; e != nil {
+		this.order.MoveToFront(e)
+		this.lock.Unlock()
+		return e.Value.(*Program), nil
+	}
+	this.lock.Unlock()
+
+	p, err := Compile(this.env, expression)
+	if err != nil {
+		return nil, err
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if e := this.programs[expression]; e != nil {
+		return e.Value.(*Program), nil
+	}
+	this.programs[expression] = this.order.PushFront(p)
+	for this.max > 0 && this.order.Len() > this.max {
+		last := this.order.Back()
+		this.order.Remove(last)
+		delete(this.programs, last.Value.(*Program).expression)
+	}
+	return p, nil
+}
+
+func (this *Cache) Len() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.order.Len()
+}
@@ -0,0 +1,319 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package query
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/google/cel-go/cel"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// Kinds of objects that can be queried.
+const (
+	KIND_MACHINE = "machine"
+	KIND_BMC     = "bmc"
+)
+
+// Variables available in query expressions.
+const (
+	// VAR_MACHINE is the MachineInfoSpec of a machine
+	// (empty for BMCs).
+	VAR_MACHINE = "machine"
+	// VAR_BMC is the spec of the BMC, or the BMC with the UUID of a
+	// queried machine (empty if none). Credentials are never included.
+	VAR_BMC = "bmc"
+	// VAR_TYPE is the name of the machine type of a machine
+	// (empty if none).
+	VAR_TYPE = "machinetype"
+	// VAR_LABELS are the labels of the queried object.
+	VAR_LABELS = "labels"
+	// VAR_NAME and VAR_NAMESPACE identify the queried object.
+	VAR_NAME      = "name"
+	VAR_NAMESPACE = "namespace"
+)
+
+// Env returns the CEL environment declaring the query variables.
+// Specs are maps keyed by the JSON field names.
+func Env() (*cel.Env, error) {
+	spec := cel.MapType(cel.StringType, cel.DynType)
+	return NewEnv(
+		cel.Variable(VAR_MACHINE, spec),
+		cel.Variable(VAR_BMC, spec),
+		cel.Variable(VAR_TYPE, cel.StringType),
+		cel.Variable(VAR_LABELS, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(VAR_NAME, cel.StringType),
+		cel.Variable(VAR_NAMESPACE, cel.StringType),
+	)
+}
+
+// empty are the values of the variables that can be hidden
+// in a request.
+var empty = map[string]interface{}{
+	VAR_MACHINE: map[string]interface{}{},
+	VAR_BMC:     map[string]interface{}{},
+	VAR_TYPE:    "",
+}
+
+const DEFAULT_LIMIT = 100
+const MAX_LIMIT = 1000
+
+// Request describes an inventory query. Filter is an expression
+// yielding a bool, Fields are expressions evaluated for the projection
+// of the matching objects. Continue is the token of a previous
+// response to request the next page. A non-empty Namespace restricts
+// the query to the objects of this namespace.
+type Request struct {
+	Kind      string
+	Namespace string
+	Filter    string
+	Fields    []string
+	Limit     int
+	Continue  string
+	// Hidden are the variables VAR_MACHINE, VAR_BMC or VAR_TYPE not
+	// exposed to the expressions, for example because the caller
+	// may not access the index providing them. They are always empty.
+	Hidden []string
+}
+
+// Executor executes inventory queries over indices
+// implementing machines.MachineLister or machines.BMCLister.
+type Executor struct {
+	machines machines.MachineIndex
+	bmcs     machines.BMCIndex
+	views    *machines.MachineViews
+	cache    *Cache
+}
+
+// NewExecutor creates a query executor caching at most cachesize
+// compiled expressions. The BMC and machine type indices are optional.
+func NewExecutor(machineindex machines.MachineIndex, bmcindex machines.BMCIndex, typeindex machines.MachineTypeIndex, cachesize int) (*Executor, error) {
+	env, err := Env()
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{
+		machines: machineindex,
+		bmcs:     bmcindex,
+		views:    machines.NewMachineViews(machineindex, bmcindex, typeindex, nil),
+		cache:    NewCache(cachesize, env),
+	}, nil
+}
+
+func (this *Executor) IsInitialized() bool {
+	return this.views.IsInitialized()
+}
+
+// Execute runs a query. Objects for which the filter refers to a
+// missing field, for example an omitted empty list, do not match, and
+// fields referring to a missing field are null. Invalid requests and
+// expressions that cannot be evaluated otherwise are reported by errors
+// for which IsInvalid is true. The query is aborted if the context is
+// cancelled.
+func (this *Executor) Execute(ctx context.Context, req *Request) (*machines.QueryResponse, error) {
+	limit := req.Limit
+	switch {
+	case limit < 0:
+		return nil, newError(-1, "invalid limit %d", limit)
+	case limit == 0:
+		limit = DEFAULT_LIMIT
+	case limit > MAX_LIMIT:
+		limit = MAX_LIMIT
+	}
+	after := ""
+	if req.Continue != "" {
+		data, err := base64.RawURLEncoding.DecodeString(req.Continue)
+		if err != nil {
+			return nil, newError(-1, "invalid continue token")
+		}
+		after = string(data)
+	}
+	filter, err := this.cache.Compile(req.Filter)
+	if err != nil {
+		return nil, prefix(err, "filter")
+	}
+	for _, h := range req.Hidden {
+		if _, ok := empty[h]; !ok {
+			return nil, newError(-1, "variable %q cannot be hidden", h)
+		}
+	}
+	fields := make([]*Program, len(req.Fields))
+	for i, f := range req.Fields {
+		if fields[i], err = this.cache.Compile(f); err != nil {
+			return nil, prefix(err, fmt.Sprintf("field %q", f))
+		}
+	}
+
+	var objects []object
+	switch req.Kind {
+	case KIND_MACHINE, "":
+		l, ok := this.machines.(machines.MachineLister)
+		if !ok {
+			return nil, fmt.Errorf("machine index does not support queries")
+		}
+		for _, m := range l.List() {
+			objects = append(objects, &machineObject{m, this.views})
+		}
+	case KIND_BMC:
+		l, ok := this.bmcs.(machines.BMCLister)
+		if !ok {
+			return nil, fmt.Errorf("bmc index does not support queries")
+		}
+		for _, b := range l.List() {
+			objects = append(objects, &bmcObject{b})
+		}
+	default:
+		return nil, newError(-1, "unknown kind %q", req.Kind)
+	}
+
+	resp := &machines.QueryResponse{Items: []*machines.QueryItem{}}
+	var last resources.ObjectName
+	for _, o := range objects {
+		name := o.name()
+		if name.String() <= after {
+			continue
+		}
+		if req.Namespace != "" && name.Namespace() != req.Namespace {
+			continue
+		}
+		vars, err := o.variables()
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range req.Hidden {
+			vars[h] = empty[h]
+		}
+		ok, err := filter.Matches(ctx, vars)
+		if err != nil && !IsMissing(err) {
+			return nil, prefix(err, fmt.Sprintf("filter for %s", name))
+		}
+		if !ok {
+			continue
+		}
+		if len(resp.Items) == limit {
+			resp.Continue = base64.RawURLEncoding.EncodeToString([]byte(last.String()))
+			break
+		}
+		last = name
+		item := &machines.QueryItem{Cluster: machines.ClusterOf(name), Name: name.Name(), Namespace: name.Namespace()}
+		for i, f := range fields {
+			v, err := f.Eval(ctx, vars)
+			if err != nil && !IsMissing(err) {
+				return nil, prefix(err, fmt.Sprintf("field %q for %s", req.Fields[i], name))
+			}
+			if item.Fields == nil {
+				item.Fields = map[string]interface{}{}
+			}
+			item.Fields[req.Fields[i]] = v
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp, nil
+}
+
+func prefix(err error, what string) error {
+	if e, ok := err.(*Error); ok {
+		return &Error{Position: e.Position, Message: "invalid " + what + ": " + e.Message}
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type object interface {
+	name() resources.ObjectName
+	variables() (map[string]interface{}, error)
+}
+
+type machineObject struct {
+	machine *machines.Machine
+	views   *machines.MachineViews
+}
+
+func (this *machineObject) name() resources.ObjectName {
+	return this.machine.Name
+}
+
+func (this *machineObject) variables() (map[string]interface{}, error) {
+	v := this.views.GetByName(this.machine.Name)
+	if v == nil {
+		v = &machines.MachineView{Machine: this.machine}
+	}
+	vars := values(this.machine.Name, this.machine.Labels())
+	spec, err := Value(this.machine.MachineInfoSpec)
+	if err != nil {
+		return nil, err
+	}
+	vars[VAR_MACHINE] = spec
+	vars[VAR_BMC] = map[string]interface{}{}
+	if v.BMC != nil {
+		if vars[VAR_BMC], err = bmcValue(v.BMC); err != nil {
+			return nil, err
+		}
+	}
+	vars[VAR_TYPE] = ""
+	if v.Type != nil {
+		vars[VAR_TYPE] = v.Type.Name.Name()
+	}
+	return vars, nil
+}
+
+type bmcObject struct {
+	bmc *machines.BaseBoardManagementController
+}
+
+func (this *bmcObject) name() resources.ObjectName {
+	return this.bmc.Name
+}
+
+func (this *bmcObject) variables() (map[string]interface{}, error) {
+	vars := values(this.bmc.Name, this.bmc.Labels())
+	spec, err := bmcValue(this.bmc)
+	if err != nil {
+		return nil, err
+	}
+	vars[VAR_BMC] = spec
+	vars[VAR_MACHINE] = map[string]interface{}{}
+	vars[VAR_TYPE] = ""
+	return vars, nil
+}
+
+func values(name resources.ObjectName, labels map[string]string) map[string]interface{} {
+	l := map[string]string{}
+	for k, v := range labels {
+		l[k] = v
+	}
+	return map[string]interface{}{
+		VAR_NAME:      name.Name(),
+		VAR_NAMESPACE: name.Namespace(),
+		VAR_LABELS:    l,
+	}
+}
+
+func bmcValue(b *machines.BaseBoardManagementController) (interface{}, error) {
+	return Value(machines.RedactBMC(b).BaseBoardManagementControllerInfoSpec)
+}
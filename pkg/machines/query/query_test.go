@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+func newMachine(name string, cores int, memory int, disk string, labels map[string]string) *machines.Machine {
+	info := &api.MachineInfo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+		Spec: api.MachineInfoSpec{
+			UUID:   name,
+			NICs:   []api.NIC{{Name: "eth0", MAC: "00:11:22:33:44:" + name[len(name)-2:]}},
+			CPUs:   []api.CPU{{Cores: cores / 2}, {Cores: cores / 2}},
+			Memory: []api.Memory{{Size: memory}},
+		},
+	}
+	if disk != "" {
+		info.Spec.Disks = []api.Disk{{Id: name, Type: disk}}
+	}
+	m, err := machines.NewMachine(info)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+var _ = Describe("Expressions", func() {
+	env, err := NewEnv(cel.Variable("x", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		panic(err)
+	}
+
+	eval := func(expr string, vars map[string]interface{}) interface{} {
+		p, err := Compile(env, expr)
+		Expect(err).To(BeNil())
+		v, err := p.Eval(context.Background(), vars)
+		Expect(err).To(BeNil())
+		return v
+	}
+
+	It("evaluates operators and macros", func() {
+		x, _ := Value(map[string]interface{}{"a": []int{1, 2, 3}, "s": "Hello", "f": 1.5})
+		vars := map[string]interface{}{"x": x}
+		Expect(eval("1 + 2 * 3 == 7 && !(1 > 2)", vars)).To(Equal(true))
+		Expect(eval("sum(x.a) >= 6 && size(x.a) == 3", vars)).To(Equal(true))
+		Expect(eval("x.a.exists(e, e == 2) && x.a.all(e, e > 0)", vars)).To(Equal(true))
+		Expect(eval("x.a.filter(e, e % 2 == 1).map(e, e * 10)", vars)).To(Equal([]interface{}{float64(10), float64(30)}))
+		Expect(eval("x.s.lowerAscii().startsWith('he') ? x.f * 2.0 : 0.0", vars)).To(Equal(float64(3)))
+		Expect(eval(`has(x.s) && !has(x.missing) && "a" in x && 2 in x.a`, vars)).To(Equal(true))
+		Expect(eval(`x.missing == 1 || true`, vars)).To(Equal(true))
+		Expect(eval(`x.s.matches("^H.*o$")`, vars)).To(Equal(true))
+		Expect(eval(`sum([]) == 0 && sum([1.5, 2.5]) == 4.0`, vars)).To(Equal(true))
+	})
+
+	It("rejects invalid expressions", func() {
+		for expr, msg := range map[string]string{
+			"1 +":               "Syntax error: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER} (at position 3)",
+			"y == 1":            "undeclared reference to 'y' (in container '') (at position 0)",
+			"x.foo(1)":          "undeclared reference to 'foo' (in container '') (at position 5)",
+			"size(x, 1)":        "found no matching overload for 'size' applied to '(map(string, dyn), int)' (at position 4)",
+			"'abc":              "Syntax error: token recognition error at: ''abc' (at position 0)",
+			"x.exists(1, true)": "argument must be a simple name (at position 9)",
+		} {
+			_, err := Compile(env, expr)
+			Expect(IsInvalid(err)).To(BeTrue(), expr)
+			Expect(err.Error()).To(Equal(msg), expr)
+		}
+	})
+
+	It("reports evaluation errors", func() {
+		x, _ := Value(map[string]interface{}{"s": "Hello"})
+		for expr, msg := range map[string]string{
+			"x.missing == 1":   "no such key: missing",
+			"x.s.matches('(')": "error parsing regexp: missing closing ): `(`",
+			"sum(['a']) == 1":  "sum requires a list of numbers, but got string",
+		} {
+			p, err := Compile(env, expr)
+			Expect(err).To(BeNil(), expr)
+			_, err = p.Eval(context.Background(), map[string]interface{}{"x": x})
+			Expect(IsInvalid(err)).To(BeTrue(), expr)
+			Expect(IsMissing(err)).To(Equal(expr == "x.missing == 1"), expr)
+			Expect(err.Error()).To(Equal(msg), expr)
+		}
+	})
+
+	It("limits the evaluation", func() {
+		a := make([]int, 200)
+		x, _ := Value(map[string]interface{}{"a": a})
+		vars := map[string]interface{}{"x": x}
+
+		p, err := Compile(env, "x.a.all(i, x.a.all(j, x.a.all(k, true)))")
+		Expect(err).To(BeNil())
+		_, err = p.Eval(context.Background(), vars)
+		Expect(IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("cost limit exceeded"))
+
+		p, err = Compile(env, "x.a.all(i, true)")
+		Expect(err).To(BeNil())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = p.Eval(ctx, vars)
+		Expect(err).To(Equal(context.Canceled))
+	})
+
+	It("caches compiled programs", func() {
+		cache := NewCache(2, env)
+		p, _ := cache.Compile("x == {}")
+		Expect(cache.Compile("x == {}")).To(BeIdenticalTo(p))
+		cache.Compile("size(x) == 1")
+		cache.Compile("size(x) == 2")
+		Expect(cache.Len()).To(Equal(2))
+		_, err := cache.Compile("x ==")
+		Expect(err).NotTo(BeNil())
+		Expect(cache.Len()).To(Equal(2))
+	})
+})
+
+var _ = Describe("Executor", func() {
+	var executor *Executor
+	var index machines.MachineIndexer
+
+	BeforeEach(func() {
+		index = machines.NewFullIndexer()
+		for i := 1; i <= 5; i++ {
+			disk := "hdd"
+			if i%2 == 1 {
+				disk = "nvme"
+			}
+			index.Set(newMachine(fmt.Sprintf("m%02d", i), 32*i, 256*i, disk, map[string]string{"rack": fmt.Sprintf("r%d", i%2)}))
+		}
+		types := machines.NewTypeFullIndexer()
+		t, _ := machines.NewMachineType(&api.MachineType{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "big"},
+			Spec:       api.MachineTypeSpec{MACPrefixes: []string{"00:11:22/24"}},
+		})
+		types.Set(t)
+		var err error
+		executor, err = NewExecutor(index, machines.NewBMCFullIndexer(), types, 10)
+		Expect(err).To(BeNil())
+	})
+
+	It("filters, projects and paginates", func() {
+		req := &Request{
+			Filter: `machinetype == "big" && sum(machine.cpus.map(c, c.cores)) >= 64 && ` +
+				`sum(machine.memory.map(m, m.size)) >= 512 && machine.disks.exists(d, d.type == "nvme")`,
+			Fields: []string{"labels.rack", "machine.disks[0].id"},
+			Limit:  1,
+		}
+		resp, err := executor.Execute(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(resp.Items).To(Equal([]*machines.QueryItem{
+			{Name: "m03", Namespace: "default", Fields: map[string]interface{}{"labels.rack": "r1", "machine.disks[0].id": "m03"}},
+		}))
+		Expect(resp.Continue).NotTo(BeEmpty())
+
+		req.Continue = resp.Continue
+		resp, err = executor.Execute(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(len(resp.Items)).To(Equal(1))
+		Expect(resp.Items[0].Name).To(Equal("m05"))
+		Expect(resp.Continue).To(BeEmpty())
+	})
+
+	It("rejects invalid queries", func() {
+		_, err := executor.Execute(context.Background(), &Request{Filter: "machine.cpus"})
+		Expect(IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(Equal("invalid filter for default/m01: expression must evaluate to bool, but got list"))
+
+		_, err = executor.Execute(context.Background(), &Request{Filter: "true", Kind: "disk"})
+		Expect(IsInvalid(err)).To(BeTrue())
+	})
+
+	It("treats missing fields as not matching", func() {
+		index.Set(newMachine("m06", 32, 256, "", map[string]string{"rack": "r0"}))
+		index.Set(newMachine("m07", 32, 256, "nvme", map[string]string{"rack": "r0"}))
+
+		resp, err := executor.Execute(context.Background(), &Request{
+			Filter: `machine.disks.exists(d, d.type == "nvme") && labels.rack != "r1"`,
+			Fields: []string{"machine.disks[0].id"},
+		})
+		Expect(err).To(BeNil())
+		Expect(resp.Items).To(Equal([]*machines.QueryItem{
+			{Name: "m07", Namespace: "default", Fields: map[string]interface{}{"machine.disks[0].id": "m07"}},
+		}))
+
+		resp, err = executor.Execute(context.Background(), &Request{Filter: `name == "m06"`, Fields: []string{"machine.disks[0].id", "bmc.address"}})
+		Expect(err).To(BeNil())
+		Expect(resp.Items).To(Equal([]*machines.QueryItem{
+			{Name: "m06", Namespace: "default", Fields: map[string]interface{}{"machine.disks[0].id": nil, "bmc.address": nil}},
+		}))
+	})
+
+	It("restricts queries to a namespace", func() {
+		resp, err := executor.Execute(context.Background(), &Request{Filter: "true", Namespace: "default"})
+		Expect(err).To(BeNil())
+		Expect(len(resp.Items)).To(Equal(5))
+
+		resp, err = executor.Execute(context.Background(), &Request{Filter: "true", Namespace: "other"})
+		Expect(err).To(BeNil())
+		Expect(resp.Items).To(BeEmpty())
+	})
+})
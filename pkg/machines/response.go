@@ -22,8 +22,198 @@
 
 package machines
 
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+// QUERY_VIEW is the query parameter used to select the response mode
+// of the index server.
+const QUERY_VIEW = "view"
+
+// VIEW_FULL requests the indexed object spec in addition to the object name.
+const VIEW_FULL = "full"
+
+// QUERY_KEY is the query parameter used to look up secondary indices.
+const QUERY_KEY = "key"
+
+const REASON_NOT_FOUND = "NotFound"
+const REASON_AMBIGUOUS = "Ambiguous"
+const REASON_NOT_INITIALIZED = "NotInitialized"
+const REASON_BAD_REQUEST = "BadRequest"
+const REASON_UNAUTHORIZED = "Unauthorized"
+const REASON_FORBIDDEN = "Forbidden"
+const REASON_INTERNAL = "Internal"
+const REASON_INVALID_QUERY = "InvalidQuery"
+const REASON_UNAVAILABLE = "Unavailable"
+
 type IndexResponse struct {
-	Name      string `json:"name,omitempty"`
+	// Cluster is the identity of the additional cluster hosting
+	// the object. It is empty for objects of the main cluster.
+	Cluster         string `json:"cluster,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Error           string `json:"error,omitempty"`
+	// Reason classifies the error
+	Reason string `json:"reason,omitempty"`
+	// Conflicts lists the matching keys for ambiguous queries
+	Conflicts []*KeyMatch `json:"conflicts,omitempty"`
+}
+
+// KeyMatch describes the object found for a dedicated key.
+type KeyMatch struct {
+	Kind      string `json:"kind"`
+	Key       string `json:"key"`
+	Cluster   string `json:"cluster,omitempty"`
+	Name      string `json:"name"`
 	Namespace string `json:"namespace,omitempty"`
-	Error     string `json:"error,omitempty"` // not yet used
+}
+
+func NewErrorResponse(reason string, msg string, conflicts ...*KeyMatch) *IndexResponse {
+	return &IndexResponse{
+		Error:     msg,
+		Reason:    reason,
+		Conflicts: conflicts,
+	}
+}
+
+func NewIndexResponse(name resources.ObjectName, version string) IndexResponse {
+	return IndexResponse{
+		Cluster:         ClusterOf(name),
+		Name:            name.Name(),
+		Namespace:       name.Namespace(),
+		ResourceVersion: version,
+	}
+}
+
+type MachineInfoResponse struct {
+	IndexResponse
+	Spec *api.MachineInfoSpec `json:"spec,omitempty"`
+}
+
+type BMCInfoResponse struct {
+	IndexResponse
+	Spec *api.BaseBoardManagementControllerInfoSpec `json:"spec,omitempty"`
+}
+
+type MachineTypeResponse struct {
+	IndexResponse
+	Spec *api.MachineTypeSpec `json:"spec,omitempty"`
+}
+
+type LeaseResponse struct {
+	IndexResponse
+	Spec *api.DHCPLeaseSpec `json:"spec,omitempty"`
+}
+
+// MachineViewResponse is the aggregated document for a machine.
+// Leases are keyed by NIC name.
+type MachineViewResponse struct {
+	Machine *MachineInfoResponse      `json:"machine"`
+	BMC     *BMCInfoResponse          `json:"bmc,omitempty"`
+	Type    *MachineTypeResponse      `json:"type,omitempty"`
+	Leases  map[string]*LeaseResponse `json:"leases,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Inventory Query
+
+// Query parameters of the query endpoint.
+const (
+	QUERY_KIND     = "kind"
+	QUERY_FILTER   = "filter"
+	QUERY_FIELDS   = "fields"
+	QUERY_LIMIT    = "limit"
+	QUERY_CONTINUE = "continue"
+)
+
+// QueryItem describes an object matching a query. Fields contains
+// the values of the requested field expressions.
+type QueryItem struct {
+	Cluster   string                 `json:"cluster,omitempty"`
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// QueryResponse is a page of a query result. If Continue is set,
+// more results can be requested by passing it as continue
+// parameter.
+type QueryResponse struct {
+	Items    []*QueryItem `json:"items"`
+	Continue string       `json:"continue,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Batch Lookup
+
+const STATUS_FOUND = "found"
+const STATUS_NOTFOUND = "notfound"
+const STATUS_AMBIGUOUS = "ambiguous"
+
+// BatchKeys lists the keys to look up in a dedicated index.
+type BatchKeys struct {
+	MACs  []string `json:"macs,omitempty"`
+	UUIDs []string `json:"uuids,omitempty"`
+}
+
+// BatchRequest maps index paths (for example "info") to the keys to look up.
+type BatchRequest map[string]*BatchKeys
+
+// MAX_BATCH_KEYS is the maximum number of keys of all indices
+// accepted in a single batch request.
+const MAX_BATCH_KEYS = 1000
+
+// MAX_BATCH_SIZE is the maximum size of a batch request body in bytes.
+const MAX_BATCH_SIZE = 1 << 20
+
+type BatchResult struct {
+	Status string `json:"status"`
+	IndexResponse
+}
+
+func NewBatchResult(name resources.ObjectName, version string) *BatchResult {
+	if name == nil {
+		return &BatchResult{Status: STATUS_NOTFOUND}
+	}
+	return &BatchResult{Status: STATUS_FOUND, IndexResponse: NewIndexResponse(name, version)}
+}
+
+// BatchKeyResults maps every requested key to its lookup result.
+type BatchKeyResults struct {
+	MACs  map[string]*BatchResult `json:"macs,omitempty"`
+	UUIDs map[string]*BatchResult `json:"uuids,omitempty"`
+}
+
+// BatchResponse maps index paths to the results for the requested keys.
+type BatchResponse map[string]*BatchKeyResults
+
+////////////////////////////////////////////////////////////////////////////////
+// Change Feed
+
+// QUERY_SINCE is the query parameter used to resume a watch after a
+// given sequence number.
+const QUERY_SINCE = "since"
+
+// QUERY_INDEX restricts a watch to the given index paths.
+const QUERY_INDEX = "index"
+
+// EVENT_RESET is sent if the requested sequence number is not available
+// anymore. Clients must discard all cached information.
+const EVENT_RESET = "reset"
+
+// IndexEvent is a single entry of the change feed of the index server.
+type IndexEvent struct {
+	Sequence        int64    `json:"sequence"`
+	Index           string   `json:"index,omitempty"`
+	Type            string   `json:"type"`
+	Cluster         string   `json:"cluster,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	Namespace       string   `json:"namespace,omitempty"`
+	ResourceVersion string   `json:"resourceVersion,omitempty"`
+	MACs            []string `json:"macs,omitempty"`
+	UUIDs           []string `json:"uuids,omitempty"`
+	Prefixes        []string `json:"prefixes,omitempty"`
 }
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// QUERY_NAMESPACE is the query parameter used to restrict lookups
+// to the objects of a namespace.
+const QUERY_NAMESPACE = "namespace"
+
+// Scope is the set of namespaces handled by an indexer. A nil scope
+// or a scope without namespaces covers all namespaces.
+type Scope struct {
+	namespaces []string
+}
+
+func NewScope(namespaces ...string) *Scope {
+	set := map[string]struct{}{}
+	for _, n := range namespaces {
+		set[n] = struct{}{}
+	}
+	list := make([]string, 0, len(set))
+	for n := range set {
+		list = append(list, n)
+	}
+	sort.Strings(list)
+	return &Scope{namespaces: list}
+}
+
+// Namespaces returns the namespaces of a restricted scope.
+func (this *Scope) Namespaces() []string {
+	if this == nil {
+		return nil
+	}
+	return this.namespaces
+}
+
+// Contains reports whether a namespace belongs to the scope.
+func (this *Scope) Contains(namespace string) bool {
+	if this == nil || len(this.namespaces) == 0 {
+		return true
+	}
+	i := sort.SearchStrings(this.namespaces, namespace)
+	return i < len(this.namespaces) && this.namespaces[i] == namespace
+}
+
+// Watch returns the namespace to watch for a scope with a single
+// namespace. Otherwise all namespaces have to be watched.
+func (this *Scope) Watch() string {
+	if this == nil || len(this.namespaces) != 1 {
+		return ""
+	}
+	return this.namespaces[0]
+}
+
+// ListCached lists the cached objects of a resource in the scope.
+func (this *Scope) ListCached(resc resources.Interface) ([]resources.Object, error) {
+	if ns := this.Watch(); ns != "" {
+		return resc.Namespace(ns).ListCached(labels.Everything())
+	}
+	list, err := resc.ListCached(labels.Everything())
+	if err != nil || len(this.Namespaces()) == 0 {
+		return list, err
+	}
+	result := list[:0]
+	for _, o := range list {
+		if this.Contains(o.GetNamespace()) {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+func (this *Scope) String() string {
+	if len(this.Namespaces()) == 0 {
+		return "all namespaces"
+	}
+	return strings.Join(this.namespaces, ", ")
+}
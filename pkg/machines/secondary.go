@@ -0,0 +1,284 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+// SecondaryIndexSpec declares a secondary index over inventory objects
+// of a dedicated kind (MachineInfo or BaseBoardManagementControllerInfo).
+// The index name is used as path on the index server.
+type SecondaryIndexSpec struct {
+	Name string
+	Kind schema.GroupKind
+	// Keys extracts the index keys from an object
+	Keys func(obj resources.ObjectData) []string
+	// Normalize optionally maps extracted and requested keys
+	// to a canonical form
+	Normalize func(key string) string
+}
+
+func (this *SecondaryIndexSpec) normalize(key string) string {
+	if this.Normalize != nil {
+		return this.Normalize(key)
+	}
+	return key
+}
+
+var reserved = func() map[string]bool {
+	r := map[string]bool{"metrics": true, "healthz": true}
+	for _, p := range ServerPaths() {
+		r[p] = true
+	}
+	return r
+}()
+
+var speclock sync.Mutex
+var specs = map[string]*SecondaryIndexSpec{}
+
+// RegisterSecondaryIndex registers a secondary index declaration. Registered
+// indices are maintained by the controllers for the declared kind and
+// exposed by the index server. It must be called during initialization.
+func RegisterSecondaryIndex(spec *SecondaryIndexSpec) {
+	speclock.Lock()
+	defer speclock.Unlock()
+
+	if spec.Name == "" || spec.Keys == nil {
+		panic("secondary index requires name and key function")
+	}
+	if reserved[spec.Name] {
+		panic(fmt.Sprintf("secondary index name %q is reserved", spec.Name))
+	}
+	if spec.Kind != api.MACHINEINFO && spec.Kind != api.BASEBOARDMANAGEMENTCONTROLLERINFO {
+		panic(fmt.Sprintf("unsupported kind %s for secondary index %q", spec.Kind, spec.Name))
+	}
+	if specs[spec.Name] != nil {
+		panic(fmt.Sprintf("secondary index %q already registered", spec.Name))
+	}
+	specs[spec.Name] = spec
+}
+
+// SecondaryIndexSpecs returns the registered secondary index declarations
+// ordered by name.
+func SecondaryIndexSpecs() []*SecondaryIndexSpec {
+	speclock.Lock()
+	defer speclock.Unlock()
+
+	result := make([]*SecondaryIndexSpec, 0, len(specs))
+	for _, s := range specs {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SecondaryIndex maps the keys of a secondary index to the names
+// of the objects claiming them.
+type SecondaryIndex struct {
+	spec        *SecondaryIndexSpec
+	lock        sync.RWMutex
+	initialized int32
+	lastSync    time.Time
+	keys        claims
+	elements    map[resources.ObjectName][]string
+}
+
+var _ IndexStatistics = &SecondaryIndex{}
+
+func NewSecondaryIndex(spec *SecondaryIndexSpec) *SecondaryIndex {
+	return &SecondaryIndex{
+		spec:     spec,
+		keys:     claims{},
+		elements: map[resources.ObjectName][]string{},
+	}
+}
+
+func (this *SecondaryIndex) Spec() *SecondaryIndexSpec {
+	return this.spec
+}
+
+func (this *SecondaryIndex) IsInitialized() bool {
+	return atomic.LoadInt32(&this.initialized) != 0
+}
+
+func (this *SecondaryIndex) Size() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return len(this.elements)
+}
+
+func (this *SecondaryIndex) LastSync() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.lastSync
+}
+
+// Lookup returns the names of all objects claiming a key.
+func (this *SecondaryIndex) Lookup(key string) []resources.ObjectName {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.keys.claimants(this.spec.normalize(key))
+}
+
+// Set updates the keys of an object.
+func (this *SecondaryIndex) Set(obj resources.ObjectData) {
+	name := resources.NewObjectName(obj.GetNamespace(), obj.GetName())
+	keys := []string{}
+	for _, k := range this.spec.Keys(obj) {
+		keys = appendKeys(keys, this.spec.normalize(k))
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.cleanup(name)
+	for _, k := range keys {
+		this.keys.add(k, name)
+	}
+	this.elements[name] = keys
+	this.lastSync = time.Now()
+	indexElements.WithLabelValues(this.spec.Name).Set(float64(len(this.elements)))
+}
+
+func (this *SecondaryIndex) Delete(name resources.ObjectName) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.cleanup(name)
+	this.lastSync = time.Now()
+	indexElements.WithLabelValues(this.spec.Name).Set(float64(len(this.elements)))
+}
+
+func (this *SecondaryIndex) cleanup(name resources.ObjectName) {
+	for _, k := range this.elements[name] {
+		this.keys.remove(k, name)
+	}
+	delete(this.elements, name)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SecondaryIndices is the set of all registered secondary indices
+// shared by the controllers maintaining them and the index server.
+type SecondaryIndices struct {
+	indices map[string]*SecondaryIndex
+}
+
+func NewSecondaryIndices() *SecondaryIndices {
+	this := &SecondaryIndices{indices: map[string]*SecondaryIndex{}}
+	for _, s := range SecondaryIndexSpecs() {
+		this.indices[s.Name] = NewSecondaryIndex(s)
+	}
+	return this
+}
+
+// Get returns the secondary index with the given name or nil.
+func (this *SecondaryIndices) Get(name string) *SecondaryIndex {
+	return this.indices[name]
+}
+
+// List returns the secondary indices ordered by name.
+func (this *SecondaryIndices) List() []*SecondaryIndex {
+	result := make([]*SecondaryIndex, 0, len(this.indices))
+	for _, i := range this.indices {
+		result = append(result, i)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].spec.Name < result[j].spec.Name })
+	return result
+}
+
+// Setup initially fills all secondary indices for the given kind
+// with the objects of a scope.
+func (this *SecondaryIndices) Setup(logger logger.LogContext, cluster cluster.Interface, kind schema.GroupKind, scope *Scope) error {
+	var list []resources.Object
+	for _, i := range this.indices {
+		if i.spec.Kind != kind || i.IsInitialized() {
+			continue
+		}
+		if list == nil {
+			resc, err := cluster.Resources().Get(kind)
+			if err != nil {
+				return err
+			}
+			list, _ = scope.ListCached(resc)
+		}
+		logger.Infof("setup secondary index %s", i.spec.Name)
+		for _, o := range list {
+			i.Set(o.Data())
+		}
+		atomic.StoreInt32(&i.initialized, 1)
+	}
+	return nil
+}
+
+// Set updates the keys of an object in all secondary indices for its kind.
+func (this *SecondaryIndices) Set(kind schema.GroupKind, obj resources.ObjectData) {
+	for _, i := range this.indices {
+		if i.spec.Kind == kind {
+			i.Set(obj)
+		}
+	}
+}
+
+// Delete removes an object from all secondary indices for its kind.
+func (this *SecondaryIndices) Delete(kind schema.GroupKind, name resources.ObjectName) {
+	for _, i := range this.indices {
+		if i.spec.Kind == kind {
+			i.Delete(name)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// predefined secondary indices
+
+const PATH_DISK = "disk"
+
+func init() {
+	RegisterSecondaryIndex(&SecondaryIndexSpec{
+		Name: PATH_DISK,
+		Kind: api.MACHINEINFO,
+		Keys: func(obj resources.ObjectData) []string {
+			var keys []string
+			for _, d := range obj.(*api.MachineInfo).Spec.Disks {
+				keys = append(keys, d.Id)
+			}
+			return keys
+		},
+	})
+}
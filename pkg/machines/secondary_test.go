@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func withDisks(m *api.MachineInfo, ids ...string) *api.MachineInfo {
+	for _, id := range ids {
+		m.Spec.Disks = append(m.Spec.Disks, api.Disk{Id: id})
+	}
+	return m
+}
+
+var _ = Describe("Secondary Indices", func() {
+	It("provides the predefined disk index", func() {
+		indices := NewSecondaryIndices()
+		m1 := withDisks(newMachineInfo("m1"), "d1", "d2")
+		m2 := withDisks(newMachineInfo("m2"), "d3")
+		indices.Set(api.MACHINEINFO, m1)
+		indices.Set(api.MACHINEINFO, m2)
+
+		disk := indices.Get(PATH_DISK)
+		Expect(disk).NotTo(BeNil())
+		Expect(disk.Lookup("d2")).To(Equal([]resources.ObjectName{resources.NewObjectName("default", "m1")}))
+		Expect(disk.Lookup("d4")).To(BeEmpty())
+
+		indices.Set(api.MACHINEINFO, withDisks(newMachineInfo("m1"), "d1"))
+		Expect(disk.Lookup("d2")).To(BeEmpty())
+		indices.Delete(api.MACHINEINFO, resources.NewObjectName("default", "m2"))
+		Expect(disk.Lookup("d3")).To(BeEmpty())
+		Expect(disk.Size()).To(Equal(1))
+	})
+
+	It("normalizes keys and tracks all claimants", func() {
+		index := NewSecondaryIndex(&SecondaryIndexSpec{
+			Name:      "test",
+			Kind:      api.MACHINEINFO,
+			Keys:      func(obj resources.ObjectData) []string { return []string{obj.(*api.MachineInfo).Spec.UUID} },
+			Normalize: strings.ToLower,
+		})
+		m1 := newMachineInfo("m1")
+		m1.Spec.UUID = "ABC"
+		m2 := newMachineInfo("m2")
+		m2.Spec.UUID = "abc"
+		index.Set(m1)
+		index.Set(m2)
+		Expect(index.Lookup("Abc")).To(HaveLen(2))
+	})
+
+	It("rejects reserved names", func() {
+		for _, name := range ServerPaths() {
+			Expect(func() {
+				RegisterSecondaryIndex(&SecondaryIndexSpec{
+					Name: name,
+					Kind: api.MACHINEINFO,
+					Keys: func(obj resources.ObjectData) []string { return nil },
+				})
+			}).To(Panic(), name)
+		}
+	})
+})
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SNAPSHOT_VERSION is the version of the snapshot file format.
+const SNAPSHOT_VERSION = 1
+
+// HEADER_STALE is set by the index server for responses served
+// by an index warm started from a snapshot, which has not yet been
+// reconciled with the cluster.
+const HEADER_STALE = "X-Index-Stale"
+
+type snapshotFile struct {
+	Version int             `json:"version"`
+	Kind    string          `json:"kind"`
+	Created time.Time       `json:"created"`
+	Objects json.RawMessage `json:"objects"`
+}
+
+// writeSnapshot atomically replaces the snapshot file by a new one
+// containing the given objects.
+func writeSnapshot(path string, kind string, objects interface{}) error {
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(&snapshotFile{
+		Version: SNAPSHOT_VERSION,
+		Kind:    kind,
+		Created: time.Now(),
+		Objects: data,
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// readSnapshot reads the objects of a snapshot file and returns the
+// creation time of the snapshot.
+func readSnapshot(path string, kind string, objects interface{}) (time.Time, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	s := &snapshotFile{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot %q: %s", path, err)
+	}
+	if s.Version != SNAPSHOT_VERSION {
+		return time.Time{}, fmt.Errorf("unsupported snapshot version %d in %q", s.Version, path)
+	}
+	if s.Kind != kind {
+		return time.Time{}, fmt.Errorf("snapshot %q contains %s instead of %s", path, s.Kind, kind)
+	}
+	if err := json.Unmarshal(s.Objects, objects); err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot %q: %s", path, err)
+	}
+	return s.Created, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// snapshotState tracks whether an index is served from a snapshot
+// and when it became consistent with the cluster.
+type snapshotState struct {
+	lock       sync.RWMutex
+	stale      bool
+	created    time.Time
+	consistent time.Time
+}
+
+func (this *snapshotState) IsStale() bool {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.stale
+}
+
+func (this *snapshotState) SnapshotTime() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.created
+}
+
+func (this *snapshotState) ConsistentSince() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.consistent
+}
+
+func (this *snapshotState) setStale(created time.Time) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.stale = true
+	this.created = created
+}
+
+func (this *snapshotState) setConsistent() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.stale = false
+	this.consistent = time.Now()
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshots", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "snapshot")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("warm starts a stale machine index", func() {
+		path := filepath.Join(dir, "machines.json")
+		info := newMachineInfo("m1", "aa:bb:cc:dd:ee:01")
+		info.ResourceVersion = "4711"
+		info.Labels = map[string]string{"rack": "r1"}
+		m, _ := NewMachine(info)
+		index := NewFullIndexer()
+		index.Set(m)
+		Expect(index.(SnapshotIndex).WriteSnapshot(path)).To(BeNil())
+
+		warm := NewFullIndexer()
+		Expect(warm.(SnapshotIndex).LoadSnapshot(logger.New(), path)).To(BeNil())
+		Expect(warm.IsInitialized()).To(BeTrue())
+		Expect(warm.(SnapshotIndex).IsStale()).To(BeTrue())
+		found := warm.GetByMAC("aa:bb:cc:dd:ee:01")
+		Expect(found).NotTo(BeNil())
+		Expect(found.ResourceVersion).To(Equal("4711"))
+		Expect(found.Labels()).To(Equal(info.Labels))
+
+		Expect(warm.(SnapshotIndex).LoadSnapshot(logger.New(), path)).NotTo(BeNil())
+	})
+
+	It("warm starts a stale machine type index", func() {
+		path := filepath.Join(dir, "types.json")
+		t := newType("t1", "aa:bb:cc/24")
+		t.ResourceVersion = "42"
+		index := NewTypeFullIndexer()
+		index.Set(t)
+		remote := newType("t2", "aa:bb:dd/24")
+		remote.Name = NewClusterObjectName("remote", "default", "t2")
+		index.Set(remote)
+		Expect(index.(SnapshotIndex).WriteSnapshot(path)).To(BeNil())
+
+		warm := NewTypeFullIndexer()
+		Expect(warm.(SnapshotIndex).LoadSnapshot(logger.New(), path)).To(BeNil())
+		Expect(warm.IsInitialized()).To(BeTrue())
+		Expect(warm.(SnapshotIndex).IsStale()).To(BeTrue())
+		found := warm.GetByMAC("aa:bb:cc:dd:ee:01")
+		Expect(found).NotTo(BeNil())
+		Expect(found.ResourceVersion).To(Equal("42"))
+		found = warm.GetByMAC("aa:bb:dd:dd:ee:01")
+		Expect(found).NotTo(BeNil())
+		Expect(ClusterOf(found.Name)).To(Equal("remote"))
+	})
+
+	It("rejects snapshots of other kinds", func() {
+		path := filepath.Join(dir, "bmcs.json")
+		Expect(NewBMCFullIndexer().(SnapshotIndex).WriteSnapshot(path)).To(BeNil())
+
+		index := NewFullIndexer()
+		err := index.(SnapshotIndex).LoadSnapshot(logger.New(), path)
+		Expect(err).NotTo(BeNil())
+		Expect(index.IsInitialized()).To(BeFalse())
+	})
+})
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+// updateStatus sets the state of an inventory object according to
+// a validation error.
+func updateStatus(obj resources.Object, err error, msg string) error {
+	state := api.STATE_OK
+	if err != nil {
+		state = api.STATE_INVALID
+		msg = err.Error()
+	}
+	_, err = resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
+		switch o := mod.Data().(type) {
+		case *api.MachineInfo:
+			mod.AssureStringValue(&o.Status.State, state)
+			mod.AssureStringValue(&o.Status.Message, msg)
+		case *api.BaseBoardManagementControllerInfo:
+			mod.AssureStringValue(&o.Status.State, state)
+			mod.AssureStringValue(&o.Status.Message, msg)
+		}
+		return nil
+	})
+	return err
+}
+
+// changedConflicts returns the objects only found in one of
+// the given conflict lists.
+func changedConflicts(before, after []resources.ObjectName) []resources.ObjectName {
+	set := map[resources.ObjectName]struct{}{}
+	addNames(set, before)
+	for _, n := range after {
+		if _, ok := set[n]; ok {
+			delete(set, n)
+		} else {
+			set[n] = struct{}{}
+		}
+	}
+	return sortedNames(set)
+}
@@ -0,0 +1,304 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TransportConfig describes the request handling of an index server client.
+// A nil config disables timeouts, retries and the circuit breaker.
+type TransportConfig struct {
+	// Timeout limits a single request attempt (0=no timeout).
+	Timeout time.Duration
+	// Retries is the number of additional attempts for failed requests.
+	Retries int
+	// Backoff is the delay before the first retry. It is doubled
+	// for every further retry and randomized by up to 50%.
+	Backoff time.Duration
+	// FailureThreshold is the number of consecutive failures opening the
+	// circuit breaker (0=no circuit breaker).
+	FailureThreshold int
+	// OpenTimeout is the time requests fail fast after the circuit
+	// breaker opened, before a single probe request is sent again.
+	OpenTimeout time.Duration
+}
+
+func (this *TransportConfig) retries() int {
+	if this == nil || this.Retries < 0 {
+		return 0
+	}
+	return this.Retries
+}
+
+func (this *TransportConfig) timeout() time.Duration {
+	if this == nil {
+		return 0
+	}
+	return this.Timeout
+}
+
+func (this *TransportConfig) backoff() time.Duration {
+	if this == nil {
+		return 0
+	}
+	return this.Backoff
+}
+
+func (this *TransportConfig) breaker() *circuitBreaker {
+	if this == nil || this.FailureThreshold <= 0 {
+		return &circuitBreaker{now: time.Now}
+	}
+	return &circuitBreaker{threshold: this.FailureThreshold, timeout: this.OpenTimeout, now: time.Now}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// circuitBreaker counts consecutive request failures. After reaching the
+// threshold, requests are rejected until the timeout is over. Then a
+// single probe request is allowed, which closes the breaker on success.
+type circuitBreaker struct {
+	lock      sync.Mutex
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether a request may be sent. For rejected requests
+// the remaining time the breaker stays open is returned.
+func (this *circuitBreaker) allow() (bool, time.Duration) {
+	if this.threshold <= 0 {
+		return true, 0
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if this.failures < this.threshold {
+		return true, 0
+	}
+	if wait := this.openUntil.Sub(this.now()); wait > 0 {
+		return false, wait
+	}
+	if this.probing {
+		return false, this.timeout
+	}
+	this.probing = true
+	return true, 0
+}
+
+func (this *circuitBreaker) success() {
+	if this.threshold <= 0 {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.failures = 0
+	this.probing = false
+}
+
+// failure records a failed request and reports whether the breaker
+// has been opened by it.
+func (this *circuitBreaker) failure() bool {
+	if this.threshold <= 0 {
+		return false
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.failures++
+	if this.failures < this.threshold {
+		return false
+	}
+	this.probing = false
+	this.openUntil = this.now().Add(this.timeout)
+	return true
+}
+
+// release gives up a probe request without result.
+func (this *circuitBreaker) release() {
+	if this.threshold <= 0 {
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.probing = false
+}
+
+func (this *circuitBreaker) isOpen() bool {
+	if this.threshold <= 0 {
+		return false
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.failures >= this.threshold
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// transport sends requests to the index servers with a timeout per
+// attempt. Failed attempts are repeated with the next endpoint. After
+// all endpoints have failed, the request is retried after a backoff.
+// Endpoints are skipped while their circuit breaker is open.
+type transport struct {
+	client    *http.Client
+	config    *TransportConfig
+	endpoints *Endpoints
+	// failed is called for every failed attempt with the
+	// information whether the circuit breaker has been opened
+	failed func(host string, err error, opened bool)
+}
+
+func newTransport(client *http.Client, config *TransportConfig, endpoints *Endpoints, failed func(host string, err error, opened bool)) *transport {
+	return &transport{
+		client:    client,
+		config:    config,
+		endpoints: endpoints,
+		failed:    failed,
+	}
+}
+
+// do executes a request and returns the response together with the
+// complete response body. A response is returned for all status codes,
+// transport failures and open circuit breakers are reported as
+// IndexError with reason REASON_UNAVAILABLE.
+func (this *transport) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, []byte, error) {
+	backoff := this.config.backoff()
+	retries := this.config.retries()
+	tried := map[*endpoint]bool{}
+
+	var r *http.Response
+	var data []byte
+	var err error
+	for {
+		e, wait := this.endpoints.pick(tried)
+		if e == nil {
+			if len(tried) == 0 {
+				return nil, nil, &IndexError{
+					StatusCode: http.StatusServiceUnavailable,
+					Reason:     REASON_UNAVAILABLE,
+					Message:    "no index server available",
+					RetryAfter: wait,
+				}
+			}
+			// all available endpoints failed
+			if retries <= 0 || this.endpoints.isOpen() {
+				if r != nil {
+					return r, data, nil
+				}
+				return nil, nil, &IndexError{
+					StatusCode: http.StatusServiceUnavailable,
+					Reason:     REASON_UNAVAILABLE,
+					Message:    err.Error(),
+				}
+			}
+			retries--
+			tried = map[*endpoint]bool{}
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(jitter(backoff)):
+			}
+			backoff *= 2
+			continue
+		}
+
+		tried[e] = true
+		r, data, err = this.attempt(ctx, method, this.endpoints.url(e, path, query), body)
+		if ctx.Err() != nil {
+			// cancelled by the caller, this says nothing about the server
+			e.breaker.release()
+			return nil, nil, ctx.Err()
+		}
+		if err == nil && !retriable(r, data) {
+			this.endpoints.succeeded(e)
+			return r, data, nil
+		}
+		if err == nil {
+			err = NewIndexError(r, errorResponse(data))
+		}
+		this.failed(e.host, err, this.endpoints.failed(e))
+	}
+}
+
+func (this *transport) attempt(ctx context.Context, method, url string, body []byte) (*http.Response, []byte, error) {
+	if timeout := this.config.timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	r, err := this.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, data, nil
+}
+
+// retriable reports whether an error response indicates a temporary
+// failure of the index server or a proxy in front of it. A syncing
+// index is not retried, because it typically takes longer than the
+// retry backoff.
+func retriable(r *http.Response, data []byte) bool {
+	switch r.StatusCode {
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	case http.StatusServiceUnavailable:
+		resp := errorResponse(data)
+		return resp == nil || resp.Reason != REASON_NOT_INITIALIZED
+	}
+	return false
+}
+
+// jitter randomizes a delay to 50-100% of its value.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("index server client transport", func() {
+	var server *httptest.Server
+	var client *IndexServerClient
+	var requests int32
+	var failures int32
+	var delay time.Duration
+
+	BeforeEach(func() {
+		requests = 0
+		failures = 0
+		delay = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&requests, 1)
+			if delay > 0 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(delay):
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if n <= atomic.LoadInt32(&failures) {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"name":"m1","namespace":"default"}`))
+		}))
+		var err error
+		client, err = newTestClient(0, 0, &TransportConfig{
+			Timeout:          100 * time.Millisecond,
+			Retries:          2,
+			Backoff:          time.Millisecond,
+			FailureThreshold: 4,
+			OpenTimeout:      time.Hour,
+		}, server)
+		Expect(err).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("retries failed requests", func() {
+		failures = 2
+		n, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(err).To(Succeed())
+		Expect(n.Name()).To(Equal("m1"))
+		Expect(requests).To(Equal(int32(3)))
+	})
+
+	It("gives up after retries", func() {
+		failures = 3
+		_, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*IndexError).StatusCode).To(Equal(http.StatusBadGateway))
+		Expect(requests).To(Equal(int32(3)))
+	})
+
+	It("fails fast after consecutive failures", func() {
+		failures = 100
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(requests).To(Equal(int32(4)))
+
+		_, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(IsUnavailable(err)).To(BeTrue())
+		Expect(err.(*IndexError).RetryAfter).To(BeNumerically(">", 0))
+		Expect(requests).To(Equal(int32(4)))
+	})
+
+	It("closes circuit breaker after successful probe", func() {
+		now := time.Now()
+		client.endpoints.list[0].breaker.now = func() time.Time { return now }
+		failures = 4
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		client.get(context.Background(), "00:00:00:00:00:01", "")
+		now = now.Add(2 * time.Hour)
+
+		n, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(err).To(Succeed())
+		Expect(n.Name()).To(Equal("m1"))
+		Expect(client.endpoints.isOpen()).To(BeFalse())
+	})
+
+	It("times out requests", func() {
+		delay = time.Second
+		_, err := client.get(context.Background(), "00:00:00:00:00:01", "")
+		Expect(IsUnavailable(err)).To(BeTrue())
+		Expect(requests).To(Equal(int32(3)))
+	})
+
+	It("stops on cancelled context", func() {
+		delay = time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.get(ctx, "00:00:00:00:00:01", "")
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(requests).To(Equal(int32(1)))
+		Expect(client.endpoints.isOpen()).To(BeFalse())
+	})
+
+	It("stops warmup on cancelled context", func() {
+		delay = time.Second
+		warm, err := newTestClient(10, time.Minute, &TransportConfig{Timeout: time.Second}, server)
+		Expect(err).To(Succeed())
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err = warm.Warmup(ctx, []string{"00:00:00:00:00:01"}, nil)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(requests).To(Equal(int32(1)))
+	})
+})
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"sort"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+// prefixTrie is a binary trie over the bits of MAC prefixes used
+// to find the machine type with the most specific (longest)
+// prefix matching a MAC address. A lookup visits at most one
+// node per bit of the address.
+type prefixTrie struct {
+	root prefixNode
+}
+
+type prefixNode struct {
+	children [2]*prefixNode
+	// types claiming exactly the prefix of this node, ordered by name
+	// to resolve identical prefixes of different types deterministically
+	types []*MachineType
+}
+
+func newPrefixTrie() *prefixTrie {
+	return &prefixTrie{}
+}
+
+func bit(addr MAC, i int) int {
+	return int(addr[i/8]>>uint(7-i%8)) & 1
+}
+
+// Add adds a prefix for a machine type. It reports whether the
+// same prefix is already claimed by another machine type.
+func (this *prefixTrie) Add(p *MACPrefix, t *MachineType) bool {
+	node := &this.root
+	for i := 0; i < p.Bits; i++ {
+		b := bit(p.Address, i)
+		if node.children[b] == nil {
+			node.children[b] = &prefixNode{}
+		}
+		node = node.children[b]
+	}
+	conflict := false
+	for i, e := range node.types {
+		if e.Name == t.Name {
+			node.types[i] = t
+			return false
+		}
+		conflict = true
+	}
+	node.types = append(node.types, t)
+	sort.Slice(node.types, func(i, j int) bool {
+		return node.types[i].Name.String() < node.types[j].Name.String()
+	})
+	return conflict
+}
+
+// Remove removes a prefix of a machine type and prunes
+// nodes not required anymore.
+func (this *prefixTrie) Remove(p *MACPrefix, name resources.ObjectName) {
+	this.root.remove(p, 0, name)
+}
+
+func (this *prefixNode) remove(p *MACPrefix, i int, name resources.ObjectName) bool {
+	if i == p.Bits {
+		for j, e := range this.types {
+			if e.Name == name {
+				this.types = append(this.types[:j], this.types[j+1:]...)
+				break
+			}
+		}
+	} else {
+		b := bit(p.Address, i)
+		if c := this.children[b]; c != nil && c.remove(p, i+1, name) {
+			this.children[b] = nil
+		}
+	}
+	return len(this.types) == 0 && this.children[0] == nil && this.children[1] == nil
+}
+
+// Empty reports whether the trie does not contain any prefix.
+func (this *prefixTrie) Empty() bool {
+	return len(this.root.types) == 0 && this.root.children[0] == nil && this.root.children[1] == nil
+}
+
+// Lookup returns the machine type with the longest prefix
+// containing the given address.
+func (this *prefixTrie) Lookup(m MAC) *MachineType {
+	var found *MachineType
+	node := &this.root
+	for i := 0; node != nil; i++ {
+		if len(node.types) > 0 {
+			found = node.types[0]
+		}
+		if i == len(m)*8 {
+			break
+		}
+		node = node.children[bit(m, i)]
+	}
+	return found
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func newType(name string, prefixes ...string) *MachineType {
+	t, err := NewMachineType(&api.MachineType{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       api.MachineTypeSpec{MACPrefixes: prefixes},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func mustParseMAC(s string) MAC {
+	m, err := ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+var _ = Describe("Prefix Trie", func() {
+	var index MachineTypeIndexer
+
+	BeforeEach(func() {
+		index = NewTypeFullIndexer()
+		index.Set(newType("vendor", "00:11:22/24"))
+		index.Set(newType("product", "00:11:22:30/28"))
+		index.Set(newType("eui64", "00:11:22:33:44:55:66/56"))
+	})
+
+	It("returns the most specific prefix", func() {
+		Expect(index.GetByMAC("00:11:22:3f:00:01").Name.Name()).To(Equal("product"))
+		Expect(index.GetByMAC("00:11:22:40:00:01").Name.Name()).To(Equal("vendor"))
+		Expect(index.GetByMAC("00:11:23:00:00:01")).To(BeNil())
+	})
+
+	It("handles EUI-64 and 20 byte addresses", func() {
+		Expect(index.GetByMAC("00:11:22:33:44:55:66:77").Name.Name()).To(Equal("eui64"))
+		Expect(index.GetByMAC("00:11:22:33:44:56:66:77").Name.Name()).To(Equal("product"))
+		Expect(index.GetByMAC("00:11:22:40:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:01").Name.Name()).To(Equal("vendor"))
+	})
+
+	It("handles prefixes longer than 127 bits", func() {
+		index.Set(newType("long", "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22/152"))
+		Expect(index.GetByMAC("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:01").Name.Name()).To(Equal("long"))
+		Expect(index.GetByMAC("00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:23:01").Name.Name()).To(Equal("eui64"))
+	})
+
+	It("falls back to less specific prefixes after deletion", func() {
+		index.Delete(newType("product", "00:11:22:30/28").Name)
+		Expect(index.GetByMAC("00:11:22:3f:00:01").Name.Name()).To(Equal("vendor"))
+	})
+
+	It("resolves identical prefixes deterministically", func() {
+		index.Set(newType("another", "00:11:22:30/28"))
+		for i := 0; i < 10; i++ {
+			Expect(index.GetByMAC("00:11:22:3f:00:01").Name.Name()).To(Equal("another"))
+		}
+	})
+
+	It("updates the prefixes of a type", func() {
+		index.Set(newType("product", "00:11:22:40/28"))
+		Expect(index.GetByMAC("00:11:22:3f:00:01").Name.Name()).To(Equal("vendor"))
+		Expect(index.GetByMAC("00:11:22:4f:00:01").Name.Name()).To(Equal("product"))
+	})
+})
+
+////////////////////////////////////////////////////////////////////////////////
+
+func benchmarkIndex(types int) (*prefixTrie, []*MachineType) {
+	trie := newPrefixTrie()
+	list := make([]*MachineType, types)
+	for i := 0; i < types; i++ {
+		t := newType(fmt.Sprintf("type%d", i),
+			fmt.Sprintf("%02x:%02x:%02x/24", i>>16&0xff, i>>8&0xff, i&0xff),
+			fmt.Sprintf("%02x:%02x:%02x:%02x/36", i>>16&0xff, i>>8&0xff, i&0xff, 0x80),
+		)
+		for _, p := range t.prefixes {
+			trie.Add(p, t)
+		}
+		list[i] = t
+	}
+	return trie, list
+}
+
+func benchmarkLookup(b *testing.B, types int, mac string) {
+	trie, _ := benchmarkIndex(types)
+	m := mustParseMAC(mac)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Lookup(m)
+	}
+}
+
+func benchmarkLinear(b *testing.B, types int, mac string) {
+	_, list := benchmarkIndex(types)
+	m := mustParseMAC(mac)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+	outer:
+		for _, t := range list {
+			for _, p := range t.prefixes {
+				if p.Contains(m) {
+					break outer
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkTrieLookup1000(b *testing.B) {
+	benchmarkLookup(b, 1000, "00:03:e7:80:00:01")
+}
+
+func BenchmarkTrieLookup10000(b *testing.B) {
+	benchmarkLookup(b, 10000, "00:27:0f:80:00:01")
+}
+
+func BenchmarkTrieLookupEUI64(b *testing.B) {
+	benchmarkLookup(b, 10000, "00:27:0f:80:00:01:02:03")
+}
+
+func BenchmarkTrieLookupMiss(b *testing.B) {
+	benchmarkLookup(b, 10000, "ff:ff:ff:80:00:01")
+}
+
+func BenchmarkLinearLookup1000(b *testing.B) {
+	benchmarkLinear(b, 1000, "00:03:e7:80:00:01")
+}
+
+func BenchmarkLinearLookup10000(b *testing.B) {
+	benchmarkLinear(b, 10000, "00:27:0f:80:00:01")
+}
+
+func BenchmarkTrieAdd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		benchmarkIndex(1000)
+	}
+}
@@ -46,8 +46,10 @@ func NewMachineType(m *api.MachineType) (*MachineType, error) {
 		prefixes[i] = prefix
 	}
 	return &MachineType{
-		Name:     resources.NewObjectName(m.Namespace, m.Name),
-		prefixes: prefixes,
+		Name:            resources.NewObjectName(m.Namespace, m.Name),
+		ResourceVersion: m.ResourceVersion,
+		MachineTypeSpec: &m.Spec,
+		prefixes:        prefixes,
 	}, nil
 }
 
@@ -64,6 +66,7 @@ func ValidateMachineType(logger logger.LogContext, obj resources.Object) (*Machi
 		})
 		return nil, err, err2
 	}
+	m.Name = ObjectNameFor(obj)
 	_, err = resources.ModifyStatus(obj, func(mod *resources.ModificationState) error {
 		m := mod.Data().(*api.MachineType)
 		mod.AssureStringValue(&m.Status.State, api.STATE_OK)
@@ -23,13 +23,16 @@
 package machines
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
 	"github.com/gardener/controller-manager-library/pkg/logger"
 	"github.com/gardener/controller-manager-library/pkg/resources"
-	"k8s.io/apimachinery/pkg/labels"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
 )
@@ -41,20 +44,47 @@ import (
  */
 
 type MachineTypeFullIndexer struct {
+	listeners
+	snapshotState
 	initlock    sync.RWMutex
 	lock        sync.RWMutex
 	initialized int32
+	lastSync    time.Time
 	elements    map[resources.ObjectName]*MachineType
+	prefixes    *prefixTrie
+	// prefixes of the types of every namespace
+	namespaces map[string]*prefixTrie
 }
 
 func NewTypeFullIndexer() MachineTypeIndexer {
 	m := &MachineTypeFullIndexer{
-		elements: map[resources.ObjectName]*MachineType{},
+		elements:   map[resources.ObjectName]*MachineType{},
+		prefixes:   newPrefixTrie(),
+		namespaces: map[string]*prefixTrie{},
 	}
 	m.initlock.Lock()
 	return m
 }
 
+var _ IndexStatistics = &MachineTypeFullIndexer{}
+var _ NamespaceTypeIndex = &MachineTypeFullIndexer{}
+var _ MachineTypeLister = &MachineTypeFullIndexer{}
+var _ SnapshotIndex = &MachineTypeFullIndexer{}
+
+func (this *MachineTypeFullIndexer) Size() int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return len(this.elements)
+}
+
+func (this *MachineTypeFullIndexer) LastSync() time.Time {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	return this.lastSync
+}
+
 func (this *MachineTypeFullIndexer) Wait() {
 	this.initlock.RLock()
 	this.initlock.RUnlock()
@@ -72,16 +102,36 @@ func (this *MachineTypeFullIndexer) GetByMAC(mac string) *MachineType {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
 
-	for _, t := range this.elements {
-		for _, p := range t.prefixes {
-			if p.Contains(m) {
-				return t
-			}
-		}
+	return this.prefixes.Lookup(m)
+}
+
+func (this *MachineTypeFullIndexer) GetByMACInNamespace(namespace, mac string) *MachineType {
+	m, err := ParseMAC(mac)
+	if err != nil {
+		return nil
+	}
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	if t := this.namespaces[namespace]; t != nil {
+		return t.Lookup(m)
 	}
 	return nil
 }
 
+// List returns all indexed machine types ordered by name.
+func (this *MachineTypeFullIndexer) List() []*MachineType {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+
+	list := make([]*MachineType, 0, len(this.elements))
+	for _, e := range this.elements {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name.String() < list[j].Name.String() })
+	return list
+}
+
 func (this *MachineTypeFullIndexer) GetByName(name resources.ObjectName) *MachineType {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
@@ -89,8 +139,9 @@ func (this *MachineTypeFullIndexer) GetByName(name resources.ObjectName) *Machin
 	return this.elements[name]
 }
 
-func (this *MachineTypeFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface) error {
-	if atomic.LoadInt32(&this.initialized) != 0 {
+func (this *MachineTypeFullIndexer) Setup(logger logger.LogContext, cluster cluster.Interface, scope *Scope) error {
+	stale := this.IsStale()
+	if atomic.LoadInt32(&this.initialized) != 0 && !stale {
 		logger.Infof("machine type cache already initialized")
 		return nil
 	}
@@ -105,49 +156,173 @@ func (this *MachineTypeFullIndexer) Setup(logger logger.LogContext, cluster clus
 		return err
 	}
 	logger.Infof("setup machine types")
-	list, _ := resc.ListCached(labels.Everything())
+	list, _ := scope.ListCached(resc)
 
+	live := map[resources.ObjectName]struct{}{}
 	for _, l := range list {
+		name := ObjectNameFor(l)
+		live[name] = struct{}{}
+		if old := this.GetByName(name); stale && old != nil && old.ResourceVersion == l.GetResourceVersion() {
+			continue
+		}
 		elem, err, _ := ValidateMachineType(logger, l)
 		if elem != nil {
 			this.Set(elem)
 			logger.Infof("found machine type %s", elem.Name)
+		} else if stale {
+			this.Delete(name)
 		}
 		if err != nil {
 			logger.Infof("errorneous machine type %s: %s", l.GetName(), err)
 		}
 	}
+	if stale {
+		for _, e := range this.List() {
+			// objects of additional clusters are reconciled by the remote
+			// watches, which drop the objects of unconfigured clusters
+			if _, ok := live[e.Name]; !ok && ClusterOf(e.Name) == "" {
+				logger.Infof("machine type %s from snapshot not found anymore", e.Name)
+				this.Delete(e.Name)
+			}
+		}
+	}
 	logger.Infof("machine type cache setup done")
-	atomic.StoreInt32(&this.initialized, 1)
-	this.initlock.Unlock()
+	this.lock.Lock()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	this.setConsistent()
+	if stale {
+		logger.Infof("machine type cache is consistent now (snapshot from %s)", this.SnapshotTime().Format(time.RFC3339))
+	} else {
+		atomic.StoreInt32(&this.initialized, 1)
+		this.initlock.Unlock()
+	}
 	return nil
 }
 
 func (this *MachineTypeFullIndexer) Set(m *MachineType) error {
 	this.lock.Lock()
-	defer this.lock.Unlock()
-
 	old := this.elements[m.Name]
 	if old != nil {
 		this.cleanup(old)
 	}
 	this.set(m)
+	this.updateMetrics()
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_MACHINETYPE, OP_SET).Inc()
+
+	c := newChange(old != nil, m.Name, m.ResourceVersion)
+	if old != nil {
+		c.addKeys(old)
+	}
+	c.addKeys(m)
+	this.notify(c)
 	return nil
 }
 
 func (this *MachineTypeFullIndexer) Delete(name resources.ObjectName) {
 	this.lock.Lock()
-	defer this.lock.Unlock()
 	old := this.elements[name]
 	if old != nil {
 		this.cleanup(old)
+		this.updateMetrics()
+	}
+	this.lastSync = time.Now()
+	this.lock.Unlock()
+	indexUpdates.WithLabelValues(PATH_MACHINETYPE, OP_DELETE).Inc()
+
+	if old != nil {
+		c := &IndexChange{Type: CHANGE_DELETED, Name: name, ResourceVersion: old.ResourceVersion}
+		c.addKeys(old)
+		this.notify(c)
 	}
 }
 
 func (this *MachineTypeFullIndexer) cleanup(m *MachineType) {
+	t := this.namespaces[m.Name.Namespace()]
+	for _, p := range m.prefixes {
+		this.prefixes.Remove(p, m.Name)
+		t.Remove(p, m.Name)
+	}
+	if t.Empty() {
+		delete(this.namespaces, m.Name.Namespace())
+	}
 	delete(this.elements, m.Name)
 }
 
 func (this *MachineTypeFullIndexer) set(m *MachineType) {
+	t := this.namespaces[m.Name.Namespace()]
+	if t == nil {
+		t = newPrefixTrie()
+		this.namespaces[m.Name.Namespace()] = t
+	}
+	for _, p := range m.prefixes {
+		this.prefixes.Add(p, m)
+		if t.Add(p, m) {
+			indexConflicts.WithLabelValues(PATH_MACHINETYPE, KEY_PREFIX).Inc()
+		}
+	}
 	this.elements[m.Name] = m
 }
+
+func (this *MachineTypeFullIndexer) updateMetrics() {
+	prefixes := 0
+	for _, t := range this.elements {
+		prefixes += len(t.prefixes)
+	}
+	indexElements.WithLabelValues(PATH_MACHINETYPE).Set(float64(len(this.elements)))
+	indexKeys.WithLabelValues(PATH_MACHINETYPE, KEY_PREFIX).Set(float64(prefixes))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// snapshots
+
+// LoadSnapshot fills the uninitialized index with the machine types of
+// a snapshot file. The index is then initialized, but stale until
+// the next Setup.
+func (this *MachineTypeFullIndexer) LoadSnapshot(logger logger.LogContext, path string) error {
+	if atomic.LoadInt32(&this.initialized) != 0 {
+		return fmt.Errorf("machine type index already initialized")
+	}
+	var list []*api.MachineType
+	created, err := readSnapshot(path, "MachineType", &list)
+	if err != nil {
+		return err
+	}
+	for _, o := range list {
+		elem, err := NewMachineType(o)
+		if elem != nil {
+			elem.Name = NewClusterObjectName(o.ClusterName, o.Namespace, o.Name)
+			err = this.Set(elem)
+		}
+		if err != nil {
+			logger.Infof("errorneous machine type %s in snapshot: %s", o.Name, err)
+		}
+	}
+	this.lock.Lock()
+	this.lastSync = created
+	this.lock.Unlock()
+	this.setStale(created)
+	logger.Infof("loaded %d machine type(s) from snapshot %s created %s", len(list), path, created.Format(time.RFC3339))
+	atomic.StoreInt32(&this.initialized, 1)
+	this.initlock.Unlock()
+	return nil
+}
+
+// WriteSnapshot writes all indexed machine types to a snapshot file.
+func (this *MachineTypeFullIndexer) WriteSnapshot(path string) error {
+	list := []*api.MachineType{}
+	for _, e := range this.List() {
+		list = append(list, &api.MachineType{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     ClusterOf(e.Name),
+				Namespace:       e.Name.Namespace(),
+				Name:            e.Name.Name(),
+				ResourceVersion: e.ResourceVersion,
+			},
+			Spec: *e.MachineTypeSpec,
+		})
+	}
+	return writeSnapshot(path, "MachineType", list)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/resources"
+)
+
+// MachineView combines the information known about a machine.
+type MachineView struct {
+	Machine *Machine
+	// BMC is the BMC with the UUID of the machine. Its credentials
+	// are always redacted.
+	BMC *BaseBoardManagementController
+	// Type is the machine type matching the first NIC with a
+	// known MAC prefix.
+	Type *MachineType
+	// Leases maps NIC names to the current DHCP lease for the NIC.
+	Leases map[string]*Lease
+}
+
+// Response converts the view into the document served by the
+// index server.
+func (this *MachineView) Response() *MachineViewResponse {
+	r := &MachineViewResponse{
+		Machine: &MachineInfoResponse{
+			IndexResponse: NewIndexResponse(this.Machine.Name, this.Machine.ResourceVersion),
+			Spec:          this.Machine.MachineInfoSpec,
+		},
+	}
+	if this.BMC != nil {
+		r.BMC = &BMCInfoResponse{
+			IndexResponse: NewIndexResponse(this.BMC.Name, this.BMC.ResourceVersion),
+			Spec:          this.BMC.BaseBoardManagementControllerInfoSpec,
+		}
+	}
+	if this.Type != nil {
+		r.Type = &MachineTypeResponse{
+			IndexResponse: NewIndexResponse(this.Type.Name, this.Type.ResourceVersion),
+			Spec:          this.Type.MachineTypeSpec,
+		}
+	}
+	for nic, l := range this.Leases {
+		if r.Leases == nil {
+			r.Leases = map[string]*LeaseResponse{}
+		}
+		r.Leases[nic] = &LeaseResponse{
+			IndexResponse: NewIndexResponse(l.Name, l.ResourceVersion),
+			Spec:          l.DHCPLeaseSpec,
+		}
+	}
+	return r
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MachineViews resolves MAC addresses and UUIDs to machine views by
+// joining the machine info, BMC, machine type and lease indices.
+// All indices except the machine index are optional.
+type MachineViews struct {
+	machines MachineIndex
+	bmcs     BMCIndex
+	types    MachineTypeIndex
+	leases   LeaseIndex
+}
+
+func NewMachineViews(machines MachineIndex, bmcs BMCIndex, types MachineTypeIndex, leases LeaseIndex) *MachineViews {
+	return &MachineViews{
+		machines: machines,
+		bmcs:     bmcs,
+		types:    types,
+		leases:   leases,
+	}
+}
+
+func (this *MachineViews) IsInitialized() bool {
+	if this.machines == nil || !this.machines.IsInitialized() {
+		return false
+	}
+	if this.bmcs != nil && !this.bmcs.IsInitialized() {
+		return false
+	}
+	if this.types != nil && !this.types.IsInitialized() {
+		return false
+	}
+	if this.leases != nil && !this.leases.IsInitialized() {
+		return false
+	}
+	return true
+}
+
+// GetByMAC returns the view for a NIC MAC address. The MAC address of
+// a BMC resolves to the machine with the UUID of the BMC.
+func (this *MachineViews) GetByMAC(mac string) *MachineView {
+	m := this.machines.GetByMAC(mac)
+	if m == nil && this.bmcs != nil {
+		if b := this.bmcs.GetByMAC(mac); b != nil && b.UUID != "" {
+			m = this.machines.GetByUUID(b.UUID)
+		}
+	}
+	return this.view(m)
+}
+
+func (this *MachineViews) GetByUUID(uuid string) *MachineView {
+	return this.view(this.machines.GetByUUID(uuid))
+}
+
+func (this *MachineViews) GetByName(name resources.ObjectName) *MachineView {
+	return this.view(this.machines.GetByName(name))
+}
+
+func (this *MachineViews) view(m *Machine) *MachineView {
+	if m == nil {
+		return nil
+	}
+	v := &MachineView{Machine: m}
+	if this.bmcs != nil && m.UUID != "" {
+		v.BMC = RedactBMC(this.bmcs.GetByUUID(m.UUID))
+	}
+	for _, nic := range m.NICs {
+		mac := canonicalMAC(nic.MAC)
+		if mac == "" {
+			continue
+		}
+		if v.Type == nil && this.types != nil {
+			v.Type = this.types.GetByMAC(mac)
+		}
+		if this.leases != nil {
+			if l := this.leases.GetByMAC(mac); l != nil {
+				if v.Leases == nil {
+					v.Leases = map[string]*Lease{}
+				}
+				v.Leases[nic.Name] = l
+			}
+		}
+	}
+	return v
+}
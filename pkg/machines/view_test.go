@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machines
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+func newLease(name, mac string, expire time.Time) *Lease {
+	l, err := NewLease(&api.DHCPLease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       api.DHCPLeaseSpec{MAC: mac, ExpireTime: metav1.NewTime(expire)},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+var _ = Describe("Machine Views", func() {
+	var views *MachineViews
+	var machine *Machine
+	var bmc *BaseBoardManagementController
+	var current *Lease
+
+	BeforeEach(func() {
+		info := newMachineInfo("m1", "aa:bb:cc:dd:ee:01", "00:11:22:33:44:55")
+		info.Spec.UUID = "u1"
+		machine, _ = NewMachine(info)
+		machineindex := NewFullIndexer()
+		machineindex.Set(machine)
+
+		b := newBMCInfo("b1", "10.0.0.1", "S1", "T1")
+		b.Spec.UUID = "u1"
+		b.Spec.MAC = "aa:bb:cc:dd:ee:ff"
+		b.Spec.Credentials = &api.BasicAuthCredentials{User: "admin", Password: "secret"}
+		bmc, _ = NewBaseBoardManagementController(b)
+		bmcindex := NewBMCFullIndexer()
+		bmcindex.Set(bmc)
+
+		typeindex := NewTypeFullIndexer()
+		typeindex.Set(newType("vendor", "00:11:22/24"))
+
+		now := time.Now()
+		current = newLease("l2", "00-11-22-33-44-55", now.Add(time.Hour))
+		leaseindex := NewLeaseFullIndexer()
+		leaseindex.Set(newLease("l1", "00:11:22:33:44:55", now))
+		leaseindex.Set(current)
+
+		views = NewMachineViews(machineindex, bmcindex, typeindex, leaseindex)
+	})
+
+	It("joins all information for a machine", func() {
+		v := views.GetByMAC("AA-BB-CC-DD-EE-01")
+		Expect(v).NotTo(BeNil())
+		Expect(v.Machine).To(Equal(machine))
+		Expect(v.BMC.Name).To(Equal(bmc.Name))
+		Expect(v.BMC.Credentials).To(BeNil())
+		Expect(bmc.Credentials).NotTo(BeNil())
+		Expect(v.Type.Name.Name()).To(Equal("vendor"))
+		Expect(v.Leases).To(Equal(map[string]*Lease{"b": current}))
+
+		r := v.Response()
+		Expect(r.Machine.Name).To(Equal("m1"))
+		Expect(r.Leases["b"].Name).To(Equal("l2"))
+	})
+
+	It("resolves uuids and bmc mac addresses", func() {
+		Expect(views.GetByUUID("u1").Machine).To(Equal(machine))
+		Expect(views.GetByMAC("aa:bb:cc:dd:ee:ff").Machine).To(Equal(machine))
+		Expect(views.GetByMAC("aa:bb:cc:dd:ee:02")).To(BeNil())
+	})
+})
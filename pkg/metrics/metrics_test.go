@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ = Describe("Metrics", func() {
+	var registry *prometheus.Registry
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+	})
+
+	get := func() (string, string) {
+		w := httptest.NewRecorder()
+		Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, PATH, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		data, err := ioutil.ReadAll(w.Body)
+		Expect(err).To(Succeed())
+		return w.Header().Get("Content-Type"), string(data)
+	}
+
+	It("serves counters and gauges", func() {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "Number of requests."}, []string{"path"})
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_elements", Help: "Number of elements."})
+		registry.MustRegister(c, g)
+
+		c.WithLabelValues("info").Inc()
+		c.WithLabelValues("bmc").Add(2)
+		c.WithLabelValues("info").Inc()
+		g.Set(17)
+
+		ctype, body := get()
+		Expect(ctype).To(HavePrefix("text/plain; version=0.0.4"))
+		Expect(body).To(Equal(`# HELP test_elements Number of elements.
+# TYPE test_elements gauge
+test_elements 17
+# HELP test_requests_total Number of requests.
+# TYPE test_requests_total counter
+test_requests_total{path="bmc"} 2
+test_requests_total{path="info"} 2
+`))
+	})
+
+	It("serves histograms", func() {
+		h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds", Help: "Request latency.", Buckets: []float64{0.1, 1}}, []string{"path"})
+		registry.MustRegister(h)
+
+		h.WithLabelValues("info").Observe(0.05)
+		h.WithLabelValues("info").Observe(0.5)
+		h.WithLabelValues("info").Observe(5)
+
+		_, body := get()
+		Expect(body).To(Equal(`# HELP test_duration_seconds Request latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{path="info",le="0.1"} 1
+test_duration_seconds_bucket{path="info",le="1"} 2
+test_duration_seconds_bucket{path="info",le="+Inf"} 3
+test_duration_seconds_sum{path="info"} 5.55
+test_duration_seconds_count{path="info"} 3
+`))
+	})
+
+	It("escapes label values and help texts", func() {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "Test \\ help\nline."}, []string{"key"})
+		registry.MustRegister(c)
+		c.WithLabelValues("a\"b\\c\nd").Inc()
+
+		_, body := get()
+		Expect(body).To(ContainSubstring(`# HELP test_total Test \\ help\nline.`))
+		Expect(body).To(ContainSubstring(`test_total{key="a\"b\\c\nd"} 1`))
+	})
+
+	It("rejects duplicate registrations", func() {
+		registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "test", Help: "Test."}))
+		Expect(func() { registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "test", Help: "Test."})) }).To(Panic())
+	})
+})
@@ -23,21 +23,80 @@
 package serverindex
 
 import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
 	"github.com/gardener/controller-manager-library/pkg/config"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
 )
 
 type Config struct {
-	Host     string
-	Port     int
-	MaxCache int
+	Host           string
+	Port           int
+	Endpoints      []string
+	SRV            string
+	HealthInterval time.Duration
+	Fallback       bool
+	MaxCache       int
+	CacheTTL       time.Duration
+	Watch          bool
+
+	hosts []string
+
+	machines.AccessConfig
+	machines.TransportConfig
 }
 
 func (this *Config) AddOptionsToSet(set config.OptionSet) {
 	set.AddStringOption(&this.Host, "indexserver-host", "", "machineindex", "host for machine index server")
 	set.AddIntOption(&this.Port, "indexserver-port", "", 8090, "port of index server")
+	set.AddStringArrayOption(&this.Endpoints, "indexserver-endpoint", "", nil, "host:port of an index server replica (replaces host and port)")
+	set.AddStringOption(&this.SRV, "indexserver-srv", "", "", "DNS name with SRV records for the index server replicas")
+	set.AddDurationOption(&this.HealthInterval, "indexserver-health-interval", "", 10*time.Second, "interval for health checks of the index servers (0=no health checks)")
+	set.AddBoolOption(&this.Fallback, "indexserver-fallback", "", false, "use a local index built from the cluster while no index server is available")
 	set.AddIntOption(&this.MaxCache, "indexserver-cachesize", "", 100, "max cache size (0=no cache)")
+	set.AddDurationOption(&this.CacheTTL, "indexserver-cache-ttl", "", 5*time.Minute, "max age of cache entries (0=no expiry)")
+	set.AddBoolOption(&this.Watch, "indexserver-watch", "", true, "watch index server for changes to invalidate cache entries")
+	set.AddDurationOption(&this.Timeout, "indexserver-timeout", "", 10*time.Second, "timeout for a single request to the index server (0=no timeout)")
+	set.AddIntOption(&this.Retries, "indexserver-retries", "", 2, "number of retries for failed requests to the index server")
+	set.AddDurationOption(&this.Backoff, "indexserver-retry-backoff", "", 200*time.Millisecond, "initial delay between retries, doubled for every retry")
+	set.AddIntOption(&this.FailureThreshold, "indexserver-failure-threshold", "", 5, "consecutive failures until requests fail fast (0=never)")
+	set.AddDurationOption(&this.OpenTimeout, "indexserver-failfast-duration", "", 30*time.Second, "duration requests fail fast before the index server is probed again")
+	set.AddBoolOption(&this.UseTLS, "indexserver-tls", "", false, "use https to access index server")
+	set.AddStringOption(&this.CAFile, "indexserver-ca-file", "", "", "ca file to verify index server certificate")
+	set.AddStringOption(&this.CertFile, "indexserver-cert-file", "", "", "client certificate file for index server")
+	set.AddStringOption(&this.KeyFile, "indexserver-key-file", "", "", "client certificate key file for index server")
+	set.AddStringOption(&this.Token, "indexserver-token", "", "", "bearer token for index server")
+	set.AddStringOption(&this.TokenFile, "indexserver-token-file", "", "", "file containing bearer token for index server")
 }
 
 func (this *Config) Prepare() error {
+	if this.SRV != "" {
+		if len(this.Endpoints) > 0 {
+			return fmt.Errorf("only one of indexserver-endpoint or indexserver-srv possible")
+		}
+		if this.HealthInterval <= 0 {
+			return fmt.Errorf("indexserver-srv requires a health interval")
+		}
+	}
+	this.hosts = nil
+	for _, e := range this.Endpoints {
+		if _, _, err := net.SplitHostPort(e); err != nil {
+			return fmt.Errorf("invalid index server endpoint %q: %s", e, err)
+		}
+		this.hosts = append(this.hosts, e)
+	}
+	if len(this.hosts) == 0 && this.SRV == "" {
+		this.hosts = []string{net.JoinHostPort(this.Host, strconv.Itoa(this.Port))}
+	}
+	if this.Retries < 0 {
+		return fmt.Errorf("indexserver-retries must not be negative")
+	}
+	if this.FailureThreshold > 0 && this.OpenTimeout <= 0 {
+		return fmt.Errorf("indexserver-failfast-duration must be positive if a failure threshold is set")
+	}
 	return nil
 }
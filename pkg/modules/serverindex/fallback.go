@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package serverindex
+
+import (
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/module"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// fallback keeps a local index up to date with the objects of the
+// main cluster. It answers lookups while no index server is available.
+type fallback struct {
+	module module.Interface
+	gk     schema.GroupKind
+	setup  func(logger logger.LogContext, cluster cluster.Interface) error
+	update func(obj resources.Object) error
+	delete func(name resources.ObjectName)
+}
+
+func newMachineFallback(mod module.Interface, index machines.MachineIndexer) *fallback {
+	return &fallback{
+		module: mod,
+		gk:     api.MACHINEINFO,
+		setup: func(logger logger.LogContext, cluster cluster.Interface) error {
+			return index.Setup(logger, cluster, nil)
+		},
+		update: func(obj resources.Object) error {
+			m, err := machines.NewMachine(obj.Data().(*api.MachineInfo))
+			if err != nil {
+				return err
+			}
+			return index.Set(m)
+		},
+		delete: index.Delete,
+	}
+}
+
+func newBMCFallback(mod module.Interface, index machines.BMCIndexer) *fallback {
+	return &fallback{
+		module: mod,
+		gk:     api.BASEBOARDMANAGEMENTCONTROLLERINFO,
+		setup: func(logger logger.LogContext, cluster cluster.Interface) error {
+			return index.Setup(logger, cluster, nil)
+		},
+		update: func(obj resources.Object) error {
+			m, err := machines.NewBaseBoardManagementController(obj.Data().(*api.BaseBoardManagementControllerInfo))
+			if err != nil {
+				return err
+			}
+			return index.Set(m)
+		},
+		delete: index.Delete,
+	}
+}
+
+func newTypeFallback(mod module.Interface, index machines.MachineTypeIndexer) *fallback {
+	return &fallback{
+		module: mod,
+		gk:     api.MACHINETYPE,
+		setup: func(logger logger.LogContext, cluster cluster.Interface) error {
+			return index.Setup(logger, cluster, nil)
+		},
+		update: func(obj resources.Object) error {
+			m, err := machines.NewMachineType(obj.Data().(*api.MachineType))
+			if err != nil {
+				return err
+			}
+			return index.Set(m)
+		},
+		delete: index.Delete,
+	}
+}
+
+// Start watches the objects and fills the index in the background.
+// The index is used as soon as it is initialized.
+func (this *fallback) Start() error {
+	cluster := this.module.GetMainCluster()
+	resc, err := cluster.Resources().Get(this.gk)
+	if err != nil {
+		return err
+	}
+	err = resc.AddSelectedEventHandler(resources.ResourceEventHandlerFuncs{
+		AddFunc:    this.updateObject,
+		UpdateFunc: func(old, new resources.Object) { this.updateObject(new) },
+		DeleteFunc: func(obj resources.Object) { this.delete(machines.ObjectNameFor(obj)) },
+	}, "", nil)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := this.setup(this.module, cluster); err != nil {
+			this.module.Errorf("cannot setup fallback index for %s: %s", this.gk.Kind, err)
+			return
+		}
+		this.module.Infof("fallback index for %s initialized", this.gk.Kind)
+	}()
+	return nil
+}
+
+func (this *fallback) updateObject(obj resources.Object) {
+	if err := this.update(obj); err != nil {
+		this.module.Warnf("cannot index %s %s in fallback index: %s", this.gk.Kind, obj.ObjectName(), err)
+	}
+}
@@ -23,6 +23,8 @@
 package serverindex
 
 import (
+	"fmt"
+
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/module"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/module/handler"
 
@@ -37,6 +39,7 @@ func init() {
 		OptionsByExample("options", &Config{}).
 		RegisterHandler("machineinfos", MachineInfos).
 		RegisterHandler("bmcinfos", BMCInfos).
+		RegisterHandler("machinetypes", MachineTypes).
 		ActivateExplicitly().
 		MustRegister()
 }
@@ -47,15 +50,35 @@ func MachineInfos(mod module.Interface) (handler.Interface, error) {
 		return nil, err
 	}
 	cfg := opts.(*Config)
+	if err := cfg.Prepare(); err != nil {
+		return nil, err
+	}
 
-	mod.Infof("  using server %s:%d", cfg.Host, cfg.Port)
+	endpoints, err := sharedEndpoints(mod, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SRV != "" {
+		mod.Infof("  using servers of %s", cfg.SRV)
+	} else {
+		mod.Infof("  using servers %v", endpoints.Hosts())
+	}
 	mod.Infof("  using cache size %d", cfg.MaxCache)
-	creator, err := machines.NewMachineIndexServerIndexCreator(mod, mod.GetMainCluster(), cfg.Host, cfg.Port, cfg.MaxCache)
+	mod.Infof("  using cache ttl %s", cfg.CacheTTL)
+	mod.Infof("  using %s access", cfg.Scheme())
+	mod.Infof("  using timeout %s with %d retries", cfg.Timeout, cfg.Retries)
+	creator, err := machines.NewMachineIndexServerIndexCreator(mod, mod.GetMainCluster(), endpoints, cfg.MaxCache, cfg.CacheTTL, &cfg.AccessConfig, &cfg.TransportConfig)
 	if err != nil {
 		return nil, err
 	}
-	controllers.GetOrCreateMachineIndex(mod.GetEnvironment(), creator)
-	return &Handler{}, nil
+	index := controllers.GetOrCreateMachineIndex(mod.GetEnvironment(), creator)
+	h := newHandler(mod, cfg, endpoints, index)
+	if s, ok := index.(*machines.MachineIndexServerIndex); ok && cfg.Fallback {
+		fallback := machines.NewFullIndexer()
+		s.SetFallback(fallback)
+		h.fallback = newMachineFallback(mod, fallback)
+	}
+	return h, nil
 }
 
 func BMCInfos(mod module.Interface) (handler.Interface, error) {
@@ -64,14 +87,93 @@ func BMCInfos(mod module.Interface) (handler.Interface, error) {
 		return nil, err
 	}
 	cfg := opts.(*Config)
+	if err := cfg.Prepare(); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := sharedEndpoints(mod, cfg)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := machines.NewBMCIndexServerIndexCreator(mod, mod.GetMainCluster(), endpoints, cfg.MaxCache, cfg.CacheTTL, &cfg.AccessConfig, &cfg.TransportConfig)
+	if err != nil {
+		return nil, err
+	}
+	index := controllers.GetOrCreateBMCIndex(mod.GetEnvironment(), creator)
+	h := newHandler(mod, cfg, endpoints, index)
+	if s, ok := index.(*machines.BMCIndexServerIndex); ok && cfg.Fallback {
+		fallback := machines.NewBMCFullIndexer()
+		s.SetFallback(fallback)
+		h.fallback = newBMCFallback(mod, fallback)
+	}
+	return h, nil
+}
 
-	creator, err := machines.NewBMCIndexServerIndexCreator(mod, mod.GetMainCluster(), cfg.Host, cfg.Port, cfg.MaxCache)
+// sharedEndpoints provides the index server endpoints shared
+// by all handlers of the module.
+func sharedEndpoints(mod module.Interface, cfg *Config) (*machines.Endpoints, error) {
+	var err error
+	endpoints := mod.GetEnvironment().ControllerManager().GetOrCreateSharedValue(NAME+"/endpoints", func() interface{} {
+		var e *machines.Endpoints
+		e, err = machines.NewEndpoints(mod, cfg.hosts, cfg.SRV, &cfg.AccessConfig, &cfg.TransportConfig)
+		return e
+	}).(*machines.Endpoints)
 	if err != nil {
 		return nil, err
 	}
-	controllers.GetOrCreateBMCIndex(mod.GetEnvironment(), creator)
-	return &Handler{}, nil
+	if endpoints == nil {
+		return nil, fmt.Errorf("no index server endpoints")
+	}
+	return endpoints, nil
+}
+
+func MachineTypes(mod module.Interface) (handler.Interface, error) {
+	opts, err := mod.GetOptionSource("options")
+	if err != nil {
+		return nil, err
+	}
+	cfg := opts.(*Config)
+	if err := cfg.Prepare(); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := sharedEndpoints(mod, cfg)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := machines.NewMachineTypeIndexServerIndexCreator(mod, mod.GetMainCluster(), endpoints, cfg.MaxCache, cfg.CacheTTL, &cfg.AccessConfig, &cfg.TransportConfig)
+	if err != nil {
+		return nil, err
+	}
+	index := controllers.GetOrCreateMachineTypeIndex(mod.GetEnvironment(), creator)
+	h := newHandler(mod, cfg, endpoints, index)
+	if s, ok := index.(*machines.MachineTypeIndexServerIndex); ok && cfg.Fallback {
+		fallback := machines.NewTypeFullIndexer()
+		s.SetFallback(fallback)
+		h.fallback = newTypeFallback(mod, fallback)
+	}
+	return h, nil
 }
 
 type Handler struct {
+	module    module.Interface
+	config    *Config
+	endpoints *machines.Endpoints
+	index     interface{}
+	fallback  *fallback
+}
+
+func newHandler(mod module.Interface, cfg *Config, endpoints *machines.Endpoints, index interface{}) *Handler {
+	return &Handler{module: mod, config: cfg, endpoints: endpoints, index: index}
+}
+
+func (this *Handler) Start() error {
+	this.endpoints.Run(this.module.GetContext(), this.config.HealthInterval)
+	if w, ok := this.index.(machines.IndexWatcher); ok && this.config.Watch && this.config.MaxCache > 0 {
+		w.Watch(this.module.GetContext())
+	}
+	if this.fallback != nil {
+		return this.fallback.Start()
+	}
+	return nil
 }
@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/cluster"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/extension"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+type TokenReviewer interface {
+	Review(ctx context.Context, token string) (*Identity, error)
+}
+
+// Authenticator determines the identity of the caller of a request
+// from a verified client certificate or a bearer token and checks
+// the authorization rules for the requested index path.
+type Authenticator struct {
+	logger   logger.LogContext
+	config   *Config
+	reviewer TokenReviewer
+}
+
+func NewAuthenticator(logger logger.LogContext, cfg *Config, reviewer TokenReviewer) *Authenticator {
+	return &Authenticator{logger: logger, config: cfg, reviewer: reviewer}
+}
+
+// Filter wraps a handler for the given path to enforce
+// authentication and authorization.
+func (this *Authenticator) Filter(path string, h http.HandlerFunc) http.HandlerFunc {
+	if !this.config.AuthenticationRequired() {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := this.Authenticate(r)
+		if err != nil {
+			this.logger.Infof("authentication for %s failed: %s", r.URL.Path, err)
+		}
+		if id == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, machines.NewErrorResponse(machines.REASON_UNAUTHORIZED, "authentication required"))
+			return
+		}
+		if !this.Allows(path, id) {
+			this.logger.Infof("access to %s denied for %s", r.URL.Path, id.User)
+			writeError(w, http.StatusForbidden, machines.NewErrorResponse(machines.REASON_FORBIDDEN, fmt.Sprintf("access to %s denied", r.URL.Path)))
+			return
+		}
+		h(w, r.WithContext(this.WithIdentity(r.Context(), id)))
+	}
+}
+
+// Authenticate returns the identity of the caller or nil
+// for an unauthenticated request. Bearer tokens are only
+// accepted on the TLS port.
+func (this *Authenticator) Authenticate(r *http.Request) (*Identity, error) {
+	auth := r.Header.Get("Authorization")
+	if r.TLS == nil && auth != "" {
+		return nil, fmt.Errorf("bearer tokens are only accepted with TLS")
+	}
+	return this.Identify(r.Context(), r.TLS, auth)
+}
+
+// Identify determines the identity of a caller from the state of
+// the TLS connection and the value of the authorization header.
+// It returns nil for an unauthenticated caller.
+func (this *Authenticator) Identify(ctx context.Context, state *tls.ConnectionState, auth string) (*Identity, error) {
+	if state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		cert := state.VerifiedChains[0][0]
+		return &Identity{User: cert.Subject.CommonName, Groups: cert.Subject.Organization}, nil
+	}
+	if auth == "" {
+		return nil, nil
+	}
+	if this.reviewer == nil {
+		return nil, fmt.Errorf("token authentication not enabled")
+	}
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+	token := strings.TrimSpace(auth[len("Bearer "):])
+	if token == "" {
+		return nil, nil
+	}
+	return this.reviewer.Review(ctx, token)
+}
+
+// Required reports whether callers must be authenticated.
+func (this *Authenticator) Required() bool {
+	return this.config.AuthenticationRequired()
+}
+
+// Allows checks the authorization rule of an index path for an
+// authenticated caller. Paths without rule are available for all
+// authenticated callers.
+func (this *Authenticator) Allows(path string, id *Identity) bool {
+	rule := this.config.rules[strings.Trim(path, "/")]
+	return rule == nil || rule.Allows(id)
+}
+
+type accessKey struct{}
+
+type access struct {
+	auth *Authenticator
+	id   *Identity
+}
+
+// WithIdentity returns a context carrying the authenticated caller
+// of a request. Handlers touching further indices check them with
+// Allowed.
+func (this *Authenticator) WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, accessKey{}, &access{auth: this, id: id})
+}
+
+// Allowed checks whether the caller of a request may access the
+// index with the given path. Without an identity in the context,
+// authentication is not required and every index is available.
+func Allowed(ctx context.Context, path string) bool {
+	a, ok := ctx.Value(accessKey{}).(*access)
+	if !ok {
+		return true
+	}
+	return a.auth.Allows(path, a.id)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const REVIEW_CACHE_TTL = time.Minute
+const REVIEW_CACHE_SIZE = 1000
+
+type review struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// tokenReviewer authenticates tokens with kubernetes token reviews.
+// Review results are cached for a short time to avoid an api server
+// round trip for every request.
+type tokenReviewer struct {
+	lock    sync.Mutex
+	client  authclient.TokenReviewInterface
+	reviews map[[sha256.Size]byte]*review
+}
+
+// CreateTokenReviewer provides a token reviewer for the default cluster
+// of the controller manager if token reviews are configured.
+func CreateTokenReviewer(env extension.Environment, cfg *Config) (TokenReviewer, error) {
+	if !cfg.TokenReview {
+		return nil, nil
+	}
+	c := env.GetCluster(cluster.DEFAULT)
+	if c == nil {
+		return nil, fmt.Errorf("token review requires cluster %q", cluster.DEFAULT)
+	}
+	restcfg := c.Config()
+	return NewTokenReviewer(&restcfg)
+}
+
+func NewTokenReviewer(cfg *rest.Config) (TokenReviewer, error) {
+	c, err := authclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenReviewer{
+		client:  c.TokenReviews(),
+		reviews: map[[sha256.Size]byte]*review{},
+	}, nil
+}
+
+func (this *tokenReviewer) Review(ctx context.Context, token string) (*Identity, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	this.lock.Lock()
+	r := this.reviews[key]
+	this.lock.Unlock()
+	if r != nil && now.Before(r.expires) {
+		return r.identity, nil
+	}
+
+	result, err := this.client.Create(ctx, &authv1.TokenReview{Spec: authv1.TokenReviewSpec{Token: token}}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %s", err)
+	}
+	r = &review{expires: now.Add(REVIEW_CACHE_TTL)}
+	if result.Status.Authenticated {
+		r.identity = &Identity{User: result.Status.User.Username, Groups: result.Status.User.Groups}
+	} else if result.Status.Error != "" {
+		err = fmt.Errorf("token rejected: %s", result.Status.Error)
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if len(this.reviews) >= REVIEW_CACHE_SIZE {
+		for k, e := range this.reviews {
+			if !now.Before(e.expires) {
+				delete(this.reviews, k)
+			}
+		}
+		if len(this.reviews) >= REVIEW_CACHE_SIZE {
+			this.reviews = map[[sha256.Size]byte]*review{}
+		}
+	}
+	this.reviews[key] = r
+	return r.identity, err
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type reviewer map[string]*Identity
+
+func (this reviewer) Review(ctx context.Context, token string) (*Identity, error) {
+	return this[token], nil
+}
+
+var _ = Describe("Authentication", func() {
+	tokens := reviewer{
+		"admin":    &Identity{User: "admin"},
+		"operator": &Identity{User: "system:serviceaccount:metal:operator", Groups: []string{"metal"}},
+	}
+
+	request := func(auth *Authenticator, path string, token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/"+path, nil)
+		r.TLS = &tls.ConnectionState{}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		auth.Filter(path, func(w http.ResponseWriter, r *http.Request) {})(w, r)
+		return w.Code
+	}
+
+	config := func(rules ...string) *Config {
+		cfg := &Config{TokenReview: true, Authorize: rules}
+		Expect(cfg.Prepare()).To(Succeed())
+		return cfg
+	}
+
+	It("parses rules", func() {
+		r, err := ParseRule("/bmc/=user:admin, group:metal")
+		Expect(err).To(Succeed())
+		Expect(r).To(Equal(&Rule{Path: "bmc", Subjects: []string{"user:admin", "group:metal"}}))
+
+		_, err = ParseRule("bmc")
+		Expect(err).NotTo(Succeed())
+		_, err = ParseRule("bmc=admin")
+		Expect(err).NotTo(Succeed())
+	})
+
+	It("passes all requests without authentication", func() {
+		cfg := &Config{}
+		Expect(cfg.Prepare()).To(Succeed())
+		auth := NewAuthenticator(logger.New(), cfg, nil)
+		Expect(request(auth, "bmc", "")).To(Equal(http.StatusOK))
+	})
+
+	It("rejects unauthenticated requests", func() {
+		auth := NewAuthenticator(logger.New(), config(), tokens)
+		Expect(request(auth, "info", "")).To(Equal(http.StatusUnauthorized))
+		Expect(request(auth, "info", "unknown")).To(Equal(http.StatusUnauthorized))
+		Expect(request(auth, "info", "admin")).To(Equal(http.StatusOK))
+	})
+
+	It("rejects tokens without TLS", func() {
+		auth := NewAuthenticator(logger.New(), config(), tokens)
+		r := httptest.NewRequest(http.MethodGet, "/info", nil)
+		r.Header.Set("Authorization", "Bearer admin")
+
+		id, err := auth.Authenticate(r)
+		Expect(err).NotTo(Succeed())
+		Expect(id).To(BeNil())
+	})
+
+	It("disables the plain port for TLS and authentication", func() {
+		cfg := &Config{ServeInsecure: true}
+		Expect(cfg.Prepare()).To(Succeed())
+		Expect(cfg.ServeInsecure).To(BeTrue())
+
+		cfg = &Config{ServeInsecure: true, TokenReview: true}
+		Expect(cfg.Prepare()).To(Succeed())
+		Expect(cfg.ServeInsecure).To(BeFalse())
+
+		cfg = &Config{ServeInsecure: true, TLSPort: 8443, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}
+		Expect(cfg.Prepare()).To(Succeed())
+		Expect(cfg.ServeInsecure).To(BeFalse())
+	})
+
+	It("authorizes by path", func() {
+		auth := NewAuthenticator(logger.New(), config("bmc=user:admin", "type=group:metal", "info=authenticated"), tokens)
+		Expect(request(auth, "bmc", "admin")).To(Equal(http.StatusOK))
+		Expect(request(auth, "bmc", "operator")).To(Equal(http.StatusForbidden))
+		Expect(request(auth, "type", "admin")).To(Equal(http.StatusForbidden))
+		Expect(request(auth, "type", "operator")).To(Equal(http.StatusOK))
+		Expect(request(auth, "info", "operator")).To(Equal(http.StatusOK))
+		Expect(request(auth, "batch", "operator")).To(Equal(http.StatusOK))
+	})
+
+	It("uses identity of verified client certificate", func() {
+		auth := NewAuthenticator(logger.New(), config("bmc=group:metal"), nil)
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client", Organization: []string{"metal"}}}
+		r := httptest.NewRequest(http.MethodGet, "/bmc", nil)
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+		id, err := auth.Authenticate(r)
+		Expect(err).To(Succeed())
+		Expect(id).To(Equal(&Identity{User: "client", Groups: []string{"metal"}}))
+	})
+})
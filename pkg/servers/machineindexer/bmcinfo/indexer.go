@@ -23,6 +23,7 @@
 package bmcinfo
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/onmetal/k8s-machines/pkg/controllers"
@@ -50,43 +51,125 @@ func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
 func (this *indexer) Setup() error {
 	this.index = controllers.GetOrCreateBMCIndex(this.server.GetEnvironment(), func() machines.BMCIndex { return machines.NewBMCFullIndexer() })
 	this.server.Register(machines.PATH_BMCINFO, this.handler)
+	this.server.RegisterBatch(machines.PATH_BMCINFO, this)
+	if n, ok := this.index.(machines.IndexNotifier); ok {
+		this.server.RegisterWatch(machines.PATH_BMCINFO, n)
+	}
 	return nil
 }
 
-func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
-	var found *machines.BaseBoardManagementController
+func (this *indexer) IsInitialized() bool {
+	return this.index != nil && this.index.IsInitialized()
+}
+
+func (this *indexer) Status() *machineindexer.IndexStatus {
+	return machineindexer.NewIndexStatus(machines.PATH_BMCINFO, this.index)
+}
+
+// ForNamespace provides the batch lookups restricted to a namespace.
+func (this *indexer) ForNamespace(namespace string) machineindexer.BatchIndex {
+	index := machines.BMCIndexForNamespace(this.index, namespace)
+	if index == nil {
+		return nil
+	}
+	return &indexer{server: this.server, index: index}
+}
+
+func (this *indexer) LookupUUID(uuid string) *machines.BatchResult {
+	if names := machineindexer.Claims(this.index, machines.KEY_UUID, uuid); names != nil {
+		return machineindexer.AmbiguousResult(names)
+	}
+	return result(this.index.GetByUUID(uuid))
+}
+
+func (this *indexer) LookupMAC(mac string) *machines.BatchResult {
+	if names := machineindexer.Claims(this.index, machines.KEY_MAC, mac); names != nil {
+		return machineindexer.AmbiguousResult(names)
+	}
+	return result(this.index.GetByMAC(mac))
+}
 
+func result(m *machines.BaseBoardManagementController) *machines.BatchResult {
+	if m == nil {
+		return machines.NewBatchResult(nil, "")
+	}
+	return machines.NewBatchResult(m.Name, m.ResourceVersion)
+}
+
+func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
 	this.server.Infof("query bmc info: %s", r.URL.RawQuery)
 	if this.index == nil || !this.index.IsInitialized() {
-		this.server.Error("no bmc index found")
-		w.WriteHeader(http.StatusNotAcceptable)
+		this.server.NotInitializedResponse(w, machines.PATH_BMCINFO)
 		return
 	}
-	uuids, macs := this.server.MachineIds(r)
-	for _, mac := range macs {
-		m := this.index.GetByMAC(mac)
-		if m != nil {
-			if found != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			found = m
+	uuids, macs, err := this.server.MachineIds(r)
+	if err != nil {
+		this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, err.Error())
+		return
+	}
+	machineindexer.MarkStale(w, this.index)
+	index := this.index
+	if ns := r.URL.Query().Get(machines.QUERY_NAMESPACE); ns != "" {
+		if index = machines.BMCIndexForNamespace(this.index, ns); index == nil {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, "namespace lookups not supported by bmc info index")
+			return
 		}
 	}
-	for _, uuid := range uuids {
-		m := this.index.GetByUUID(uuid)
-		if m != nil {
-			if found != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			found = m
+	matches := machineindexer.NewMatches()
+	values := r.URL.Query()
+	keys := map[string][]string{
+		machines.KEY_MAC:      macs,
+		machines.KEY_UUID:     uuids,
+		machines.KEY_IP:       values[machines.KEY_IP],
+		machines.KEY_SERIAL:   values[machines.KEY_SERIAL],
+		machines.KEY_ASSETTAG: values[machines.KEY_ASSETTAG],
+	}
+	for _, kind := range []string{machines.KEY_MAC, machines.KEY_UUID, machines.KEY_IP, machines.KEY_SERIAL, machines.KEY_ASSETTAG} {
+		if err := this.lookup(r.Context(), index, matches, kind, keys[kind]); err != nil {
+			this.server.LookupErrorResponse(w, err)
+			return
 		}
 	}
+	if matches.Ambiguous() {
+		machineindexer.CountLookup(machines.PATH_BMCINFO, machineindexer.LOOKUP_AMBIGUOUS)
+		this.server.AmbiguousResponse(w, matches)
+		return
+	}
+	found, _ := matches.Found().(*machines.BaseBoardManagementController)
 	if found != nil {
+		machineindexer.CountLookup(machines.PATH_BMCINFO, machineindexer.LOOKUP_HIT)
 		w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
-		this.server.ObjectResponse(w, found.Name)
+		if this.server.FullView(r) {
+			this.server.JSONResponse(w, &machines.BMCInfoResponse{
+				IndexResponse: machines.NewIndexResponse(found.Name, found.ResourceVersion),
+				Spec:          machines.RedactBMC(found).BaseBoardManagementControllerInfoSpec,
+			})
+		} else {
+			this.server.ObjectResponse(w, found.Name)
+		}
 	} else {
-		w.WriteHeader(http.StatusNotFound)
+		machineindexer.CountLookup(machines.PATH_BMCINFO, machineindexer.LOOKUP_MISS)
+		this.server.ErrorResponse(w, http.StatusNotFound, machines.REASON_NOT_FOUND, "no bmc info found")
 	}
 }
+
+// lookup adds the matches for the given keys of a key kind.
+func (this *indexer) lookup(ctx context.Context, index machines.BMCIndex, matches *machineindexer.Matches, kind string, keys []string) error {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if names := machineindexer.Claims(index, kind, key); names != nil {
+			matches.AddClaims(kind, key, names)
+			continue
+		}
+		m, err := machines.LookupBMC(ctx, index, kind, key)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			matches.Add(kind, key, m.Name, m)
+		}
+	}
+	return nil
+}
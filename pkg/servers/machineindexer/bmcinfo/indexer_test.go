@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package bmcinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// server provides the parts of the index server used by successful lookups.
+type server struct {
+	machineindexer.IndexServer
+}
+
+func (this *server) Infof(msgfmt string, args ...interface{}) {}
+
+func (this *server) MachineIds(r *http.Request) ([]string, []string, error) {
+	return r.URL.Query()["uuid"], nil, nil
+}
+
+func (this *server) FullView(r *http.Request) bool {
+	return r.URL.Query().Get(machines.QUERY_VIEW) == machines.VIEW_FULL
+}
+
+func (this *server) JSONResponse(w http.ResponseWriter, obj interface{}) {
+	json.NewEncoder(w).Encode(obj)
+}
+
+// initialized is a BMC index considered as synchronized.
+type initialized struct {
+	machines.BMCIndexer
+}
+
+func (this *initialized) IsInitialized() bool { return true }
+
+var _ = Describe("BMC Info Index", func() {
+	It("does not return credentials", func() {
+		b := &api.BaseBoardManagementControllerInfo{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b1"},
+		}
+		b.Spec.UUID = "u1"
+		b.Spec.Credentials = &api.BasicAuthCredentials{User: "admin", Password: "secret"}
+		bmc, err := machines.NewBaseBoardManagementController(b)
+		Expect(err).To(Succeed())
+		index := machines.NewBMCFullIndexer()
+		index.Set(bmc)
+
+		h := &indexer{server: &server{}, index: &initialized{index}}
+		w := httptest.NewRecorder()
+		h.handler(w, httptest.NewRequest(http.MethodGet, "/bmc?uuid=u1&view=full", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		resp := &machines.BMCInfoResponse{}
+		Expect(json.Unmarshal(w.Body.Bytes(), resp)).To(Succeed())
+		Expect(resp.Name).To(Equal("b1"))
+		Expect(resp.Spec.UUID).To(Equal("u1"))
+		Expect(resp.Spec.Credentials).To(BeNil())
+		Expect(w.Body.String()).NotTo(ContainSubstring("secret"))
+		Expect(bmc.Credentials).NotTo(BeNil())
+	})
+	It("restricts batch lookups to a namespace", func() {
+		index := machines.NewBMCFullIndexer()
+		for _, ns := range []string{"tenant-a", "tenant-b"} {
+			b := &api.BaseBoardManagementControllerInfo{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "b1"},
+			}
+			b.Spec.UUID = "u1"
+			bmc, err := machines.NewBaseBoardManagementController(b)
+			Expect(err).To(Succeed())
+			index.Set(bmc)
+		}
+
+		h := &indexer{server: &server{}, index: index}
+		Expect(h.LookupUUID("u1").Status).To(Equal(machines.STATUS_AMBIGUOUS))
+		r := h.ForNamespace("tenant-b").LookupUUID("u1")
+		Expect(r.Status).To(Equal(machines.STATUS_FOUND))
+		Expect(r.Namespace).To(Equal("tenant-b"))
+		Expect(h.ForNamespace("other").LookupUUID("u1").Status).To(Equal(machines.STATUS_NOTFOUND))
+	})
+})
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/config"
+)
+
+// Config describes the secure serving and the access control
+// of the index server.
+type Config struct {
+	ServeInsecure     bool
+	TLSPort           int
+	TLSCertFile       string
+	TLSKeyFile        string
+	ClientCAFile      string
+	RequireClientCert bool
+	TokenReview       bool
+	Authorize         []string
+
+	options config.Options
+	rules   map[string]*Rule
+}
+
+func (this *Config) AddOptionsToSet(set config.OptionSet) {
+	this.options = set
+	set.AddBoolOption(&this.ServeInsecure, "serve-insecure", "", true, "serve indices on the plain server port (default false if TLS or authentication is configured)")
+	set.AddIntOption(&this.TLSPort, "tls-port", "", 0, "port for serving indices with TLS (0=disabled)")
+	set.AddStringOption(&this.TLSCertFile, "tls-cert-file", "", "", "server certificate file for TLS port")
+	set.AddStringOption(&this.TLSKeyFile, "tls-key-file", "", "", "server certificate key file for TLS port")
+	set.AddStringOption(&this.ClientCAFile, "client-ca-file", "", "", "ca file used to verify client certificates on TLS port")
+	set.AddBoolOption(&this.RequireClientCert, "require-client-cert", "", false, "require client certificates on TLS port")
+	set.AddBoolOption(&this.TokenReview, "token-review", "", false, "authenticate bearer tokens with kubernetes token reviews")
+	set.AddStringArrayOption(&this.Authorize, "authorize", "", nil, "authorization rule for index path (<path>=<subject>{,<subject>}, subject: user:<name>, group:<name> or authenticated)")
+}
+
+func (this *Config) Prepare() error {
+	if this.TLSPort > 0 && (this.TLSCertFile == "" || this.TLSKeyFile == "") {
+		return fmt.Errorf("TLS port requires server certificate and key file")
+	}
+	if this.TLSPort <= 0 && this.ClientCAFile != "" {
+		return fmt.Errorf("client ca file requires TLS port")
+	}
+	if this.RequireClientCert && this.ClientCAFile == "" {
+		return fmt.Errorf("client certificates require client ca file")
+	}
+	this.rules = map[string]*Rule{}
+	for _, a := range this.Authorize {
+		r, err := ParseRule(a)
+		if err != nil {
+			return err
+		}
+		if old := this.rules[r.Path]; old != nil {
+			old.Subjects = append(old.Subjects, r.Subjects...)
+		} else {
+			this.rules[r.Path] = r
+		}
+	}
+	if (this.TLSPort > 0 || this.AuthenticationRequired()) && !this.changed("serve-insecure") {
+		this.ServeInsecure = false
+	}
+	return nil
+}
+
+func (this *Config) changed(name string) bool {
+	if this.options == nil {
+		return false
+	}
+	o := this.options.GetOption(name)
+	return o != nil && o.Changed()
+}
+
+// AuthenticationRequired reports whether requests must be authenticated.
+func (this *Config) AuthenticationRequired() bool {
+	return this.TokenReview || this.ClientCAFile != "" || len(this.rules) > 0
+}
+
+// TLSConfig provides the TLS configuration for the server certificate
+// and the verification of client certificates.
+func (this *Config) TLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(this.TLSCertFile, this.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load server certificate: %s", err)
+	}
+	tlscfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if this.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(this.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read client ca file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in client ca file %q", this.ClientCAFile)
+		}
+		tlscfg.ClientCAs = pool
+		tlscfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if this.RequireClientCert {
+			tlscfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlscfg, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const SUBJECT_AUTHENTICATED = "authenticated"
+const SUBJECT_USER = "user:"
+const SUBJECT_GROUP = "group:"
+
+// Rule restricts the access to an index path to a set of subjects.
+type Rule struct {
+	Path     string
+	Subjects []string
+}
+
+func ParseRule(s string) (*Rule, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid authorization rule %q: <path>=<subjects> expected", s)
+	}
+	r := &Rule{Path: strings.Trim(strings.TrimSpace(s[:i]), "/")}
+	for _, sub := range strings.Split(s[i+1:], ",") {
+		sub = strings.TrimSpace(sub)
+		switch {
+		case sub == SUBJECT_AUTHENTICATED:
+		case strings.HasPrefix(sub, SUBJECT_USER) && len(sub) > len(SUBJECT_USER):
+		case strings.HasPrefix(sub, SUBJECT_GROUP) && len(sub) > len(SUBJECT_GROUP):
+		default:
+			return nil, fmt.Errorf("invalid subject %q in authorization rule %q", sub, s)
+		}
+		r.Subjects = append(r.Subjects, sub)
+	}
+	return r, nil
+}
+
+// Allows checks whether the identity matches one of the subjects of the rule.
+func (this *Rule) Allows(id *Identity) bool {
+	for _, sub := range this.Subjects {
+		switch {
+		case sub == SUBJECT_AUTHENTICATED:
+			return true
+		case strings.HasPrefix(sub, SUBJECT_USER):
+			if sub[len(SUBJECT_USER):] == id.User {
+				return true
+			}
+		case strings.HasPrefix(sub, SUBJECT_GROUP):
+			for _, g := range id.Groups {
+				if sub[len(SUBJECT_GROUP):] == g {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// RETRY_AFTER is the delay in seconds suggested to clients
+// while an index is syncing.
+const RETRY_AFTER = 5
+
+// writeError writes a JSON error body with the given status code.
+func writeError(w http.ResponseWriter, status int, resp *machines.IndexResponse) {
+	w.Header().Set(CONTENT_TYPE, "application/json")
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(RETRY_AFTER))
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (this *requesthandler) ErrorResponse(w http.ResponseWriter, status int, reason string, msg string) {
+	writeError(w, status, machines.NewErrorResponse(reason, msg))
+}
+
+func (this *requesthandler) NotInitializedResponse(w http.ResponseWriter, path string) {
+	this.Infof("index %q not initialized", path)
+	writeError(w, http.StatusServiceUnavailable, machines.NewErrorResponse(machines.REASON_NOT_INITIALIZED, "index "+path+" is syncing"))
+}
+
+func (this *requesthandler) AmbiguousResponse(w http.ResponseWriter, matches *Matches) {
+	writeError(w, http.StatusConflict, machines.NewErrorResponse(machines.REASON_AMBIGUOUS, "keys match different objects", matches.Matches()...))
+}
+
+// LookupErrorResponse reports a failed lookup of an index backed by
+// another index server. Error responses of this server are passed on.
+func (this *requesthandler) LookupErrorResponse(w http.ResponseWriter, err error) {
+	this.Warnf("lookup failed: %s", err)
+	if e, ok := err.(*machines.IndexError); ok {
+		writeError(w, e.StatusCode, machines.NewErrorResponse(e.Reason, e.Message, e.Conflicts...))
+		return
+	}
+	writeError(w, http.StatusServiceUnavailable, machines.NewErrorResponse(machines.REASON_UNAVAILABLE, err.Error()))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Matches collects the objects found for the keys of a query.
+type Matches struct {
+	matches []*machines.KeyMatch
+	objects map[resources.ObjectName]interface{}
+	first   interface{}
+}
+
+func NewMatches() *Matches {
+	return &Matches{objects: map[resources.ObjectName]interface{}{}}
+}
+
+// Add records the object found for a key.
+func (this *Matches) Add(kind, key string, name resources.ObjectName, obj interface{}) {
+	this.matches = append(this.matches, &machines.KeyMatch{
+		Kind:      kind,
+		Key:       key,
+		Cluster:   machines.ClusterOf(name),
+		Name:      name.Name(),
+		Namespace: name.Namespace(),
+	})
+	if this.objects[name] == nil {
+		this.objects[name] = obj
+		if this.first == nil {
+			this.first = obj
+		}
+	}
+}
+
+// AddClaims records all objects claiming an ambiguous key.
+func (this *Matches) AddClaims(kind, key string, names []resources.ObjectName) {
+	for _, n := range names {
+		this.Add(kind, key, n, n)
+	}
+}
+
+// Found returns the single found object or nil.
+func (this *Matches) Found() interface{} {
+	if this.Ambiguous() {
+		return nil
+	}
+	return this.first
+}
+
+// Ambiguous reports whether the keys match different objects.
+func (this *Matches) Ambiguous() bool {
+	return len(this.objects) > 1
+}
+
+func (this *Matches) Matches() []*machines.KeyMatch {
+	return this.matches
+}
+
+// Claims returns the objects claiming an ambiguous key of an index
+// implementing machines.ClaimIndex. It returns nil for unique keys.
+func Claims(index interface{}, kind, key string) []resources.ObjectName {
+	c, ok := index.(machines.ClaimIndex)
+	if !ok {
+		return nil
+	}
+	var names []resources.ObjectName
+	switch kind {
+	case machines.KEY_MAC:
+		names = c.ClaimsForMAC(key)
+	case machines.KEY_UUID:
+		names = c.ClaimsForUUID(key)
+	case machines.KEY_IP, machines.KEY_SERIAL, machines.KEY_ASSETTAG:
+		b, ok := index.(machines.BMCClaimIndex)
+		if !ok {
+			return nil
+		}
+		switch kind {
+		case machines.KEY_IP:
+			names = b.ClaimsForIP(key)
+		case machines.KEY_SERIAL:
+			names = b.ClaimsForSerial(key)
+		default:
+			names = b.ClaimsForAssetTag(key)
+		}
+	}
+	if len(names) < 2 {
+		return nil
+	}
+	return names
+}
+
+// AmbiguousResult returns the batch result for an ambiguous key.
+func AmbiguousResult(names []resources.ObjectName) *machines.BatchResult {
+	r := &machines.BatchResult{Status: machines.STATUS_AMBIGUOUS}
+	r.Error = "key claimed by several objects"
+	r.Reason = machines.REASON_AMBIGUOUS
+	for _, n := range names {
+		r.Conflicts = append(r.Conflicts, &machines.KeyMatch{Cluster: machines.ClusterOf(n), Name: n.Name(), Namespace: n.Namespace()})
+	}
+	return r
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// paths maps the methods of the service to the index paths
+// used by the authorization rules.
+var paths = map[string]string{
+	MachineIndex_GetMachineByMAC_FullMethodName:      machines.PATH_MACHINEINFO,
+	MachineIndex_GetMachineByUUID_FullMethodName:     machines.PATH_MACHINEINFO,
+	MachineIndex_GetMachineByName_FullMethodName:     machines.PATH_MACHINEINFO,
+	MachineIndex_GetBMCByMAC_FullMethodName:          machines.PATH_BMCINFO,
+	MachineIndex_GetBMCByUUID_FullMethodName:         machines.PATH_BMCINFO,
+	MachineIndex_GetBMCByName_FullMethodName:         machines.PATH_BMCINFO,
+	MachineIndex_GetMachineTypeByMAC_FullMethodName:  machines.PATH_MACHINETYPE,
+	MachineIndex_GetMachineTypeByName_FullMethodName: machines.PATH_MACHINETYPE,
+	MachineIndex_Watch_FullMethodName:                machines.PATH_WATCH,
+}
+
+// authorizer enforces the access control of the index server
+// for the methods of the gRPC service.
+type authorizer struct {
+	logger logger.LogContext
+	auth   *machineindexer.Authenticator
+}
+
+func (this *authorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := this.check(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (this *authorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := this.check(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ss, ctx})
+}
+
+// check authorizes the call of a method and returns the context
+// carrying the identity of the caller.
+func (this *authorizer) check(ctx context.Context, method string) (context.Context, error) {
+	path, ok := paths[method]
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	id, err := this.auth.Identify(ctx, tlsState(ctx), authorization(ctx))
+	if err != nil {
+		this.logger.Infof("authentication for %s failed: %s", method, err)
+	}
+	if id == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if !this.auth.Allows(path, id) {
+		this.logger.Infof("access to %s denied for %s", method, id.User)
+		return nil, status.Errorf(codes.PermissionDenied, "access to %s denied", path)
+	}
+	return this.auth.WithIdentity(ctx, id), nil
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (this *serverStream) Context() context.Context {
+	return this.ctx
+}
+
+func tlsState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		return &info.State
+	}
+	return nil
+}
+
+func authorization(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get("authorization") {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/gardener/controller-manager-library/pkg/config"
+
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// Config describes the gRPC port. TLS and the access control are
+// configured like for the TLS port of the http index server.
+type Config struct {
+	BindAddress string
+	Port        int
+
+	machineindexer.Config
+}
+
+func (this *Config) AddOptionsToSet(set config.OptionSet) {
+	set.AddStringOption(&this.BindAddress, "grpc-bind-address", "", "", "bind address for the gRPC machine index server")
+	set.AddIntOption(&this.Port, "grpc-port", "", 8091, "port of the gRPC machine index server")
+	set.AddStringOption(&this.TLSCertFile, "grpc-tls-cert-file", "", "", "server certificate file for the gRPC port")
+	set.AddStringOption(&this.TLSKeyFile, "grpc-tls-key-file", "", "", "server certificate key file for the gRPC port")
+	set.AddStringOption(&this.ClientCAFile, "grpc-client-ca-file", "", "", "ca file used to verify client certificates on the gRPC port")
+	set.AddBoolOption(&this.RequireClientCert, "grpc-require-client-cert", "", false, "require client certificates on the gRPC port")
+	set.AddBoolOption(&this.TokenReview, "grpc-token-review", "", false, "authenticate bearer tokens on the gRPC port with kubernetes token reviews")
+	set.AddStringArrayOption(&this.Authorize, "grpc-authorize", "", nil, "authorization rule for index path on the gRPC port (<path>=<subject>{,<subject>}, subject: user:<name>, group:<name> or authenticated)")
+}
+
+func (this *Config) Prepare() error {
+	// the gRPC port is the TLS port if a server certificate is configured
+	this.TLSPort = 0
+	if this.TLSCertFile != "" || this.TLSKeyFile != "" {
+		this.TLSPort = this.Port
+	}
+	err := this.Config.Prepare()
+	if err != nil {
+		return err
+	}
+	if this.TLSPort <= 0 && this.AuthenticationRequired() {
+		return fmt.Errorf("authentication on the gRPC port requires TLS")
+	}
+	return nil
+}
+
+// TLS reports whether the gRPC port is served with TLS.
+func (this *Config) TLS() bool {
+	return this.TLSPort > 0
+}
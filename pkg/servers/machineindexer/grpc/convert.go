@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+package grpc
+
+import (
+	"encoding/json"
+
+	"github.com/gardener/controller-manager-library/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+)
+
+// Conversion of the v1alpha1 specs into the messages of the API.
+// The schemaless values are passed as protobuf structs.
+
+func machineInfoSpec(spec *api.MachineInfoSpec) (*MachineInfoSpec, error) {
+	values, err := structOf(spec.Values)
+	if err != nil {
+		return nil, err
+	}
+	r := &MachineInfoSpec{Uuid: spec.UUID, Values: values}
+	for _, n := range spec.NICs {
+		r.Nics = append(r.Nics, &NIC{Name: n.Name, Mac: n.MAC, Bandwidth: int64(n.Bandwidth)})
+	}
+	for _, c := range spec.CPUs {
+		r.Cpus = append(r.Cpus, &CPU{CpuInfo: c.CPUInfo, BogoMips: int64(c.BogoMips), Mhz: int64(c.MHZ), Cores: int64(c.Cores)})
+	}
+	for _, m := range spec.Memory {
+		numa, err := structOf(m.Numa)
+		if err != nil {
+			return nil, err
+		}
+		r.Memory = append(r.Memory, &Memory{Size: int64(m.Size), Numa: numa})
+	}
+	for _, d := range spec.Disks {
+		r.Disks = append(r.Disks, &Disk{Id: d.Id, Name: d.Name, Type: d.Type, Size: int64(d.Size)})
+	}
+	return r, nil
+}
+
+// bmcSpec converts a BMC spec. There is no field for the credentials.
+func bmcSpec(spec *api.BaseBoardManagementControllerInfoSpec) (*BMCSpec, error) {
+	values, err := structOf(spec.Values)
+	if err != nil {
+		return nil, err
+	}
+	r := &BMCSpec{
+		Uuid:       spec.UUID,
+		BmcVersion: spec.BMCVersion,
+		Nic:        spec.NIC,
+		Ip:         spec.IP,
+		Mac:        spec.MAC,
+		Values:     values,
+	}
+	for _, f := range spec.FRUs {
+		fru := &FieldReplaceableUnit{Id: f.ID, Description: f.Description}
+		if fru.Chassis, err = fruInfo(f.Chassis); err != nil {
+			return nil, err
+		}
+		if fru.Board, err = fruInfo(f.Board); err != nil {
+			return nil, err
+		}
+		if fru.Product, err = fruInfo(f.Product); err != nil {
+			return nil, err
+		}
+		r.Frus = append(r.Frus, fru)
+	}
+	return r, nil
+}
+
+func fruInfo(info *api.FieldReplacableUnitInfo) (*FieldReplaceableUnitInfo, error) {
+	if info == nil {
+		return nil, nil
+	}
+	values, err := structOf(info.Values)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldReplaceableUnitInfo{
+		Name:         info.Name,
+		Type:         info.Type,
+		Serial:       info.Serial,
+		Manufacturer: info.Manufacturer,
+		MfgDate:      info.MfgData,
+		PartNumber:   info.PartNumber,
+		Version:      info.Version,
+		AssetTag:     info.AssetTag,
+		Extra:        info.Extra,
+		Values:       values,
+	}, nil
+}
+
+func machineTypeSpec(spec *api.MachineTypeSpec) (*MachineTypeSpec, error) {
+	values, err := structOf(spec.Values)
+	if err != nil {
+		return nil, err
+	}
+	return &MachineTypeSpec{
+		Manufacturer: spec.Manufacturer,
+		Type:         spec.Type,
+		MacPrefixes:  spec.MACPrefixes,
+		Values:       values,
+	}, nil
+}
+
+// structOf converts schemaless values via their JSON representation,
+// which is already restricted to the types supported by a struct.
+func structOf(values types.Values) (*structpb.Struct, error) {
+	if values.Values == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot marshal values: %s", err)
+	}
+	r := &structpb.Struct{}
+	if err := r.UnmarshalJSON(data); err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot convert values: %s", err)
+	}
+	return r, nil
+}
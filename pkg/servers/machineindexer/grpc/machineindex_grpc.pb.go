@@ -0,0 +1,469 @@
+//
+// Copyright (c) 2020 by The metal-stack Authors.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Version 1 of the gRPC API of the machine index server.
+//
+// The Go code is generated with protoc-gen-go and protoc-gen-go-grpc
+// (see go:generate in server.go).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: machineindex.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MachineIndex_GetMachineByMAC_FullMethodName      = "/machineindex.v1.MachineIndex/GetMachineByMAC"
+	MachineIndex_GetMachineByUUID_FullMethodName     = "/machineindex.v1.MachineIndex/GetMachineByUUID"
+	MachineIndex_GetMachineByName_FullMethodName     = "/machineindex.v1.MachineIndex/GetMachineByName"
+	MachineIndex_GetBMCByMAC_FullMethodName          = "/machineindex.v1.MachineIndex/GetBMCByMAC"
+	MachineIndex_GetBMCByUUID_FullMethodName         = "/machineindex.v1.MachineIndex/GetBMCByUUID"
+	MachineIndex_GetBMCByName_FullMethodName         = "/machineindex.v1.MachineIndex/GetBMCByName"
+	MachineIndex_GetMachineTypeByMAC_FullMethodName  = "/machineindex.v1.MachineIndex/GetMachineTypeByMAC"
+	MachineIndex_GetMachineTypeByName_FullMethodName = "/machineindex.v1.MachineIndex/GetMachineTypeByName"
+	MachineIndex_Watch_FullMethodName                = "/machineindex.v1.MachineIndex/Watch"
+)
+
+// MachineIndexClient is the client API for MachineIndex service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MachineIndexClient interface {
+	GetMachineByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*Machine, error)
+	GetMachineByUUID(ctx context.Context, in *UUIDRequest, opts ...grpc.CallOption) (*Machine, error)
+	GetMachineByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*Machine, error)
+	GetBMCByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*BMC, error)
+	GetBMCByUUID(ctx context.Context, in *UUIDRequest, opts ...grpc.CallOption) (*BMC, error)
+	GetBMCByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*BMC, error)
+	GetMachineTypeByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*MachineType, error)
+	GetMachineTypeByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*MachineType, error)
+	// Watch streams the changes of the indices. Without since the
+	// watch starts with the next change, otherwise with the first change
+	// after the given sequence number (0 for all available changes). If
+	// the requested changes are not available anymore an event of type
+	// "reset" is sent first.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MachineIndex_WatchClient, error)
+}
+
+type machineIndexClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMachineIndexClient(cc grpc.ClientConnInterface) MachineIndexClient {
+	return &machineIndexClient{cc}
+}
+
+func (c *machineIndexClient) GetMachineByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*Machine, error) {
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineIndex_GetMachineByMAC_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetMachineByUUID(ctx context.Context, in *UUIDRequest, opts ...grpc.CallOption) (*Machine, error) {
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineIndex_GetMachineByUUID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetMachineByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*Machine, error) {
+	out := new(Machine)
+	err := c.cc.Invoke(ctx, MachineIndex_GetMachineByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetBMCByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*BMC, error) {
+	out := new(BMC)
+	err := c.cc.Invoke(ctx, MachineIndex_GetBMCByMAC_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetBMCByUUID(ctx context.Context, in *UUIDRequest, opts ...grpc.CallOption) (*BMC, error) {
+	out := new(BMC)
+	err := c.cc.Invoke(ctx, MachineIndex_GetBMCByUUID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetBMCByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*BMC, error) {
+	out := new(BMC)
+	err := c.cc.Invoke(ctx, MachineIndex_GetBMCByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetMachineTypeByMAC(ctx context.Context, in *MACRequest, opts ...grpc.CallOption) (*MachineType, error) {
+	out := new(MachineType)
+	err := c.cc.Invoke(ctx, MachineIndex_GetMachineTypeByMAC_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) GetMachineTypeByName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*MachineType, error) {
+	out := new(MachineType)
+	err := c.cc.Invoke(ctx, MachineIndex_GetMachineTypeByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineIndexClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MachineIndex_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MachineIndex_ServiceDesc.Streams[0], MachineIndex_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &machineIndexWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MachineIndex_WatchClient interface {
+	Recv() (*IndexEvent, error)
+	grpc.ClientStream
+}
+
+type machineIndexWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *machineIndexWatchClient) Recv() (*IndexEvent, error) {
+	m := new(IndexEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MachineIndexServer is the server API for MachineIndex service.
+// All implementations must embed UnimplementedMachineIndexServer
+// for forward compatibility
+type MachineIndexServer interface {
+	GetMachineByMAC(context.Context, *MACRequest) (*Machine, error)
+	GetMachineByUUID(context.Context, *UUIDRequest) (*Machine, error)
+	GetMachineByName(context.Context, *NameRequest) (*Machine, error)
+	GetBMCByMAC(context.Context, *MACRequest) (*BMC, error)
+	GetBMCByUUID(context.Context, *UUIDRequest) (*BMC, error)
+	GetBMCByName(context.Context, *NameRequest) (*BMC, error)
+	GetMachineTypeByMAC(context.Context, *MACRequest) (*MachineType, error)
+	GetMachineTypeByName(context.Context, *NameRequest) (*MachineType, error)
+	// Watch streams the changes of the indices. Without since the
+	// watch starts with the next change, otherwise with the first change
+	// after the given sequence number (0 for all available changes). If
+	// the requested changes are not available anymore an event of type
+	// "reset" is sent first.
+	Watch(*WatchRequest, MachineIndex_WatchServer) error
+	mustEmbedUnimplementedMachineIndexServer()
+}
+
+// UnimplementedMachineIndexServer must be embedded to have forward compatible implementations.
+type UnimplementedMachineIndexServer struct {
+}
+
+func (UnimplementedMachineIndexServer) GetMachineByMAC(context.Context, *MACRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachineByMAC not implemented")
+}
+func (UnimplementedMachineIndexServer) GetMachineByUUID(context.Context, *UUIDRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachineByUUID not implemented")
+}
+func (UnimplementedMachineIndexServer) GetMachineByName(context.Context, *NameRequest) (*Machine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachineByName not implemented")
+}
+func (UnimplementedMachineIndexServer) GetBMCByMAC(context.Context, *MACRequest) (*BMC, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBMCByMAC not implemented")
+}
+func (UnimplementedMachineIndexServer) GetBMCByUUID(context.Context, *UUIDRequest) (*BMC, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBMCByUUID not implemented")
+}
+func (UnimplementedMachineIndexServer) GetBMCByName(context.Context, *NameRequest) (*BMC, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBMCByName not implemented")
+}
+func (UnimplementedMachineIndexServer) GetMachineTypeByMAC(context.Context, *MACRequest) (*MachineType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachineTypeByMAC not implemented")
+}
+func (UnimplementedMachineIndexServer) GetMachineTypeByName(context.Context, *NameRequest) (*MachineType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMachineTypeByName not implemented")
+}
+func (UnimplementedMachineIndexServer) Watch(*WatchRequest, MachineIndex_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedMachineIndexServer) mustEmbedUnimplementedMachineIndexServer() {}
+
+// UnsafeMachineIndexServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MachineIndexServer will
+// result in compilation errors.
+type UnsafeMachineIndexServer interface {
+	mustEmbedUnimplementedMachineIndexServer()
+}
+
+func RegisterMachineIndexServer(s grpc.ServiceRegistrar, srv MachineIndexServer) {
+	s.RegisterService(&MachineIndex_ServiceDesc, srv)
+}
+
+func _MachineIndex_GetMachineByMAC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MACRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetMachineByMAC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetMachineByMAC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetMachineByMAC(ctx, req.(*MACRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetMachineByUUID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UUIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetMachineByUUID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetMachineByUUID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetMachineByUUID(ctx, req.(*UUIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetMachineByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetMachineByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetMachineByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetMachineByName(ctx, req.(*NameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetBMCByMAC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MACRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetBMCByMAC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetBMCByMAC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetBMCByMAC(ctx, req.(*MACRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetBMCByUUID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UUIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetBMCByUUID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetBMCByUUID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetBMCByUUID(ctx, req.(*UUIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetBMCByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetBMCByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetBMCByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetBMCByName(ctx, req.(*NameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetMachineTypeByMAC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MACRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetMachineTypeByMAC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetMachineTypeByMAC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetMachineTypeByMAC(ctx, req.(*MACRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_GetMachineTypeByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineIndexServer).GetMachineTypeByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MachineIndex_GetMachineTypeByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineIndexServer).GetMachineTypeByName(ctx, req.(*NameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineIndex_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MachineIndexServer).Watch(m, &machineIndexWatchServer{stream})
+}
+
+type MachineIndex_WatchServer interface {
+	Send(*IndexEvent) error
+	grpc.ServerStream
+}
+
+type machineIndexWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *machineIndexWatchServer) Send(m *IndexEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// MachineIndex_ServiceDesc is the grpc.ServiceDesc for MachineIndex service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MachineIndex_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "machineindex.v1.MachineIndex",
+	HandlerType: (*MachineIndexServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMachineByMAC",
+			Handler:    _MachineIndex_GetMachineByMAC_Handler,
+		},
+		{
+			MethodName: "GetMachineByUUID",
+			Handler:    _MachineIndex_GetMachineByUUID_Handler,
+		},
+		{
+			MethodName: "GetMachineByName",
+			Handler:    _MachineIndex_GetMachineByName_Handler,
+		},
+		{
+			MethodName: "GetBMCByMAC",
+			Handler:    _MachineIndex_GetBMCByMAC_Handler,
+		},
+		{
+			MethodName: "GetBMCByUUID",
+			Handler:    _MachineIndex_GetBMCByUUID_Handler,
+		},
+		{
+			MethodName: "GetBMCByName",
+			Handler:    _MachineIndex_GetBMCByName_Handler,
+		},
+		{
+			MethodName: "GetMachineTypeByMAC",
+			Handler:    _MachineIndex_GetMachineTypeByMAC_Handler,
+		},
+		{
+			MethodName: "GetMachineTypeByName",
+			Handler:    _MachineIndex_GetMachineTypeByName_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _MachineIndex_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "machineindex.proto",
+}
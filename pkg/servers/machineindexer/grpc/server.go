@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative machineindex.proto
+
+package grpc
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/module"
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/module/handler"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/onmetal/k8s-machines/pkg/controllers"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+
+	// register reguired controllers
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/bmc"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/machines"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/types"
+)
+
+const NAME = "machineindexgrpc"
+
+func init() {
+	module.Configure(NAME).
+		OptionsByExample("options", &Config{}).
+		RegisterHandler("grpc", Create).
+		ActivateExplicitly().
+		MustRegister()
+}
+
+type grpcserver struct {
+	module  module.Interface
+	config  *Config
+	auth    *machineindexer.Authenticator
+	service MachineIndexServer
+}
+
+func Create(mod module.Interface) (handler.Interface, error) {
+	opts, err := mod.GetOptionSource("options")
+	if err != nil {
+		return nil, err
+	}
+	cfg := opts.(*Config)
+	err = cfg.Prepare()
+	if err != nil {
+		return nil, err
+	}
+	reviewer, err := machineindexer.CreateTokenReviewer(mod.GetEnvironment(), &cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer != nil {
+		mod.Infof("  using token reviews")
+	}
+	return &grpcserver{
+		module: mod,
+		config: cfg,
+		auth:   machineindexer.NewAuthenticator(mod, &cfg.Config, reviewer),
+	}, nil
+}
+
+// Setup uses the same shared indices and change feed as the
+// http index server.
+func (this *grpcserver) Setup() error {
+	env := this.module.GetEnvironment()
+	minfo := controllers.GetOrCreateMachineIndex(env, func() machines.MachineIndex { return machines.NewFullIndexer() })
+	bmcs := controllers.GetOrCreateBMCIndex(env, func() machines.BMCIndex { return machines.NewBMCFullIndexer() })
+	types := controllers.GetOrCreateMachineTypeIndex(env, func() machines.MachineTypeIndex { return machines.NewTypeFullIndexer() })
+
+	feed := controllers.GetOrCreateChangeFeed(env)
+	attach(feed, machines.PATH_MACHINEINFO, minfo)
+	attach(feed, machines.PATH_BMCINFO, bmcs)
+	attach(feed, machines.PATH_MACHINETYPE, types)
+
+	this.service = NewService(minfo, bmcs, types, feed, this.module.GetContext().Done())
+	return nil
+}
+
+func attach(feed *machines.ChangeFeed, name string, index interface{}) {
+	if n, ok := index.(machines.IndexNotifier); ok {
+		feed.Attach(name, n)
+	}
+}
+
+func (this *grpcserver) Start() error {
+	opts, err := ServerOptions(this.module, this.config, this.auth)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(opts...)
+	RegisterMachineIndexServer(server, this.service)
+
+	address := fmt.Sprintf("%s:%d", this.config.BindAddress, this.config.Port)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %s", address, err)
+	}
+	if this.config.TLS() {
+		this.module.Infof("starting gRPC server with TLS (serving on %s)", address)
+	} else {
+		this.module.Infof("starting gRPC server (serving on %s)", address)
+	}
+
+	ctx := this.module.GetContext()
+	go func() {
+		<-ctx.Done()
+		this.module.Infof("shutting down gRPC server")
+		server.GracefulStop()
+	}()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && ctx.Err() == nil {
+			this.module.Errorf("gRPC server failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+// ServerOptions provides the options for a gRPC server using
+// TLS and the access control of the given configuration.
+func ServerOptions(logger logger.LogContext, cfg *Config, auth *machineindexer.Authenticator) ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if cfg.TLS() {
+		tlscfg, err := cfg.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlscfg)))
+	}
+	if auth != nil && auth.Required() {
+		a := &authorizer{logger: logger, auth: auth}
+		opts = append(opts, grpc.UnaryInterceptor(a.unary), grpc.StreamInterceptor(a.stream))
+	}
+	return opts, nil
+}
@@ -0,0 +1,399 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+type service struct {
+	UnimplementedMachineIndexServer
+	machines machines.MachineIndex
+	bmcs     machines.BMCIndex
+	types    machines.MachineTypeIndex
+	feed     *machines.ChangeFeed
+	done     <-chan struct{}
+}
+
+// NewService provides the gRPC service serving the given indices.
+// Watch streams are terminated if the done channel is closed.
+func NewService(m machines.MachineIndex, b machines.BMCIndex, t machines.MachineTypeIndex, feed *machines.ChangeFeed, done <-chan struct{}) MachineIndexServer {
+	return &service{machines: m, bmcs: b, types: t, feed: feed, done: done}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// machines
+
+// machineIndex provides the machine index, restricted to the
+// objects of a namespace if given.
+func (this *service) machineIndex(namespace string) (machines.MachineIndex, error) {
+	if this.machines == nil || !this.machines.IsInitialized() {
+		return nil, status.Errorf(codes.Unavailable, "machine index not initialized")
+	}
+	if namespace == "" {
+		return this.machines, nil
+	}
+	if index := machines.MachineIndexForNamespace(this.machines, namespace); index != nil {
+		return index, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "namespace lookups not supported by machine index")
+}
+
+func (this *service) GetMachineByMAC(ctx context.Context, req *MACRequest) (*Machine, error) {
+	index, err := this.machineIndex(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := macKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ambiguous(index, machines.KEY_MAC, mac); err != nil {
+		return nil, err
+	}
+	m, err := machines.LookupMachine(ctx, index, machines.KEY_MAC, mac)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return machineObject(m, "mac", mac)
+}
+
+func (this *service) GetMachineByUUID(ctx context.Context, req *UUIDRequest) (*Machine, error) {
+	index, err := this.machineIndex(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	uuid, err := uuidKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ambiguous(index, machines.KEY_UUID, uuid); err != nil {
+		return nil, err
+	}
+	m, err := machines.LookupMachine(ctx, index, machines.KEY_UUID, uuid)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return machineObject(m, "uuid", uuid)
+}
+
+func (this *service) GetMachineByName(ctx context.Context, req *NameRequest) (*Machine, error) {
+	index, err := this.machineIndex("")
+	if err != nil {
+		return nil, err
+	}
+	name, err := nameKey(req)
+	if err != nil {
+		return nil, err
+	}
+	return machineObject(index.GetByName(name), "name", name.String())
+}
+
+func machineObject(m *machines.Machine, kind, key string) (*Machine, error) {
+	if m == nil {
+		return nil, status.Errorf(codes.NotFound, "no machine found for %s %q", kind, key)
+	}
+	spec, err := machineInfoSpec(m.MachineInfoSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &Machine{Metadata: objectMeta(m.Name, m.ResourceVersion), Spec: spec}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// bmcs
+
+func (this *service) bmcIndex(namespace string) (machines.BMCIndex, error) {
+	if this.bmcs == nil || !this.bmcs.IsInitialized() {
+		return nil, status.Errorf(codes.Unavailable, "bmc index not initialized")
+	}
+	if namespace == "" {
+		return this.bmcs, nil
+	}
+	if index := machines.BMCIndexForNamespace(this.bmcs, namespace); index != nil {
+		return index, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "namespace lookups not supported by bmc index")
+}
+
+func (this *service) GetBMCByMAC(ctx context.Context, req *MACRequest) (*BMC, error) {
+	index, err := this.bmcIndex(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := macKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ambiguous(index, machines.KEY_MAC, mac); err != nil {
+		return nil, err
+	}
+	m, err := machines.LookupBMC(ctx, index, machines.KEY_MAC, mac)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return bmcObject(m, "mac", mac)
+}
+
+func (this *service) GetBMCByUUID(ctx context.Context, req *UUIDRequest) (*BMC, error) {
+	index, err := this.bmcIndex(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	uuid, err := uuidKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ambiguous(index, machines.KEY_UUID, uuid); err != nil {
+		return nil, err
+	}
+	m, err := machines.LookupBMC(ctx, index, machines.KEY_UUID, uuid)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return bmcObject(m, "uuid", uuid)
+}
+
+func (this *service) GetBMCByName(ctx context.Context, req *NameRequest) (*BMC, error) {
+	index, err := this.bmcIndex("")
+	if err != nil {
+		return nil, err
+	}
+	name, err := nameKey(req)
+	if err != nil {
+		return nil, err
+	}
+	return bmcObject(index.GetByName(name), "name", name.String())
+}
+
+// bmcObject never includes the BMC credentials.
+func bmcObject(m *machines.BaseBoardManagementController, kind, key string) (*BMC, error) {
+	if m == nil {
+		return nil, status.Errorf(codes.NotFound, "no bmc found for %s %q", kind, key)
+	}
+	m = machines.RedactBMC(m)
+	spec, err := bmcSpec(m.BaseBoardManagementControllerInfoSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &BMC{Metadata: objectMeta(m.Name, m.ResourceVersion), Spec: spec}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// machine types
+
+func (this *service) typeIndex(namespace string) (machines.MachineTypeIndex, error) {
+	if this.types == nil || !this.types.IsInitialized() {
+		return nil, status.Errorf(codes.Unavailable, "machine type index not initialized")
+	}
+	if namespace == "" {
+		return this.types, nil
+	}
+	if index := machines.MachineTypeIndexForNamespace(this.types, namespace); index != nil {
+		return index, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "namespace lookups not supported by machine type index")
+}
+
+func (this *service) GetMachineTypeByMAC(ctx context.Context, req *MACRequest) (*MachineType, error) {
+	index, err := this.typeIndex(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := macKey(req)
+	if err != nil {
+		return nil, err
+	}
+	m, err := machines.LookupMachineType(ctx, index, mac)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	return typeObject(m, "mac", mac)
+}
+
+func (this *service) GetMachineTypeByName(ctx context.Context, req *NameRequest) (*MachineType, error) {
+	index, err := this.typeIndex("")
+	if err != nil {
+		return nil, err
+	}
+	name, err := nameKey(req)
+	if err != nil {
+		return nil, err
+	}
+	return typeObject(index.GetByName(name), "name", name.String())
+}
+
+func typeObject(m *machines.MachineType, kind, key string) (*MachineType, error) {
+	if m == nil {
+		return nil, status.Errorf(codes.NotFound, "no machine type found for %s %q", kind, key)
+	}
+	spec, err := machineTypeSpec(m.MachineTypeSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &MachineType{Metadata: objectMeta(m.Name, m.ResourceVersion), Spec: spec}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// watch
+
+func (this *service) Watch(req *WatchRequest, stream MachineIndex_WatchServer) error {
+	if this.feed == nil {
+		return status.Errorf(codes.Unimplemented, "no change feed configured")
+	}
+	indices := map[string]bool{}
+	for _, i := range req.Indices {
+		if !machineindexer.Allowed(stream.Context(), i) {
+			return status.Errorf(codes.PermissionDenied, "access to %s denied", i)
+		}
+		indices[i] = true
+	}
+	// without explicit indices, all accessible indices are watched
+	selected := func(e *machines.IndexEvent) bool {
+		if e.Type == machines.EVENT_RESET {
+			return true
+		}
+		if req.Namespace != "" && e.Namespace != req.Namespace {
+			return false
+		}
+		if len(indices) > 0 {
+			return indices[e.Index]
+		}
+		return machineindexer.Allowed(stream.Context(), e.Index)
+	}
+	// without since the watch starts with the next change
+	seq := this.feed.Current()
+	if req.Since != nil {
+		if *req.Since < 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid sequence number %d", *req.Since)
+		}
+		seq = *req.Since
+	}
+	for {
+		events, changed := this.feed.Since(seq)
+		for _, e := range events {
+			seq = e.Sequence
+			if !selected(e) {
+				continue
+			}
+			if err := stream.Send(eventMessage(e)); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		case <-this.done:
+			return nil
+		}
+	}
+}
+
+func eventMessage(e *machines.IndexEvent) *IndexEvent {
+	return &IndexEvent{
+		Sequence:        e.Sequence,
+		Index:           e.Index,
+		Type:            e.Type,
+		Cluster:         e.Cluster,
+		Namespace:       e.Namespace,
+		Name:            e.Name,
+		ResourceVersion: e.ResourceVersion,
+		Macs:            e.MACs,
+		Uuids:           e.UUIDs,
+		Prefixes:        e.Prefixes,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// utils
+
+// lookupError maps the failure of a lookup of an index backed
+// by another index server to a status.
+func lookupError(err error) error {
+	switch {
+	case machines.IsAmbiguous(err):
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	case machines.IsNotInitialized(err), machines.IsUnavailable(err):
+		return status.Errorf(codes.Unavailable, "%s", err)
+	}
+	if _, ok := err.(*machines.IndexError); ok {
+		return status.Errorf(codes.Internal, "%s", err)
+	}
+	return status.Errorf(codes.Unavailable, "%s", err)
+}
+
+// ambiguous reports keys claimed by several objects.
+func ambiguous(index interface{}, kind, key string) error {
+	names := machineindexer.Claims(index, kind, key)
+	if names == nil {
+		return nil
+	}
+	list := make([]string, len(names))
+	for i, n := range names {
+		list[i] = n.String()
+	}
+	return status.Errorf(codes.FailedPrecondition, "%s %q claimed by several objects: %s", kind, key, strings.Join(list, ", "))
+}
+
+func macKey(req *MACRequest) (string, error) {
+	if req.Mac == "" {
+		return "", status.Errorf(codes.InvalidArgument, "mac required")
+	}
+	mac, err := machines.NormalizeMAC(req.Mac)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid mac address %q", req.Mac)
+	}
+	return mac, nil
+}
+
+func uuidKey(req *UUIDRequest) (string, error) {
+	if req.Uuid == "" {
+		return "", status.Errorf(codes.InvalidArgument, "uuid required")
+	}
+	return req.Uuid, nil
+}
+
+func nameKey(req *NameRequest) (resources.ObjectName, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name required")
+	}
+	return machines.NewClusterObjectName(req.Cluster, req.Namespace, req.Name), nil
+}
+
+func objectMeta(name resources.ObjectName, version string) *ObjectMeta {
+	return &ObjectMeta{
+		Cluster:         machines.ClusterOf(name),
+		Namespace:       name.Namespace(),
+		Name:            name.Name(),
+		ResourceVersion: version,
+	}
+}
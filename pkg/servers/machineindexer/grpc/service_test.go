@@ -0,0 +1,355 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"github.com/gardener/controller-manager-library/pkg/types"
+	"github.com/gardener/controller-manager-library/pkg/types/infodata/simple"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+type machineIndex struct {
+	machine *machines.Machine
+}
+
+func (this *machineIndex) IsInitialized() bool { return true }
+
+func (this *machineIndex) GetByMAC(mac string) *machines.Machine {
+	if mac == "00:11:22:33:44:55" {
+		return this.machine
+	}
+	return nil
+}
+
+func (this *machineIndex) GetByUUID(uuid string) *machines.Machine {
+	if uuid == "uuid-1" {
+		return this.machine
+	}
+	return nil
+}
+
+func (this *machineIndex) GetByName(name resources.ObjectName) *machines.Machine {
+	if name == this.machine.Name {
+		return this.machine
+	}
+	return nil
+}
+
+type bmcIndex struct {
+	bmc *machines.BaseBoardManagementController
+}
+
+func (this *bmcIndex) IsInitialized() bool { return true }
+
+func (this *bmcIndex) GetByMAC(mac string) *machines.BaseBoardManagementController {
+	if mac == "00:11:22:33:44:66" {
+		return this.bmc
+	}
+	return nil
+}
+
+func (this *bmcIndex) GetByUUID(uuid string) *machines.BaseBoardManagementController { return nil }
+func (this *bmcIndex) GetByName(name resources.ObjectName) *machines.BaseBoardManagementController {
+	return nil
+}
+func (this *bmcIndex) GetByIP(ip string) *machines.BaseBoardManagementController         { return nil }
+func (this *bmcIndex) GetBySerial(serial string) *machines.BaseBoardManagementController { return nil }
+func (this *bmcIndex) GetByAssetTag(tag string) *machines.BaseBoardManagementController  { return nil }
+
+type notifier struct {
+	listener machines.IndexListener
+}
+
+func (this *notifier) AddListener(l machines.IndexListener) {
+	this.listener = l
+}
+
+type reviewer struct{}
+
+func (this *reviewer) Review(ctx context.Context, token string) (*machineindexer.Identity, error) {
+	switch token {
+	case "admin":
+		return &machineindexer.Identity{User: "admin", Groups: []string{"admins"}}, nil
+	case "user":
+		return &machineindexer.Identity{User: "user"}, nil
+	}
+	return nil, fmt.Errorf("invalid token")
+}
+
+func code(err error) codes.Code {
+	return status.Code(err)
+}
+
+var _ = Describe("gRPC", func() {
+	var listener *bufconn.Listener
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var client MachineIndexClient
+	var feed *machines.ChangeFeed
+	var n *notifier
+	var bmc *machines.BaseBoardManagementController
+	var cancel context.CancelFunc
+
+	start := func(opts ...grpc.ServerOption) {
+		var ctx context.Context
+		var err error
+		ctx, cancel = context.WithCancel(context.Background())
+
+		index := &machineIndex{&machines.Machine{
+			Name:            resources.NewObjectName("default", "m1"),
+			ResourceVersion: "17",
+			MachineInfoSpec: &api.MachineInfoSpec{
+				UUID:   "uuid-1",
+				NICs:   []api.NIC{{Name: "eth0", MAC: "00:11:22:33:44:55", Bandwidth: 1000}},
+				Values: types.Values{Values: simple.Values{"rack": "r1", "slot": 4}},
+			},
+		}}
+		bmc = &machines.BaseBoardManagementController{
+			Name:            resources.NewObjectName("default", "b1"),
+			ResourceVersion: "3",
+			BaseBoardManagementControllerInfoSpec: &api.BaseBoardManagementControllerInfoSpec{
+				UUID:        "uuid-2",
+				MAC:         "00:11:22:33:44:66",
+				Credentials: &api.BasicAuthCredentials{User: "admin", Password: "secret"},
+				FRUs:        []api.FieldReplacableUnit{{ID: "0", Chassis: &api.FieldReplacableUnitInfo{Serial: "s1"}}},
+			},
+		}
+		feed = machines.NewChangeFeed()
+		n = &notifier{}
+		feed.Attach(machines.PATH_MACHINEINFO, n)
+
+		listener = bufconn.Listen(1024 * 1024)
+		server = grpc.NewServer(opts...)
+		RegisterMachineIndexServer(server, NewService(index, &bmcIndex{bmc}, nil, feed, ctx.Done()))
+		go server.Serve(listener)
+
+		conn, err = grpc.DialContext(context.Background(), "bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return listener.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).To(Succeed())
+		client = NewMachineIndexClient(conn)
+	}
+
+	AfterEach(func() {
+		cancel()
+		conn.Close()
+		server.Stop()
+	})
+
+	Context("service", func() {
+		BeforeEach(func() {
+			start()
+		})
+
+		It("gets machine by mac", func() {
+			m, err := client.GetMachineByMAC(context.Background(), &MACRequest{Mac: "00-11-22-33-44-55"})
+			Expect(err).To(Succeed())
+			Expect(m.Metadata.Namespace).To(Equal("default"))
+			Expect(m.Metadata.Name).To(Equal("m1"))
+			Expect(m.Metadata.ResourceVersion).To(Equal("17"))
+			Expect(m.Spec.Uuid).To(Equal("uuid-1"))
+			Expect(m.Spec.Nics).To(HaveLen(1))
+			Expect(m.Spec.Nics[0].Mac).To(Equal("00:11:22:33:44:55"))
+			Expect(m.Spec.Nics[0].Bandwidth).To(Equal(int64(1000)))
+			Expect(m.Spec.Values.AsMap()).To(Equal(map[string]interface{}{"rack": "r1", "slot": float64(4)}))
+		})
+
+		It("gets machine by name", func() {
+			m, err := client.GetMachineByName(context.Background(), &NameRequest{Namespace: "default", Name: "m1"})
+			Expect(err).To(Succeed())
+			Expect(m.Metadata.Name).To(Equal("m1"))
+		})
+
+		It("reports not found", func() {
+			_, err := client.GetMachineByUUID(context.Background(), &UUIDRequest{Uuid: "other"})
+			Expect(code(err)).To(Equal(codes.NotFound))
+		})
+
+		It("reports invalid arguments", func() {
+			_, err := client.GetMachineByMAC(context.Background(), &MACRequest{Mac: "invalid"})
+			Expect(code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("reports unavailable index", func() {
+			_, err := client.GetMachineTypeByMAC(context.Background(), &MACRequest{Mac: "00:11:22:33:44:55"})
+			Expect(code(err)).To(Equal(codes.Unavailable))
+		})
+
+		It("gets bmc without credentials", func() {
+			b, err := client.GetBMCByMAC(context.Background(), &MACRequest{Mac: "00:11:22:33:44:66"})
+			Expect(err).To(Succeed())
+			Expect(b.Metadata.Name).To(Equal("b1"))
+			Expect(b.Spec.Uuid).To(Equal("uuid-2"))
+			Expect(b.Spec.Frus[0].Chassis.Serial).To(Equal("s1"))
+			data, err := proto.Marshal(b)
+			Expect(err).To(Succeed())
+			Expect(strings.Contains(string(data), "secret")).To(BeFalse())
+			Expect(bmc.Credentials.Password).To(Equal("secret"))
+		})
+
+		It("streams changes", func() {
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_ADDED, Name: resources.NewObjectName("default", "m1"), ResourceVersion: "1"})
+
+			ctx, done := context.WithCancel(context.Background())
+			defer done()
+			stream, err := client.Watch(ctx, &WatchRequest{Since: proto.Int64(1)})
+			Expect(err).To(Succeed())
+
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_UPDATED, Name: resources.NewObjectName("default", "m1"), ResourceVersion: "2", MACs: []string{"00:11:22:33:44:55"}})
+			e, err := stream.Recv()
+			Expect(err).To(Succeed())
+			Expect(e.Sequence).To(Equal(int64(2)))
+			Expect(e.Index).To(Equal(machines.PATH_MACHINEINFO))
+			Expect(e.Type).To(Equal(machines.CHANGE_UPDATED))
+			Expect(e.ResourceVersion).To(Equal("2"))
+			Expect(e.Macs).To(Equal([]string{"00:11:22:33:44:55"}))
+		})
+
+		It("resumes from the first change", func() {
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_ADDED, Name: resources.NewObjectName("default", "m1"), ResourceVersion: "1"})
+
+			ctx, done := context.WithCancel(context.Background())
+			defer done()
+			stream, err := client.Watch(ctx, &WatchRequest{Since: proto.Int64(0)})
+			Expect(err).To(Succeed())
+			e, err := stream.Recv()
+			Expect(err).To(Succeed())
+			Expect(e.Sequence).To(Equal(int64(1)))
+			Expect(e.ResourceVersion).To(Equal("1"))
+		})
+
+		It("starts without since with the next change", func() {
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_ADDED, Name: resources.NewObjectName("default", "m1"), ResourceVersion: "1"})
+
+			ctx, done := context.WithCancel(context.Background())
+			defer done()
+			stream, err := client.Watch(ctx, &WatchRequest{})
+			Expect(err).To(Succeed())
+			// changes are reported until the watch has been started
+			go func() {
+				for v := 2; ctx.Err() == nil; v++ {
+					n.listener(&machines.IndexChange{Type: machines.CHANGE_UPDATED, Name: resources.NewObjectName("default", "m1"), ResourceVersion: fmt.Sprint(v)})
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+			e, err := stream.Recv()
+			Expect(err).To(Succeed())
+			Expect(e.Sequence).To(BeNumerically(">", 1))
+		})
+
+		It("rejects negative sequence numbers", func() {
+			stream, err := client.Watch(context.Background(), &WatchRequest{Since: proto.Int64(-1)})
+			Expect(err).To(Succeed())
+			_, err = stream.Recv()
+			Expect(code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("rejects namespaces for indices without namespace lookups", func() {
+			_, err := client.GetMachineByMAC(context.Background(), &MACRequest{Mac: "00:11:22:33:44:55", Namespace: "default"})
+			Expect(code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("restricts the streamed changes to a namespace", func() {
+			ctx, done := context.WithCancel(context.Background())
+			defer done()
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_ADDED, Name: resources.NewObjectName("tenant", "m0")})
+			stream, err := client.Watch(ctx, &WatchRequest{Since: proto.Int64(1), Namespace: "tenant"})
+			Expect(err).To(Succeed())
+
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_UPDATED, Name: resources.NewObjectName("default", "m1")})
+			n.listener(&machines.IndexChange{Type: machines.CHANGE_UPDATED, Name: resources.NewObjectName("tenant", "m2")})
+			e, err := stream.Recv()
+			Expect(err).To(Succeed())
+			Expect(e.Namespace).To(Equal("tenant"))
+			Expect(e.Name).To(Equal("m2"))
+		})
+	})
+
+	Context("access control", func() {
+		BeforeEach(func() {
+			cfg := &machineindexer.Config{TokenReview: true, Authorize: []string{"bmc=group:admins"}}
+			Expect(cfg.Prepare()).To(Succeed())
+			a := &authorizer{logger: logger.New(), auth: machineindexer.NewAuthenticator(logger.New(), cfg, &reviewer{})}
+			start(grpc.UnaryInterceptor(a.unary), grpc.StreamInterceptor(a.stream))
+		})
+
+		token := func(t string) context.Context {
+			return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+t)
+		}
+
+		It("rejects unauthenticated calls", func() {
+			_, err := client.GetMachineByName(context.Background(), &NameRequest{Namespace: "default", Name: "m1"})
+			Expect(code(err)).To(Equal(codes.Unauthenticated))
+			_, err = client.GetMachineByName(token("invalid"), &NameRequest{Namespace: "default", Name: "m1"})
+			Expect(code(err)).To(Equal(codes.Unauthenticated))
+		})
+
+		It("rejects unauthenticated watches", func() {
+			stream, err := client.Watch(context.Background(), &WatchRequest{})
+			Expect(err).To(Succeed())
+			_, err = stream.Recv()
+			Expect(code(err)).To(Equal(codes.Unauthenticated))
+		})
+
+		It("applies the authorization rules", func() {
+			_, err := client.GetMachineByName(token("user"), &NameRequest{Namespace: "default", Name: "m1"})
+			Expect(err).To(Succeed())
+			_, err = client.GetBMCByMAC(token("user"), &MACRequest{Mac: "00:11:22:33:44:66"})
+			Expect(code(err)).To(Equal(codes.PermissionDenied))
+			_, err = client.GetBMCByMAC(token("admin"), &MACRequest{Mac: "00:11:22:33:44:66"})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	Context("config", func() {
+		It("requires TLS for authentication", func() {
+			cfg := &Config{Port: 8091}
+			cfg.TokenReview = true
+			Expect(cfg.Prepare()).NotTo(Succeed())
+			cfg.TLSCertFile = "cert.pem"
+			cfg.TLSKeyFile = "key.pem"
+			Expect(cfg.Prepare()).To(Succeed())
+			Expect(cfg.TLS()).To(BeTrue())
+		})
+	})
+})
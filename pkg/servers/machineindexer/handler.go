@@ -23,21 +23,42 @@
 package machineindexer
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/server"
 	"github.com/gardener/controller-manager-library/pkg/resources"
+	srvutils "github.com/gardener/controller-manager-library/pkg/server"
+	"github.com/gardener/controller-manager-library/pkg/utils"
+
+	"github.com/onmetal/k8s-machines/pkg/controllers"
+	"github.com/onmetal/k8s-machines/pkg/machines"
 )
 
 const CONTENT_TYPE = "Content-Type"
 
 type requesthandler struct {
 	server.Interface
+	config   *Config
+	auth     *Authenticator
+	mux      *http.ServeMux
 	indexers []Interface
+	batches  map[string]BatchIndex
+	feed     *machines.ChangeFeed
 }
 
 func (this *requesthandler) Setup() error {
+	this.mux = http.NewServeMux()
+	this.batches = map[string]BatchIndex{}
+	this.feed = controllers.GetOrCreateChangeFeed(this.GetEnvironment())
+	this.Register(machines.PATH_BATCH, this.batch)
+	this.Register(machines.PATH_WATCH, this.watch)
+	this.registerReadiness()
 	for _, t := range defaultRegistry.handlers {
 		h, err := t(this)
 		if err != nil {
@@ -52,14 +73,259 @@ func (this *requesthandler) Setup() error {
 	return nil
 }
 
-func (this *requesthandler) MachineIds(r *http.Request) ([]string, []string) {
+// Register registers a handler for the insecure server port and the
+// TLS port enforcing the configured access control.
+func (this *requesthandler) Register(pattern string, handler http.HandlerFunc) {
+	handler = instrument(strings.Trim(pattern, "/"), this.auth.Filter(pattern, handler))
+	if this.config.ServeInsecure {
+		this.Interface.Register(pattern, handler)
+	}
+	this.mux.HandleFunc(srvutils.NormPath(pattern), handler)
+}
+
+func (this *requesthandler) Start() error {
+	if this.config.TLSPort <= 0 {
+		return nil
+	}
+	tlscfg, err := this.config.TLSConfig()
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:      fmt.Sprintf(":%d", this.config.TLSPort),
+		Handler:   this.mux,
+		TLSConfig: tlscfg,
+	}
+	ctx := this.GetContext()
+	go func() {
+		<-ctx.Done()
+		this.Infof("shutting down TLS server")
+		c, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(c)
+	}()
+	go func() {
+		this.Infof("starting TLS server (serving on %s)", srv.Addr)
+		err := srv.ListenAndServeTLS("", "")
+		if err != nil && err != http.ErrServerClosed {
+			this.Errorf("cannot start TLS server: %s", err)
+		}
+	}()
+	return nil
+}
+
+func (this *requesthandler) MachineIds(r *http.Request) ([]string, []string, error) {
 	values := r.URL.Query()
 	this.Infof("  found uuids: %v", values["uuid"])
 	this.Infof("  found macs : %v", values["mac"])
-	return values["uuid"], values["mac"]
+	macs := make([]string, 0, len(values["mac"]))
+	for _, m := range values["mac"] {
+		mac, err := machines.NormalizeMAC(m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid mac address %q", m)
+		}
+		macs = append(macs, mac)
+	}
+	return values["uuid"], macs, nil
+}
+
+func (this *requesthandler) FullView(r *http.Request) bool {
+	return r.URL.Query().Get(machines.QUERY_VIEW) == machines.VIEW_FULL
 }
 
 func (this *requesthandler) ObjectResponse(w http.ResponseWriter, n resources.ObjectName) {
+	if c := machines.ClusterOf(n); c != "" {
+		r := fmt.Sprintf("{ \"cluster\": \"%s\", \"name\": \"%s\", \"namespace\": \"%s\" }", c, n.Name(), n.Namespace())
+		w.Write([]byte(r))
+		return
+	}
 	r := fmt.Sprintf("{ \"name\": \"%s\", \"namespace\": \"%s\" }", n.Name(), n.Namespace())
 	w.Write([]byte(r))
 }
+
+func (this *requesthandler) JSONResponse(w http.ResponseWriter, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		this.Errorf("cannot marshal response: %s", err)
+		this.ErrorResponse(w, http.StatusInternalServerError, machines.REASON_INTERNAL, "cannot marshal response")
+		return
+	}
+	w.Write(data)
+}
+
+func (this *requesthandler) RegisterBatch(path string, index BatchIndex) {
+	this.batches[path] = index
+}
+
+func (this *requesthandler) batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		this.ErrorResponse(w, http.StatusMethodNotAllowed, machines.REASON_BAD_REQUEST, "batch requires POST")
+		return
+	}
+	request := machines.BatchRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, machines.MAX_BATCH_SIZE)).Decode(&request)
+	if err != nil {
+		this.Infof("invalid batch request: %s", err)
+		this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("invalid batch request: %s", err))
+		return
+	}
+	count := 0
+	for _, keys := range request {
+		if keys != nil {
+			count += len(keys.MACs) + len(keys.UUIDs)
+		}
+	}
+	if count > machines.MAX_BATCH_KEYS {
+		this.Infof("batch request for %d keys rejected", count)
+		this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("batch request for %d keys exceeds the limit of %d keys", count, machines.MAX_BATCH_KEYS))
+		return
+	}
+
+	namespace := r.URL.Query().Get(machines.QUERY_NAMESPACE)
+	response := machines.BatchResponse{}
+	for path, keys := range request {
+		index := this.batches[path]
+		if index == nil {
+			this.Infof("batch request for unknown index %q", path)
+			this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("unknown index %q", path))
+			return
+		}
+		if !Allowed(r.Context(), path) {
+			this.Infof("batch request for index %q denied", path)
+			this.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, fmt.Sprintf("access to index %q denied", path))
+			return
+		}
+		if !index.IsInitialized() {
+			this.NotInitializedResponse(w, path)
+			return
+		}
+		if namespace != "" {
+			if n, ok := index.(NamespaceBatchIndex); ok {
+				index = n.ForNamespace(namespace)
+			} else {
+				index = nil
+			}
+			if index == nil {
+				this.Infof("index %q does not support namespace lookups", path)
+				this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("index %q does not support namespace lookups", path))
+				return
+			}
+		}
+		if keys == nil {
+			continue
+		}
+		result := &machines.BatchKeyResults{}
+		var ok bool
+		if result.MACs, ok = lookup(keys.MACs, index.LookupMAC); !ok {
+			this.Infof("index %q does not support mac keys", path)
+			this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("index %q does not support mac keys", path))
+			return
+		}
+		if result.UUIDs, ok = lookup(keys.UUIDs, index.LookupUUID); !ok {
+			this.Infof("index %q does not support uuid keys", path)
+			this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("index %q does not support uuid keys", path))
+			return
+		}
+		for _, r := range result.MACs {
+			countBatchResult(path, r)
+		}
+		for _, r := range result.UUIDs {
+			countBatchResult(path, r)
+		}
+		response[path] = result
+	}
+	this.Infof("batch request for %d indices", len(response))
+	w.Header().Set(CONTENT_TYPE, "application/json")
+	this.JSONResponse(w, response)
+}
+
+func lookup(keys []string, f func(string) *machines.BatchResult) (map[string]*machines.BatchResult, bool) {
+	if len(keys) == 0 {
+		return nil, true
+	}
+	result := map[string]*machines.BatchResult{}
+	for _, k := range keys {
+		r := f(k)
+		if r == nil {
+			return nil, false
+		}
+		result[k] = r
+	}
+	return result, true
+}
+
+func (this *requesthandler) RegisterWatch(path string, notifier machines.IndexNotifier) {
+	this.feed.Attach(path, notifier)
+}
+
+// watch streams the index changes as a sequence of JSON documents,
+// one per line, until the client closes the connection.
+func (this *requesthandler) watch(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		this.Errorf("streaming not supported")
+		this.ErrorResponse(w, http.StatusInternalServerError, machines.REASON_INTERNAL, "streaming not supported")
+		return
+	}
+	values := r.URL.Query()
+	seq := this.feed.Current()
+	if s := values.Get(machines.QUERY_SINCE); s != "" {
+		seq, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || seq < 0 {
+			this.Infof("invalid sequence number %q", s)
+			this.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("invalid sequence number %q", s))
+			return
+		}
+	}
+	indices := utils.NewStringSet(values[machines.QUERY_INDEX]...)
+	for i := range indices {
+		if !Allowed(r.Context(), i) {
+			this.Infof("watch for index %q denied", i)
+			this.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, fmt.Sprintf("access to index %q denied", i))
+			return
+		}
+	}
+	namespace := values.Get(machines.QUERY_NAMESPACE)
+	// without explicit indices, all accessible indices are watched
+	selected := func(e *machines.IndexEvent) bool {
+		if e.Type == machines.EVENT_RESET {
+			return true
+		}
+		if namespace != "" && e.Namespace != namespace {
+			return false
+		}
+		if len(indices) > 0 {
+			return indices.Contains(e.Index)
+		}
+		return Allowed(r.Context(), e.Index)
+	}
+
+	this.Infof("start watch for %v in namespace %q since %d", indices, namespace, seq)
+	w.Header().Set(CONTENT_TYPE, "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		events, changed := this.feed.Since(seq)
+		for _, e := range events {
+			seq = e.Sequence
+			if !selected(e) {
+				continue
+			}
+			if err := encoder.Encode(e); err != nil {
+				this.Infof("watch aborted: %s", err)
+				return
+			}
+		}
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			this.Infof("watch closed")
+			return
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gardener/controller-manager-library/pkg/controllermanager/server"
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// silent provides the logging of the server.
+type silent struct {
+	server.Interface
+}
+
+func (this *silent) Infof(msgfmt string, args ...interface{})  {}
+func (this *silent) Errorf(msgfmt string, args ...interface{}) {}
+
+// batchindex finds every uuid.
+type batchindex struct{}
+
+func (this *batchindex) IsInitialized() bool                        { return true }
+func (this *batchindex) LookupMAC(mac string) *machines.BatchResult { return nil }
+func (this *batchindex) LookupUUID(uuid string) *machines.BatchResult {
+	return machines.NewBatchResult(resources.NewObjectName("default", uuid), "1")
+}
+
+// namespacebatchindex finds every uuid in the namespace.
+type namespacebatchindex struct {
+	batchindex
+	namespace string
+}
+
+func (this *namespacebatchindex) ForNamespace(namespace string) BatchIndex {
+	return &namespacebatchindex{namespace: namespace}
+}
+
+func (this *namespacebatchindex) LookupUUID(uuid string) *machines.BatchResult {
+	return machines.NewBatchResult(resources.NewObjectName(this.namespace, uuid), "1")
+}
+
+// notifier reports changes to the feed.
+type notifier struct {
+	listener machines.IndexListener
+}
+
+func (this *notifier) AddListener(l machines.IndexListener) {
+	this.listener = l
+}
+
+func (this *notifier) changed(name string) {
+	this.changedIn("default", name)
+}
+
+func (this *notifier) changedIn(namespace, name string) {
+	this.listener(&machines.IndexChange{Type: machines.CHANGE_UPDATED, Name: resources.NewObjectName(namespace, name)})
+}
+
+var _ = Describe("Index authorization", func() {
+	var h *requesthandler
+	var auth *Authenticator
+
+	tokens := reviewer{
+		"admin":    &Identity{User: "admin"},
+		"operator": &Identity{User: "operator"},
+	}
+
+	request := func(path string, h http.HandlerFunc, r *http.Request, token string) *httptest.ResponseRecorder {
+		r.TLS = &tls.ConnectionState{}
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		auth.Filter(path, h)(w, r)
+		return w
+	}
+
+	BeforeEach(func() {
+		cfg := &Config{TokenReview: true, Authorize: []string{"bmc=user:admin"}}
+		Expect(cfg.Prepare()).To(Succeed())
+		auth = NewAuthenticator(logger.New(), cfg, tokens)
+		h = &requesthandler{
+			Interface: &silent{},
+			auth:      auth,
+			batches:   map[string]BatchIndex{},
+			feed:      machines.NewChangeFeed(),
+		}
+	})
+
+	It("applies the rules of the indices of a batch request", func() {
+		h.RegisterBatch(machines.PATH_BMCINFO, &batchindex{})
+		h.RegisterBatch(machines.PATH_MACHINEINFO, &batchindex{})
+		body := `{ "bmc": { "uuids": [ "u1" ] }, "info": { "uuids": [ "u1" ] } }`
+
+		w := request(machines.PATH_BATCH, h.batch, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)), "operator")
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).NotTo(ContainSubstring("u1"))
+
+		w = request(machines.PATH_BATCH, h.batch, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)), "admin")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		resp := machines.BatchResponse{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+		Expect(resp).To(HaveKey(machines.PATH_BMCINFO))
+	})
+
+	It("applies the rules of the watched indices", func() {
+		bmcs, infos := &notifier{}, &notifier{}
+		h.RegisterWatch(machines.PATH_BMCINFO, bmcs)
+		h.RegisterWatch(machines.PATH_MACHINEINFO, infos)
+		bmcs.changed("b1")
+		infos.changed("m1")
+
+		// the closed context ends the watch after the pending events
+		watch := func(query string, token string) *httptest.ResponseRecorder {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			r := httptest.NewRequest(http.MethodGet, "/watch?since=0"+query, nil).WithContext(ctx)
+			return request(machines.PATH_WATCH, h.watch, r, token)
+		}
+
+		w := watch("&index=bmc", "operator")
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+
+		w = watch("", "operator")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`"m1"`))
+		Expect(w.Body.String()).NotTo(ContainSubstring(`"b1"`))
+
+		w = watch("", "admin")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`"m1"`))
+		Expect(w.Body.String()).To(ContainSubstring(`"b1"`))
+	})
+})
+
+var _ = Describe("Batch requests", func() {
+	var h *requesthandler
+
+	BeforeEach(func() {
+		h = &requesthandler{Interface: &silent{}, batches: map[string]BatchIndex{}}
+		h.RegisterBatch(machines.PATH_MACHINEINFO, &batchindex{})
+	})
+
+	batchIn := func(namespace string, request machines.BatchRequest) *httptest.ResponseRecorder {
+		body, err := json.Marshal(request)
+		Expect(err).To(Succeed())
+		target := "/batch"
+		if namespace != "" {
+			target += "?namespace=" + namespace
+		}
+		w := httptest.NewRecorder()
+		h.batch(w, httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body)))
+		return w
+	}
+
+	batch := func(request machines.BatchRequest) *httptest.ResponseRecorder {
+		return batchIn("", request)
+	}
+
+	It("limits the number of keys", func() {
+		uuids := make([]string, machines.MAX_BATCH_KEYS)
+		for i := range uuids {
+			uuids[i] = fmt.Sprintf("u%d", i)
+		}
+		Expect(batch(machines.BatchRequest{"info": {UUIDs: uuids}}).Code).To(Equal(http.StatusOK))
+
+		w := batch(machines.BatchRequest{"info": {UUIDs: append(uuids, "u")}})
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(ContainSubstring("exceeds the limit"))
+	})
+
+	It("limits the size of the request", func() {
+		w := batch(machines.BatchRequest{"info": {UUIDs: []string{strings.Repeat("u", machines.MAX_BATCH_SIZE)}}})
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+	It("restricts the lookups to a namespace", func() {
+		h.RegisterBatch(machines.PATH_BMCINFO, &namespacebatchindex{})
+		w := batchIn("tenant", machines.BatchRequest{"bmc": {UUIDs: []string{"u1"}}})
+		Expect(w.Code).To(Equal(http.StatusOK))
+		resp := machines.BatchResponse{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+		Expect(resp["bmc"].UUIDs["u1"].Namespace).To(Equal("tenant"))
+	})
+
+	It("rejects namespaces for indices without namespace lookups", func() {
+		w := batchIn("tenant", machines.BatchRequest{"info": {UUIDs: []string{"u1"}}})
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(ContainSubstring("namespace"))
+	})
+})
+
+var _ = Describe("Watch requests", func() {
+	It("restricts the events to a namespace", func() {
+		h := &requesthandler{Interface: &silent{}, feed: machines.NewChangeFeed()}
+		infos := &notifier{}
+		h.RegisterWatch(machines.PATH_MACHINEINFO, infos)
+		infos.changedIn("default", "m1")
+		infos.changedIn("tenant", "m2")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		h.watch(w, httptest.NewRequest(http.MethodGet, "/watch?since=0&namespace=tenant", nil).WithContext(ctx))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`"m2"`))
+		Expect(w.Body.String()).NotTo(ContainSubstring(`"m1"`))
+	})
+})
@@ -29,14 +29,39 @@ import (
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/module/handler"
 	"github.com/gardener/controller-manager-library/pkg/controllermanager/server"
 	"github.com/gardener/controller-manager-library/pkg/resources"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
 )
 
 type Interface = handler.SetupInterface
 
 type IndexServer interface {
 	server.Interface
-	MachineIds(r *http.Request) ([]string, []string)
+	MachineIds(r *http.Request) ([]string, []string, error)
+	FullView(r *http.Request) bool
 	ObjectResponse(w http.ResponseWriter, n resources.ObjectName)
+	JSONResponse(w http.ResponseWriter, obj interface{})
+	ErrorResponse(w http.ResponseWriter, status int, reason string, msg string)
+	NotInitializedResponse(w http.ResponseWriter, path string)
+	AmbiguousResponse(w http.ResponseWriter, matches *Matches)
+	LookupErrorResponse(w http.ResponseWriter, err error)
+	RegisterBatch(path string, index BatchIndex)
+	RegisterWatch(path string, notifier machines.IndexNotifier)
+}
+
+// BatchIndex is used by the batch endpoint to resolve single keys
+// of an index. A nil result indicates an unsupported key type.
+type BatchIndex interface {
+	IsInitialized() bool
+	LookupMAC(mac string) *machines.BatchResult
+	LookupUUID(uuid string) *machines.BatchResult
+}
+
+// NamespaceBatchIndex is implemented by batch indices able to
+// restrict the lookups to the objects of a namespace. ForNamespace
+// returns nil if the index does not support namespace lookups.
+type NamespaceBatchIndex interface {
+	ForNamespace(namespace string) BatchIndex
 }
 
 type IndexHandlerType func(IndexServer) (Interface, error)
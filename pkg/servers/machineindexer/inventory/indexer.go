@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package inventory
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/onmetal/k8s-machines/pkg/controllers"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/machines/query"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+
+	// register reguired controllers
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/bmc"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/machines"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/types"
+)
+
+// CACHE_SIZE is the number of compiled expressions kept by the server.
+const CACHE_SIZE = 200
+
+func init() {
+	machineindexer.RegisterIndex(New)
+}
+
+// indexer serves inventory queries over the full machine and
+// BMC indices.
+type indexer struct {
+	server   machineindexer.IndexServer
+	executor *query.Executor
+}
+
+func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
+	return &indexer{server: server}, nil
+}
+
+func (this *indexer) Setup() error {
+	env := this.server.GetEnvironment()
+	executor, err := query.NewExecutor(
+		controllers.GetOrCreateMachineIndex(env, func() machines.MachineIndex { return machines.NewFullIndexer() }),
+		controllers.GetOrCreateBMCIndex(env, func() machines.BMCIndex { return machines.NewBMCFullIndexer() }),
+		controllers.GetOrCreateMachineTypeIndex(env, func() machines.MachineTypeIndex { return machines.NewTypeFullIndexer() }),
+		CACHE_SIZE,
+	)
+	if err != nil {
+		return err
+	}
+	this.executor = executor
+	this.server.Register(machines.PATH_QUERY, this.handler)
+	return nil
+}
+
+func (this *indexer) IsInitialized() bool {
+	return this.executor != nil && this.executor.IsInitialized()
+}
+
+func (this *indexer) Status() *machineindexer.IndexStatus {
+	if this.executor == nil {
+		return machineindexer.NewIndexStatus(machines.PATH_QUERY, nil)
+	}
+	return machineindexer.NewIndexStatus(machines.PATH_QUERY, this.executor)
+}
+
+func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
+	this.server.Infof("query inventory: %s", r.URL.RawQuery)
+	if !this.IsInitialized() {
+		this.server.NotInitializedResponse(w, machines.PATH_QUERY)
+		return
+	}
+	values := r.URL.Query()
+	req := &query.Request{
+		Kind:      values.Get(machines.QUERY_KIND),
+		Namespace: values.Get(machines.QUERY_NAMESPACE),
+		Filter:    values.Get(machines.QUERY_FILTER),
+		Fields:    values[machines.QUERY_FIELDS],
+		Continue:  values.Get(machines.QUERY_CONTINUE),
+	}
+	if l := values.Get(machines.QUERY_LIMIT); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_INVALID_QUERY, "invalid limit "+strconv.Quote(l))
+			return
+		}
+		req.Limit = limit
+	}
+	// the queried objects require the access to their index, the
+	// joined objects of other indices are hidden without access
+	ctx := r.Context()
+	switch req.Kind {
+	case query.KIND_MACHINE, "":
+		if !machineindexer.Allowed(ctx, machines.PATH_MACHINEINFO) {
+			this.server.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, "access to machine info denied")
+			return
+		}
+		if !machineindexer.Allowed(ctx, machines.PATH_BMCINFO) {
+			req.Hidden = append(req.Hidden, query.VAR_BMC)
+		}
+		if !machineindexer.Allowed(ctx, machines.PATH_MACHINETYPE) {
+			req.Hidden = append(req.Hidden, query.VAR_TYPE)
+		}
+	case query.KIND_BMC:
+		if !machineindexer.Allowed(ctx, machines.PATH_BMCINFO) {
+			this.server.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, "access to bmc info denied")
+			return
+		}
+	}
+	resp, err := this.executor.Execute(ctx, req)
+	if err != nil {
+		if query.IsInvalid(err) {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_INVALID_QUERY, err.Error())
+		} else {
+			this.server.ErrorResponse(w, http.StatusNotImplemented, machines.REASON_INTERNAL, err.Error())
+		}
+		return
+	}
+	w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
+	this.server.JSONResponse(w, resp)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/machines/query"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// server provides the parts of the index server used by queries.
+type server struct {
+	machineindexer.IndexServer
+}
+
+func (this *server) Infof(msgfmt string, args ...interface{}) {}
+
+func (this *server) JSONResponse(w http.ResponseWriter, obj interface{}) {
+	json.NewEncoder(w).Encode(obj)
+}
+
+func (this *server) ErrorResponse(w http.ResponseWriter, status int, reason string, msg string) {
+	w.WriteHeader(status)
+}
+
+// initialized indices are considered as synchronized.
+type initialized struct {
+	*machines.MachineFullIndexer
+}
+
+func (this *initialized) IsInitialized() bool { return true }
+
+type initializedBMCs struct {
+	*machines.BMCFullIndexer
+}
+
+func (this *initializedBMCs) IsInitialized() bool { return true }
+
+var _ = Describe("Inventory", func() {
+	var h *indexer
+	var auth *machineindexer.Authenticator
+
+	BeforeEach(func() {
+		m, err := machines.NewMachine(&api.MachineInfo{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "m1"},
+			Spec:       api.MachineInfoSpec{UUID: "u1"},
+		})
+		Expect(err).To(Succeed())
+		b, err := machines.NewBaseBoardManagementController(&api.BaseBoardManagementControllerInfo{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b1"},
+			Spec:       api.BaseBoardManagementControllerInfoSpec{UUID: "u1"},
+		})
+		Expect(err).To(Succeed())
+		index := machines.NewFullIndexer().(*machines.MachineFullIndexer)
+		index.Set(m)
+		bmcs := machines.NewBMCFullIndexer().(*machines.BMCFullIndexer)
+		bmcs.Set(b)
+		executor, err := query.NewExecutor(&initialized{index}, &initializedBMCs{bmcs}, nil, CACHE_SIZE)
+		Expect(err).To(Succeed())
+		h = &indexer{server: &server{}, executor: executor}
+
+		cfg := &machineindexer.Config{TokenReview: true, Authorize: []string{"bmc=user:admin"}}
+		Expect(cfg.Prepare()).To(Succeed())
+		auth = machineindexer.NewAuthenticator(logger.New(), cfg, nil)
+	})
+
+	execute := func(user string, kind string, filter string) (int, *machines.QueryResponse) {
+		ctx := auth.WithIdentity(context.Background(), &machineindexer.Identity{User: user})
+		values := url.Values{machines.QUERY_KIND: {kind}, machines.QUERY_FILTER: {filter}}
+		w := httptest.NewRecorder()
+		h.handler(w, httptest.NewRequest(http.MethodGet, "/query?"+values.Encode(), nil).WithContext(ctx))
+		resp := &machines.QueryResponse{}
+		if w.Code == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), resp)).To(Succeed())
+		}
+		return w.Code, resp
+	}
+
+	It("requires access to the bmc index for BMC queries", func() {
+		code, _ := execute("operator", query.KIND_BMC, "true")
+		Expect(code).To(Equal(http.StatusForbidden))
+		code, resp := execute("admin", query.KIND_BMC, "true")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(len(resp.Items)).To(Equal(1))
+	})
+
+	It("hides the BMCs of machines without access to the bmc index", func() {
+		code, resp := execute("operator", query.KIND_MACHINE, `has(bmc.uuid)`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(resp.Items).To(BeEmpty())
+		code, resp = execute("admin", query.KIND_MACHINE, `has(bmc.uuid)`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(len(resp.Items)).To(Equal(1))
+	})
+})
@@ -23,6 +23,7 @@
 package machineinfo
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/onmetal/k8s-machines/pkg/controllers"
@@ -49,45 +50,117 @@ func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
 func (this *indexer) Setup() error {
 	this.index = controllers.GetOrCreateMachineIndex(this.server.GetEnvironment(), func() machines.MachineIndex { return machines.NewFullIndexer() })
 	this.server.Register(machines.PATH_MACHINEINFO, this.handler)
+	this.server.RegisterBatch(machines.PATH_MACHINEINFO, this)
+	if n, ok := this.index.(machines.IndexNotifier); ok {
+		this.server.RegisterWatch(machines.PATH_MACHINEINFO, n)
+	}
 	return nil
 }
 
-func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
-	var found *machines.Machine
+func (this *indexer) IsInitialized() bool {
+	return this.index != nil && this.index.IsInitialized()
+}
+
+func (this *indexer) Status() *machineindexer.IndexStatus {
+	return machineindexer.NewIndexStatus(machines.PATH_MACHINEINFO, this.index)
+}
 
+// ForNamespace provides the batch lookups restricted to a namespace.
+func (this *indexer) ForNamespace(namespace string) machineindexer.BatchIndex {
+	index := machines.MachineIndexForNamespace(this.index, namespace)
+	if index == nil {
+		return nil
+	}
+	return &indexer{server: this.server, index: index}
+}
+
+func (this *indexer) LookupUUID(uuid string) *machines.BatchResult {
+	if names := machineindexer.Claims(this.index, machines.KEY_UUID, uuid); names != nil {
+		return machineindexer.AmbiguousResult(names)
+	}
+	return result(this.index.GetByUUID(uuid))
+}
+
+func (this *indexer) LookupMAC(mac string) *machines.BatchResult {
+	if names := machineindexer.Claims(this.index, machines.KEY_MAC, mac); names != nil {
+		return machineindexer.AmbiguousResult(names)
+	}
+	return result(this.index.GetByMAC(mac))
+}
+
+func result(m *machines.Machine) *machines.BatchResult {
+	if m == nil {
+		return machines.NewBatchResult(nil, "")
+	}
+	return machines.NewBatchResult(m.Name, m.ResourceVersion)
+}
+
+func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
 	this.server.Infof("query machine info: %s", r.URL.RawQuery)
 	if this.index == nil || !this.index.IsInitialized() {
-		this.server.Error("no machine index found")
-		w.WriteHeader(http.StatusNotAcceptable)
+		this.server.NotInitializedResponse(w, machines.PATH_MACHINEINFO)
 		return
 	}
-	uuids, macs := this.server.MachineIds(r)
-	for _, mac := range macs {
-		m := this.index.GetByMAC(mac)
-		this.server.Infof("mac %s -> %v", mac, m)
-		if m != nil {
-			if found != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			found = m
-		}
+	uuids, macs, err := this.server.MachineIds(r)
+	if err != nil {
+		this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, err.Error())
+		return
 	}
-	for _, uuid := range uuids {
-		m := this.index.GetByUUID(uuid)
-		this.server.Infof("uuid %s -> %v", uuid, m)
-		if m != nil {
-			if found != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			found = m
+	machineindexer.MarkStale(w, this.index)
+	index := this.index
+	if ns := r.URL.Query().Get(machines.QUERY_NAMESPACE); ns != "" {
+		if index = machines.MachineIndexForNamespace(this.index, ns); index == nil {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, "namespace lookups not supported by machine info index")
+			return
 		}
 	}
+	matches := machineindexer.NewMatches()
+	if err := this.lookup(r.Context(), index, matches, machines.KEY_MAC, macs); err != nil {
+		this.server.LookupErrorResponse(w, err)
+		return
+	}
+	if err := this.lookup(r.Context(), index, matches, machines.KEY_UUID, uuids); err != nil {
+		this.server.LookupErrorResponse(w, err)
+		return
+	}
+	if matches.Ambiguous() {
+		machineindexer.CountLookup(machines.PATH_MACHINEINFO, machineindexer.LOOKUP_AMBIGUOUS)
+		this.server.AmbiguousResponse(w, matches)
+		return
+	}
+	found, _ := matches.Found().(*machines.Machine)
 	if found != nil {
+		machineindexer.CountLookup(machines.PATH_MACHINEINFO, machineindexer.LOOKUP_HIT)
 		w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
-		this.server.ObjectResponse(w, found.Name)
+		if this.server.FullView(r) {
+			this.server.JSONResponse(w, &machines.MachineInfoResponse{
+				IndexResponse: machines.NewIndexResponse(found.Name, found.ResourceVersion),
+				Spec:          found.MachineInfoSpec,
+			})
+		} else {
+			this.server.ObjectResponse(w, found.Name)
+		}
 	} else {
-		w.WriteHeader(http.StatusNotFound)
+		machineindexer.CountLookup(machines.PATH_MACHINEINFO, machineindexer.LOOKUP_MISS)
+		this.server.ErrorResponse(w, http.StatusNotFound, machines.REASON_NOT_FOUND, "no machine info found")
 	}
 }
+
+// lookup adds the matches for the given keys of a key kind.
+func (this *indexer) lookup(ctx context.Context, index machines.MachineIndex, matches *machineindexer.Matches, kind string, keys []string) error {
+	for _, key := range keys {
+		if names := machineindexer.Claims(index, kind, key); names != nil {
+			matches.AddClaims(kind, key, names)
+			continue
+		}
+		m, err := machines.LookupMachine(ctx, index, kind, key)
+		if err != nil {
+			return err
+		}
+		this.server.Infof("%s %s -> %v", kind, key, m)
+		if m != nil {
+			matches.Add(kind, key, m.Name, m)
+		}
+	}
+	return nil
+}
@@ -49,34 +49,94 @@ func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
 func (this *indexer) Setup() error {
 	this.index = controllers.GetOrCreateMachineTypeIndex(this.server.GetEnvironment(), func() machines.MachineTypeIndex { return machines.NewTypeFullIndexer() })
 	this.server.Register(machines.PATH_MACHINETYPE, this.handler)
+	this.server.RegisterBatch(machines.PATH_MACHINETYPE, this)
+	if n, ok := this.index.(machines.IndexNotifier); ok {
+		this.server.RegisterWatch(machines.PATH_MACHINETYPE, n)
+	}
 	return nil
 }
 
-func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
-	var found *machines.MachineType
+func (this *indexer) IsInitialized() bool {
+	return this.index != nil && this.index.IsInitialized()
+}
+
+func (this *indexer) Status() *machineindexer.IndexStatus {
+	return machineindexer.NewIndexStatus(machines.PATH_MACHINETYPE, this.index)
+}
+
+// ForNamespace provides the batch lookups restricted to a namespace.
+func (this *indexer) ForNamespace(namespace string) machineindexer.BatchIndex {
+	index := machines.MachineTypeIndexForNamespace(this.index, namespace)
+	if index == nil {
+		return nil
+	}
+	return &indexer{server: this.server, index: index}
+}
+
+func (this *indexer) LookupUUID(uuid string) *machines.BatchResult {
+	return nil
+}
+
+func (this *indexer) LookupMAC(mac string) *machines.BatchResult {
+	return result(this.index.GetByMAC(mac))
+}
 
+func result(m *machines.MachineType) *machines.BatchResult {
+	if m == nil {
+		return machines.NewBatchResult(nil, "")
+	}
+	return machines.NewBatchResult(m.Name, m.ResourceVersion)
+}
+
+func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
 	this.server.Infof("query machine info: %s", r.URL.RawQuery)
 	if this.index == nil || !this.index.IsInitialized() {
-		this.server.Error("no machine index found")
-		w.WriteHeader(http.StatusNotAcceptable)
+		this.server.NotInitializedResponse(w, machines.PATH_MACHINETYPE)
+		return
+	}
+	_, macs, err := this.server.MachineIds(r)
+	if err != nil {
+		this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, err.Error())
 		return
 	}
-	_, macs := this.server.MachineIds(r)
+	index := this.index
+	if ns := r.URL.Query().Get(machines.QUERY_NAMESPACE); ns != "" {
+		if index = machines.MachineTypeIndexForNamespace(this.index, ns); index == nil {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, "namespace lookups not supported by machine type index")
+			return
+		}
+	}
+	matches := machineindexer.NewMatches()
 	for _, mac := range macs {
-		m := this.index.GetByMAC(mac)
+		m, err := machines.LookupMachineType(r.Context(), index, mac)
+		if err != nil {
+			this.server.LookupErrorResponse(w, err)
+			return
+		}
 		this.server.Infof("mac %s -> %v", mac, m)
 		if m != nil {
-			if found != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			found = m
+			matches.Add(machines.KEY_MAC, mac, m.Name, m)
 		}
 	}
+	if matches.Ambiguous() {
+		machineindexer.CountLookup(machines.PATH_MACHINETYPE, machineindexer.LOOKUP_AMBIGUOUS)
+		this.server.AmbiguousResponse(w, matches)
+		return
+	}
+	found, _ := matches.Found().(*machines.MachineType)
 	if found != nil {
+		machineindexer.CountLookup(machines.PATH_MACHINETYPE, machineindexer.LOOKUP_HIT)
 		w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
-		this.server.ObjectResponse(w, found.Name)
+		if this.server.FullView(r) {
+			this.server.JSONResponse(w, &machines.MachineTypeResponse{
+				IndexResponse: machines.NewIndexResponse(found.Name, found.ResourceVersion),
+				Spec:          found.MachineTypeSpec,
+			})
+		} else {
+			this.server.ObjectResponse(w, found.Name)
+		}
 	} else {
-		w.WriteHeader(http.StatusNotFound)
+		machineindexer.CountLookup(machines.PATH_MACHINETYPE, machineindexer.LOOKUP_MISS)
+		this.server.ErrorResponse(w, http.StatusNotFound, machines.REASON_NOT_FOUND, "no machine type found")
 	}
 }
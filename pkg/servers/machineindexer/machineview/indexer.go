@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineview
+
+import (
+	"net/http"
+
+	"github.com/onmetal/k8s-machines/pkg/controllers"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+
+	// register reguired controllers
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/bmc"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/leaseindex"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/machines"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/types"
+)
+
+func init() {
+	machineindexer.RegisterIndex(New)
+}
+
+// indexer serves the aggregated machine views joining the machine
+// info, BMC, machine type and lease indices.
+type indexer struct {
+	server machineindexer.IndexServer
+	index  machines.MachineIndex
+	bmcs   machines.BMCIndex
+	types  machines.MachineTypeIndex
+	leases machines.LeaseIndex
+	views  *machines.MachineViews
+}
+
+func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
+	return &indexer{server: server}, nil
+}
+
+func (this *indexer) Setup() error {
+	env := this.server.GetEnvironment()
+	this.index = controllers.GetOrCreateMachineIndex(env, func() machines.MachineIndex { return machines.NewFullIndexer() })
+	this.bmcs = controllers.GetOrCreateBMCIndex(env, func() machines.BMCIndex { return machines.NewBMCFullIndexer() })
+	this.types = controllers.GetOrCreateMachineTypeIndex(env, func() machines.MachineTypeIndex { return machines.NewTypeFullIndexer() })
+	this.leases = controllers.GetOrCreateLeaseIndex(env, func() machines.LeaseIndex { return machines.NewLeaseFullIndexer() })
+	this.views = machines.NewMachineViews(this.index, this.bmcs, this.types, this.leases)
+	this.server.Register(machines.PATH_MACHINE, this.handler)
+	return nil
+}
+
+func (this *indexer) IsInitialized() bool {
+	return this.views != nil && this.views.IsInitialized()
+}
+
+func (this *indexer) Status() *machineindexer.IndexStatus {
+	if this.views == nil {
+		return machineindexer.NewIndexStatus(machines.PATH_MACHINE, nil)
+	}
+	return machineindexer.NewIndexStatus(machines.PATH_MACHINE, this.views)
+}
+
+func (this *indexer) handler(w http.ResponseWriter, r *http.Request) {
+	this.server.Infof("query machine view: %s", r.URL.RawQuery)
+	if !this.IsInitialized() {
+		this.server.NotInitializedResponse(w, machines.PATH_MACHINE)
+		return
+	}
+	// the view includes the machine info, so its rule applies, too
+	if !machineindexer.Allowed(r.Context(), machines.PATH_MACHINEINFO) {
+		this.server.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, "access to machine info denied")
+		return
+	}
+	uuids, macs, err := this.server.MachineIds(r)
+	if err != nil {
+		this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, err.Error())
+		return
+	}
+	index, views := this.index, this.views
+	if ns := r.URL.Query().Get(machines.QUERY_NAMESPACE); ns != "" {
+		// leases are not namespace specific
+		index = machines.MachineIndexForNamespace(this.index, ns)
+		bmcs := machines.BMCIndexForNamespace(this.bmcs, ns)
+		types := machines.MachineTypeIndexForNamespace(this.types, ns)
+		if index == nil || bmcs == nil || types == nil {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, "namespace lookups not supported by machine indices")
+			return
+		}
+		views = machines.NewMachineViews(index, bmcs, types, this.leases)
+	}
+	matches := machineindexer.NewMatches()
+	for _, mac := range macs {
+		if names := machineindexer.Claims(index, machines.KEY_MAC, mac); names != nil {
+			matches.AddClaims(machines.KEY_MAC, mac, names)
+			continue
+		}
+		if v := views.GetByMAC(mac); v != nil {
+			matches.Add(machines.KEY_MAC, mac, v.Machine.Name, v)
+		}
+	}
+	for _, uuid := range uuids {
+		if names := machineindexer.Claims(index, machines.KEY_UUID, uuid); names != nil {
+			matches.AddClaims(machines.KEY_UUID, uuid, names)
+			continue
+		}
+		if v := views.GetByUUID(uuid); v != nil {
+			matches.Add(machines.KEY_UUID, uuid, v.Machine.Name, v)
+		}
+	}
+	if matches.Ambiguous() {
+		machineindexer.CountLookup(machines.PATH_MACHINE, machineindexer.LOOKUP_AMBIGUOUS)
+		this.server.AmbiguousResponse(w, matches)
+		return
+	}
+	found, _ := matches.Found().(*machines.MachineView)
+	if found != nil {
+		machineindexer.CountLookup(machines.PATH_MACHINE, machineindexer.LOOKUP_HIT)
+		// parts of indices not accessible for the caller are omitted
+		view := *found
+		if !machineindexer.Allowed(r.Context(), machines.PATH_BMCINFO) {
+			view.BMC = nil
+		}
+		if !machineindexer.Allowed(r.Context(), machines.PATH_MACHINETYPE) {
+			view.Type = nil
+		}
+		w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
+		this.server.JSONResponse(w, view.Response())
+	} else {
+		machineindexer.CountLookup(machines.PATH_MACHINE, machineindexer.LOOKUP_MISS)
+		this.server.ErrorResponse(w, http.StatusNotFound, machines.REASON_NOT_FOUND, "no machine found")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineview
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// server provides the parts of the index server used by lookups.
+type server struct {
+	machineindexer.IndexServer
+}
+
+func (this *server) Infof(msgfmt string, args ...interface{}) {}
+
+func (this *server) MachineIds(r *http.Request) ([]string, []string, error) {
+	return r.URL.Query()["uuid"], nil, nil
+}
+
+func (this *server) JSONResponse(w http.ResponseWriter, obj interface{}) {
+	json.NewEncoder(w).Encode(obj)
+}
+
+func (this *server) ErrorResponse(w http.ResponseWriter, status int, reason string, msg string) {
+	w.WriteHeader(status)
+}
+
+// initialized indices are considered as synchronized.
+type initialized struct {
+	machines.MachineIndexer
+}
+
+func (this *initialized) IsInitialized() bool { return true }
+
+type initializedBMCs struct {
+	machines.BMCIndexer
+}
+
+func (this *initializedBMCs) IsInitialized() bool { return true }
+
+var _ = Describe("Machine View", func() {
+	var h *indexer
+	var auth *machineindexer.Authenticator
+
+	BeforeEach(func() {
+		m, err := machines.NewMachine(&api.MachineInfo{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "m1"},
+			Spec:       api.MachineInfoSpec{UUID: "u1"},
+		})
+		Expect(err).To(Succeed())
+		b, err := machines.NewBaseBoardManagementController(&api.BaseBoardManagementControllerInfo{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b1"},
+			Spec:       api.BaseBoardManagementControllerInfoSpec{UUID: "u1"},
+		})
+		Expect(err).To(Succeed())
+		index := machines.NewFullIndexer()
+		index.Set(m)
+		bmcs := machines.NewBMCFullIndexer()
+		bmcs.Set(b)
+		h = &indexer{server: &server{}, index: &initialized{index}, bmcs: &initializedBMCs{bmcs}}
+		h.views = machines.NewMachineViews(h.index, h.bmcs, nil, nil)
+
+		cfg := &machineindexer.Config{TokenReview: true, Authorize: []string{"bmc=user:admin"}}
+		Expect(cfg.Prepare()).To(Succeed())
+		auth = machineindexer.NewAuthenticator(logger.New(), cfg, nil)
+	})
+
+	lookup := func(user string) *machines.MachineViewResponse {
+		ctx := auth.WithIdentity(context.Background(), &machineindexer.Identity{User: user})
+		w := httptest.NewRecorder()
+		h.handler(w, httptest.NewRequest(http.MethodGet, "/machine?uuid=u1", nil).WithContext(ctx))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		resp := &machines.MachineViewResponse{}
+		Expect(json.Unmarshal(w.Body.Bytes(), resp)).To(Succeed())
+		Expect(resp.Machine.Name).To(Equal("m1"))
+		return resp
+	}
+
+	It("omits the BMC without access to the bmc index", func() {
+		Expect(lookup("operator").BMC).To(BeNil())
+		Expect(lookup("admin").BMC).NotTo(BeNil())
+	})
+})
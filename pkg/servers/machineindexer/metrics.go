@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/metrics"
+)
+
+const (
+	LOOKUP_HIT       = "hit"
+	LOOKUP_MISS      = "miss"
+	LOOKUP_AMBIGUOUS = "ambiguous"
+)
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexserver_requests_total",
+		Help: "Number of index server requests.",
+	}, []string{"path", "code"})
+	lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "machines_indexserver_lookups_total",
+		Help: "Number of index server lookups by result.",
+	}, []string{"path", "result"})
+	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "machines_indexserver_request_duration_seconds",
+		Help:    "Latency of index server requests.",
+		Buckets: metrics.DefBuckets,
+	}, []string{"path"})
+)
+
+func init() {
+	metrics.MustRegister(requests, lookups, latency)
+}
+
+// CountLookup records the result of a lookup for an index path.
+func CountLookup(path string, result string) {
+	lookups.WithLabelValues(path, result).Inc()
+}
+
+func countBatchResult(path string, r *machines.BatchResult) {
+	switch r.Status {
+	case machines.STATUS_FOUND:
+		CountLookup(path, LOOKUP_HIT)
+	case machines.STATUS_AMBIGUOUS:
+		CountLookup(path, LOOKUP_AMBIGUOUS)
+	default:
+		CountLookup(path, LOOKUP_MISS)
+	}
+}
+
+// instrument records the request count and latency for a path.
+// Long running watch requests are excluded from the latency.
+func instrument(path string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h(rw, r)
+		requests.WithLabelValues(path, strconv.Itoa(rw.status)).Inc()
+		if path != machines.PATH_WATCH {
+			latency.WithLabelValues(path).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (this *statusWriter) WriteHeader(code int) {
+	if !this.written {
+		this.status = code
+		this.written = true
+	}
+	this.ResponseWriter.WriteHeader(code)
+}
+
+func (this *statusWriter) Write(data []byte) (int, error) {
+	this.written = true
+	return this.ResponseWriter.Write(data)
+}
+
+func (this *statusWriter) Flush() {
+	if f, ok := this.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	srvutils "github.com/gardener/controller-manager-library/pkg/server"
+
+	"github.com/onmetal/k8s-machines/pkg/machines"
+)
+
+// PATH_READY is the path of the readiness endpoint. It is served
+// without access control to be usable by kubelet probes.
+const PATH_READY = machines.PATH_READY
+
+// IndexStatus describes the synchronization state of an index.
+type IndexStatus struct {
+	Path        string     `json:"path"`
+	Initialized bool       `json:"initialized"`
+	Objects     *int       `json:"objects,omitempty"`
+	LastSync    *time.Time `json:"lastSync,omitempty"`
+	// Stale is set while an index is served from a snapshot.
+	Stale           bool       `json:"stale,omitempty"`
+	SnapshotTime    *time.Time `json:"snapshotTime,omitempty"`
+	ConsistentSince *time.Time `json:"consistentSince,omitempty"`
+}
+
+// StatusReporter is implemented by index handlers to report the
+// synchronization state of their index.
+type StatusReporter interface {
+	Status() *IndexStatus
+}
+
+// MultiStatusReporter is implemented by index handlers serving
+// several indices.
+type MultiStatusReporter interface {
+	Statuses() []*IndexStatus
+}
+
+// ReadinessResponse is the response of the readiness endpoint.
+type ReadinessResponse struct {
+	Ready   bool                    `json:"ready"`
+	Indices map[string]*IndexStatus `json:"indices"`
+}
+
+type initializer interface {
+	IsInitialized() bool
+}
+
+// NewIndexStatus determines the status of an index. The number of
+// objects and the last sync time are reported for indices
+// implementing machines.IndexStatistics, the snapshot state for
+// indices implementing machines.SnapshotIndex.
+func NewIndexStatus(path string, index initializer) *IndexStatus {
+	status := &IndexStatus{Path: path}
+	if index == nil {
+		return status
+	}
+	status.Initialized = index.IsInitialized()
+	if s, ok := index.(machines.IndexStatistics); ok && status.Initialized {
+		size := s.Size()
+		last := s.LastSync()
+		status.Objects = &size
+		if !last.IsZero() {
+			status.LastSync = &last
+		}
+	}
+	if s, ok := index.(machines.SnapshotIndex); ok && status.Initialized {
+		status.Stale = s.IsStale()
+		if t := s.SnapshotTime(); !t.IsZero() {
+			status.SnapshotTime = &t
+		}
+		if t := s.ConsistentSince(); !t.IsZero() {
+			status.ConsistentSince = &t
+		}
+	}
+	return status
+}
+
+// MarkStale flags a response served by an index that has been
+// warm started from a snapshot and is not yet consistent.
+func MarkStale(w http.ResponseWriter, index interface{}) {
+	if s, ok := index.(machines.SnapshotIndex); ok && s.IsStale() {
+		w.Header().Set(machines.HEADER_STALE, "true")
+	}
+}
+
+// Readiness determines the status of all index handlers. It is ready
+// only if all indices are initialized.
+func (this *requesthandler) Readiness() *ReadinessResponse {
+	resp := &ReadinessResponse{Ready: true, Indices: map[string]*IndexStatus{}}
+	for _, h := range this.indexers {
+		var list []*IndexStatus
+		switch r := h.(type) {
+		case StatusReporter:
+			list = []*IndexStatus{r.Status()}
+		case MultiStatusReporter:
+			list = r.Statuses()
+		}
+		for _, s := range list {
+			resp.Indices[s.Path] = s
+			if !s.Initialized {
+				resp.Ready = false
+			}
+		}
+	}
+	return resp
+}
+
+func (this *requesthandler) registerReadiness() {
+	handler := instrument(PATH_READY, this.ready)
+	this.Interface.Register(PATH_READY, handler)
+	this.mux.HandleFunc(srvutils.NormPath(PATH_READY), handler)
+}
+
+func (this *requesthandler) ready(w http.ResponseWriter, r *http.Request) {
+	resp := this.Readiness()
+	w.Header().Set(CONTENT_TYPE, "application/json")
+	if !resp.Ready {
+		w.Header().Set("Retry-After", strconv.Itoa(RETRY_AFTER))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	this.JSONResponse(w, resp)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package machineindexer
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type index struct {
+	initialized bool
+	size        int
+	last        time.Time
+}
+
+func (this *index) IsInitialized() bool { return this.initialized }
+func (this *index) Size() int           { return this.size }
+func (this *index) LastSync() time.Time { return this.last }
+
+type statusHandler struct {
+	path  string
+	index *index
+}
+
+func (this *statusHandler) Setup() error { return nil }
+
+func (this *statusHandler) Status() *IndexStatus {
+	return NewIndexStatus(this.path, this.index)
+}
+
+var _ = Describe("Readiness", func() {
+	var info, bmc *index
+	var h *requesthandler
+
+	BeforeEach(func() {
+		info = &index{size: 3, last: time.Now()}
+		bmc = &index{}
+		h = &requesthandler{indexers: []Interface{
+			&statusHandler{"info", info},
+			&statusHandler{"bmc", bmc},
+		}}
+	})
+
+	It("is not ready before all indices are initialized", func() {
+		info.initialized = true
+		r := h.Readiness()
+		Expect(r.Ready).To(BeFalse())
+		Expect(r.Indices["info"].Initialized).To(BeTrue())
+		Expect(*r.Indices["info"].Objects).To(Equal(3))
+		Expect(r.Indices["bmc"].Initialized).To(BeFalse())
+		Expect(r.Indices["bmc"].Objects).To(BeNil())
+	})
+
+	It("is ready if all indices are initialized", func() {
+		info.initialized = true
+		bmc.initialized = true
+		r := h.Readiness()
+		Expect(r.Ready).To(BeTrue())
+		Expect(r.Indices["info"].LastSync).NotTo(BeNil())
+		Expect(r.Indices["bmc"].LastSync).To(BeNil())
+	})
+})
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package secondary
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/controllers"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+
+	// register reguired controllers
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/bmc"
+	_ "github.com/onmetal/k8s-machines/pkg/controllers/machines"
+)
+
+func init() {
+	machineindexer.RegisterIndex(New)
+}
+
+// indexer serves all registered secondary indices
+// with the path /<index>?key=<key>.
+type indexer struct {
+	server   machineindexer.IndexServer
+	indices  *machines.SecondaryIndices
+	machines machines.MachineIndex
+	bmcs     machines.BMCIndex
+}
+
+var _ machineindexer.MultiStatusReporter = &indexer{}
+
+func New(server machineindexer.IndexServer) (machineindexer.Interface, error) {
+	return &indexer{server: server}, nil
+}
+
+func (this *indexer) Setup() error {
+	env := this.server.GetEnvironment()
+	this.indices = controllers.GetOrCreateSecondaryIndices(env)
+	this.machines = controllers.GetOrCreateMachineIndex(env, func() machines.MachineIndex { return machines.NewFullIndexer() })
+	this.bmcs = controllers.GetOrCreateBMCIndex(env, func() machines.BMCIndex { return machines.NewBMCFullIndexer() })
+	for _, i := range this.indices.List() {
+		this.server.Infof("serving secondary index %s", i.Spec().Name)
+		this.server.Register(i.Spec().Name, this.handler(i))
+	}
+	return nil
+}
+
+func (this *indexer) Statuses() []*machineindexer.IndexStatus {
+	var result []*machineindexer.IndexStatus
+	for _, i := range this.indices.List() {
+		result = append(result, machineindexer.NewIndexStatus(i.Spec().Name, i))
+	}
+	return result
+}
+
+// primary maps the kinds of secondary indices to the
+// paths of their primary indices.
+var primary = map[schema.GroupKind]string{
+	api.MACHINEINFO:                       machines.PATH_MACHINEINFO,
+	api.BASEBOARDMANAGEMENTCONTROLLERINFO: machines.PATH_BMCINFO,
+}
+
+func (this *indexer) handler(index *machines.SecondaryIndex) http.HandlerFunc {
+	path := index.Spec().Name
+	return func(w http.ResponseWriter, r *http.Request) {
+		this.server.Infof("query %s: %s", path, r.URL.RawQuery)
+		// the index reveals objects of a primary index,
+		// so the rule of the primary index applies, too
+		if p := primary[index.Spec().Kind]; !machineindexer.Allowed(r.Context(), p) {
+			this.server.ErrorResponse(w, http.StatusForbidden, machines.REASON_FORBIDDEN, fmt.Sprintf("access to index %s denied", p))
+			return
+		}
+		if !index.IsInitialized() {
+			this.server.NotInitializedResponse(w, path)
+			return
+		}
+		keys := r.URL.Query()[machines.QUERY_KEY]
+		if len(keys) == 0 {
+			this.server.ErrorResponse(w, http.StatusBadRequest, machines.REASON_BAD_REQUEST, fmt.Sprintf("query parameter %q required", machines.QUERY_KEY))
+			return
+		}
+		namespace := r.URL.Query().Get(machines.QUERY_NAMESPACE)
+		matches := machineindexer.NewMatches()
+		for _, k := range keys {
+			for _, n := range index.Lookup(k) {
+				if namespace != "" && n.Namespace() != namespace {
+					continue
+				}
+				matches.Add(path, k, n, n)
+			}
+		}
+		if matches.Ambiguous() {
+			machineindexer.CountLookup(path, machineindexer.LOOKUP_AMBIGUOUS)
+			this.server.AmbiguousResponse(w, matches)
+			return
+		}
+		name, _ := matches.Found().(resources.ObjectName)
+		if name == nil {
+			machineindexer.CountLookup(path, machineindexer.LOOKUP_MISS)
+			this.server.ErrorResponse(w, http.StatusNotFound, machines.REASON_NOT_FOUND, fmt.Sprintf("no object found in index %s", path))
+			return
+		}
+		machineindexer.CountLookup(path, machineindexer.LOOKUP_HIT)
+		w.Header().Set(machineindexer.CONTENT_TYPE, "application/json")
+		if this.server.FullView(r) {
+			this.server.JSONResponse(w, this.fullView(index.Spec(), name))
+		} else {
+			this.server.ObjectResponse(w, name)
+		}
+	}
+}
+
+// fullView provides the indexed spec of the primary index
+// for the kind of the secondary index.
+func (this *indexer) fullView(spec *machines.SecondaryIndexSpec, name resources.ObjectName) interface{} {
+	switch spec.Kind {
+	case api.MACHINEINFO:
+		if m := this.machines.GetByName(name); m != nil {
+			return &machines.MachineInfoResponse{
+				IndexResponse: machines.NewIndexResponse(m.Name, m.ResourceVersion),
+				Spec:          m.MachineInfoSpec,
+			}
+		}
+	case api.BASEBOARDMANAGEMENTCONTROLLERINFO:
+		if m := machines.RedactBMC(this.bmcs.GetByName(name)); m != nil {
+			return &machines.BMCInfoResponse{
+				IndexResponse: machines.NewIndexResponse(m.Name, m.ResourceVersion),
+				Spec:          m.BaseBoardManagementControllerInfoSpec,
+			}
+		}
+	}
+	r := machines.NewIndexResponse(name, "")
+	return &r
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2020 by The metal-stack Authors.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package secondary
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gardener/controller-manager-library/pkg/logger"
+	"github.com/gardener/controller-manager-library/pkg/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/onmetal/k8s-machines/pkg/apis/machines/v1alpha1"
+	"github.com/onmetal/k8s-machines/pkg/machines"
+	"github.com/onmetal/k8s-machines/pkg/servers/machineindexer"
+)
+
+// server provides the parts of the index server used by lookups.
+type server struct {
+	machineindexer.IndexServer
+}
+
+func (this *server) Infof(msgfmt string, args ...interface{}) {}
+
+func (this *server) ErrorResponse(w http.ResponseWriter, status int, reason string, msg string) {
+	w.WriteHeader(status)
+}
+
+func (this *server) NotInitializedResponse(w http.ResponseWriter, path string) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+var _ = Describe("Secondary Index", func() {
+	It("applies the rule of the primary index", func() {
+		cfg := &machineindexer.Config{TokenReview: true, Authorize: []string{"bmc=user:admin"}}
+		Expect(cfg.Prepare()).To(Succeed())
+		auth := machineindexer.NewAuthenticator(logger.New(), cfg, nil)
+		keys := func(obj resources.ObjectData) []string { return nil }
+		h := &indexer{server: &server{}}
+
+		lookup := func(kind schema.GroupKind, user string) int {
+			index := machines.NewSecondaryIndex(&machines.SecondaryIndexSpec{Name: "serial", Kind: kind, Keys: keys})
+			ctx := auth.WithIdentity(context.Background(), &machineindexer.Identity{User: user})
+			w := httptest.NewRecorder()
+			h.handler(index)(w, httptest.NewRequest(http.MethodGet, "/serial?key=s1", nil).WithContext(ctx))
+			return w.Code
+		}
+
+		// the unsynchronized index reports its state after the authorization
+		Expect(lookup(api.BASEBOARDMANAGEMENTCONTROLLERINFO, "operator")).To(Equal(http.StatusForbidden))
+		Expect(lookup(api.BASEBOARDMANAGEMENTCONTROLLERINFO, "admin")).To(Equal(http.StatusServiceUnavailable))
+		Expect(lookup(api.MACHINEINFO, "operator")).To(Equal(http.StatusServiceUnavailable))
+	})
+})
@@ -32,11 +32,33 @@ const NAME = "machineindex"
 func init() {
 	server.Configure(NAME).
 		Port(8090).
+		OptionsByExample("options", &Config{}).
 		RegisterHandler("index", Create).
 		ActivateExplicitly().
 		MustRegister()
 }
 
 func Create(srv server.Interface) (handler.Interface, error) {
-	return &requesthandler{Interface: srv}, nil
+	opts, err := srv.GetOptionSource("options")
+	if err != nil {
+		return nil, err
+	}
+	cfg := opts.(*Config)
+	err = cfg.Prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	reviewer, err := CreateTokenReviewer(srv.GetEnvironment(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer != nil {
+		srv.Infof("  using token reviews")
+	}
+	return &requesthandler{
+		Interface: srv,
+		config:    cfg,
+		auth:      NewAuthenticator(srv, cfg, reviewer),
+	}, nil
 }